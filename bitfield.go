@@ -0,0 +1,227 @@
+package svd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// Geometry of the bit-field diagrams, in pixels.
+const (
+	bitfieldBitWidth = 28
+	bitfieldMargin   = 4
+	bitfieldLane     = 72
+)
+
+// AccessLabel : Get the short name of the access policy of a field
+// For example RW, RO, WO, W1C (write one to clear) or RC (read to clear).
+func (f ResolvedField) AccessLabel() string {
+	label := "RW"
+	switch f.Access {
+	case AccessReadOnly:
+		label = "RO"
+	case AccessWriteOnly:
+		label = "WO"
+	case AccessWriteOnce:
+		label = "W1"
+	case AccessReadWriteOnce:
+		label = "RW1"
+	}
+	switch f.ModifiedWriteValues {
+	case ModifiedWriteValuesOneToClear:
+		label = "W1C"
+	case ModifiedWriteValuesOneToSet:
+		label = "W1S"
+	case ModifiedWriteValuesOneToToggle:
+		label = "W1T"
+	case ModifiedWriteValuesZeroToClear:
+		label = "W0C"
+	case ModifiedWriteValuesZeroToSet:
+		label = "W0S"
+	case ModifiedWriteValuesZeroToToggle:
+		label = "W0T"
+	case ModifiedWriteValuesClear:
+		label = "WC"
+	case ModifiedWriteValuesSet:
+		label = "WS"
+	}
+	switch f.ReadAction {
+	case ReadActionClear:
+		label += ",RC"
+	case ReadActionSet:
+		label += ",RS"
+	case ReadActionModify, ReadActionModifyExternal:
+		label += ",RM"
+	}
+	return label
+}
+
+// bitfieldSegment : a field, or a reserved gap, of the register
+type bitfieldSegment struct {
+	name     string
+	lsb, msb uint
+	access   string
+	reserved bool
+}
+
+// segments : Get the fields and reserved gaps of the register, lsb first
+// Fields overlapping a previous one or beyond the register size are left
+// out and reported in err.
+func (r ResolvedRegister) segments() (segs []bitfieldSegment, err error) {
+	fields := append([]ResolvedField{}, r.bitFields()...)
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Lsb < fields[j].Lsb })
+	var problems []string
+	next := uint(0)
+	var last ResolvedField
+	for _, f := range fields {
+		if f.Msb >= r.Size {
+			problems = append(problems, fmt.Sprintf("field %s %s exceeds the %d bits of the register", f.Name, bitsLabel(f.Lsb, f.Msb), r.Size))
+			continue
+		}
+		if f.Lsb < next {
+			problems = append(problems, fmt.Sprintf("field %s %s overlaps field %s %s", f.Name, bitsLabel(f.Lsb, f.Msb), last.Name, bitsLabel(last.Lsb, last.Msb)))
+			continue
+		}
+		last = f
+		if f.Lsb > next {
+			segs = append(segs, bitfieldSegment{lsb: next, msb: f.Lsb - 1, reserved: true})
+		}
+		segs = append(segs, bitfieldSegment{name: f.Name, lsb: f.Lsb, msb: f.Msb, access: f.AccessLabel()})
+		next = f.Msb + 1
+	}
+	if next < r.Size {
+		segs = append(segs, bitfieldSegment{lsb: next, msb: r.Size - 1, reserved: true})
+	}
+	if problems != nil {
+		err = fmt.Errorf("register %s: %s", r.Name, strings.Join(problems, ", "))
+	}
+	return
+}
+
+// BitfieldSVG : Draw the bit-field diagram of the register as SVG
+// Bits are drawn most significant first, 32 bits per lane. Each field shows
+// its name, bit span and access; reserved bits are greyed out and every bit
+// holds its reset value (x when undefined). Overlapping fields cannot be
+// drawn: the diagram is still returned, without them, along with an error.
+func (r ResolvedRegister) BitfieldSVG() ([]byte, error) {
+	segs, err := r.segments()
+	laneBits := r.Size
+	if laneBits > 32 {
+		laneBits = 32
+	}
+	if laneBits == 0 {
+		laneBits = 1
+	}
+	lanes := (r.Size + laneBits - 1) / laneBits
+	width := int(laneBits)*bitfieldBitWidth + 2*bitfieldMargin
+	height := int(lanes) * bitfieldLane
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(r.Name))
+	// x position of the left edge of a bit in its lane
+	x := func(bit uint) int {
+		return bitfieldMargin + int(laneBits-1-bit%laneBits)*bitfieldBitWidth
+	}
+	for _, s := range segs {
+		for lane := s.lsb / laneBits; lane <= s.msb/laneBits; lane++ {
+			lsb, msb := s.lsb, s.msb
+			if lsb < lane*laneBits {
+				lsb = lane * laneBits
+			}
+			if msb >= (lane+1)*laneBits {
+				msb = (lane+1)*laneBits - 1
+			}
+			y := int(lanes-1-lane) * bitfieldLane
+			left, right := x(msb), x(lsb)+bitfieldBitWidth
+			fill := "#ffffff"
+			if s.reserved {
+				fill = "#d8d8d8"
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="32" fill="%s" stroke="#000"/>`+"\n",
+				left, y+14, right-left, fill)
+			fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="start">%d</text>`+"\n", left+2, y+11, msb)
+			if msb != lsb {
+				fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%d</text>`+"\n", right-2, y+11, lsb)
+			}
+			for bit := lsb; bit <= msb; bit++ {
+				v := "x"
+				if r.ResetMask>>bit&1 == 1 {
+					v = fmt.Sprint(r.ResetValue >> bit & 1)
+				}
+				color := "#555"
+				if s.reserved {
+					color = "#888"
+				}
+				fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" font-size="9" fill="%s">%s</text>`+"\n",
+					x(bit)+bitfieldBitWidth/2, y+42, color, v)
+				if bit != lsb {
+					fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#bbb"/>`+"\n",
+						x(bit)+bitfieldBitWidth, y+34, x(bit)+bitfieldBitWidth, y+46)
+				}
+			}
+			if s.reserved {
+				continue
+			}
+			span := right - left
+			fit := ""
+			if n := len(s.name) * 7; n > span-4 {
+				fit = fmt.Sprintf(` textLength="%d" lengthAdjust="spacingAndGlyphs"`, span-4)
+			}
+			fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle"%s>%s</text>`+"\n",
+				(left+right)/2, y+28, fit, html.EscapeString(s.name))
+			fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" font-size="9" fill="#036">%s</text>`+"\n",
+				(left+right)/2, y+58, s.access)
+		}
+	}
+	b.WriteString("</svg>\n")
+	return b.Bytes(), err
+}
+
+// wavedromField : a field of a WaveDrom bitfield description
+type wavedromField struct {
+	Bits int           `json:"bits"`
+	Name string        `json:"name,omitempty"`
+	Attr []interface{} `json:"attr,omitempty"`
+}
+
+// WaveDrom : Describe the bit-field diagram of the register as WaveDrom JSON
+// Fields are listed from the least significant bit; the attributes of a
+// field are its reset value (shown as bits) and its access. Overlapping
+// fields are reported as an error.
+func (r ResolvedRegister) WaveDrom() ([]byte, error) {
+	segs, err := r.segments()
+	if err != nil {
+		return nil, err
+	}
+	var desc struct {
+		Reg    []wavedromField `json:"reg"`
+		Config struct {
+			Bits   uint `json:"bits"`
+			Lanes  uint `json:"lanes"`
+			Hspace int  `json:"hspace"`
+		} `json:"config"`
+	}
+	for _, s := range segs {
+		f := wavedromField{Bits: int(s.msb - s.lsb + 1)}
+		if !s.reserved {
+			f.Name = s.name
+			mask := bitMask(uint(f.Bits)) << s.lsb
+			if r.ResetMask&mask == mask {
+				f.Attr = append(f.Attr, (r.ResetValue&mask)>>s.lsb)
+			}
+			f.Attr = append(f.Attr, s.access)
+		}
+		desc.Reg = append(desc.Reg, f)
+	}
+	desc.Config.Bits = r.Size
+	desc.Config.Lanes = (r.Size + 31) / 32
+	if desc.Config.Lanes == 0 {
+		desc.Config.Lanes = 1
+	}
+	desc.Config.Hspace = int(r.Size/desc.Config.Lanes) * bitfieldBitWidth
+	return json.Marshal(desc)
+}
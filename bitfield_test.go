@@ -0,0 +1,96 @@
+package svd
+
+import (
+	"strings"
+	"testing"
+)
+
+func bitfieldTestRegister() ResolvedRegister {
+	return ResolvedRegister{
+		Name:       "CTRL",
+		Size:       8,
+		Access:     AccessReadWrite,
+		ResetValue: 0x05,
+		ResetMask:  0x0F,
+		Fields: []ResolvedField{
+			{Name: "MODE", Lsb: 2, Msb: 3, Access: AccessReadOnly},
+			{Name: "EN", Lsb: 0, Msb: 0, Access: AccessReadWrite, ModifiedWriteValues: ModifiedWriteValuesOneToClear},
+		},
+	}
+}
+
+func TestResolvedRegister_WaveDrom(t *testing.T) {
+	overlap := bitfieldTestRegister()
+	overlap.Fields = append(overlap.Fields, ResolvedField{Name: "BAD", Lsb: 1, Msb: 2})
+	tests := []struct {
+		name    string
+		reg     ResolvedRegister
+		want    string
+		wantErr string
+	}{
+		{
+			name: "reserved gaps",
+			reg:  bitfieldTestRegister(),
+			want: `{"reg":[{"bits":1,"name":"EN","attr":[1,"W1C"]},{"bits":1},{"bits":2,"name":"MODE","attr":[1,"RO"]},{"bits":4}],` +
+				`"config":{"bits":8,"lanes":1,"hspace":224}}`,
+		},
+		{
+			name:    "overlap",
+			reg:     overlap,
+			wantErr: "register CTRL: field MODE [3:2] overlaps field BAD [2:1]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.reg.WaveDrom()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("ResolvedRegister.WaveDrom() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.want {
+				t.Errorf("ResolvedRegister.WaveDrom() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvedRegister_BitfieldSVG(t *testing.T) {
+	svg, err := bitfieldTestRegister().BitfieldSVG()
+	if err != nil {
+		t.Fatalf("ResolvedRegister.BitfieldSVG() error = %v", err)
+	}
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="232" height="72"`,
+		`<title>CTRL</title>`,
+		// EN, bit 0 at the right
+		`<rect x="200" y="14" width="28" height="32" fill="#ffffff" stroke="#000"/>`,
+		`>EN</text>`, `>W1C</text>`,
+		// reserved bit 1
+		`<rect x="172" y="14" width="28" height="32" fill="#d8d8d8" stroke="#000"/>`,
+		`<rect x="116" y="14" width="56" height="32" fill="#ffffff" stroke="#000"/>`,
+		`>MODE</text>`, `>RO</text>`,
+		// reserved bits 7:4 without defined reset
+		`<rect x="4" y="14" width="112" height="32" fill="#d8d8d8" stroke="#000"/>`,
+		`fill="#888">x</text>`,
+	} {
+		if !strings.Contains(string(svg), want) {
+			t.Errorf("ResolvedRegister.BitfieldSVG() does not contain %s:\n%s", want, svg)
+		}
+	}
+	if n := strings.Count(string(svg), "<rect"); n != 4 {
+		t.Errorf("ResolvedRegister.BitfieldSVG() draws %d segments, want 4", n)
+	}
+
+	overlap := bitfieldTestRegister()
+	overlap.Fields = append(overlap.Fields, ResolvedField{Name: "BAD", Lsb: 1, Msb: 2}, ResolvedField{Name: "HIGH", Lsb: 8, Msb: 9})
+	svg, err = overlap.BitfieldSVG()
+	want := "register CTRL: field MODE [3:2] overlaps field BAD [2:1], field HIGH [9:8] exceeds the 8 bits of the register"
+	if err == nil || err.Error() != want {
+		t.Errorf("ResolvedRegister.BitfieldSVG() error = %v, want %s", err, want)
+	}
+	if strings.Contains(string(svg), ">MODE<") || !strings.Contains(string(svg), ">BAD<") {
+		t.Errorf("ResolvedRegister.BitfieldSVG() with overlap = %s", svg)
+	}
+}
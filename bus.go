@@ -0,0 +1,175 @@
+package svd
+
+import (
+	"fmt"
+	"time"
+)
+
+// Bus : access to the memory mapped registers of a target
+// Width is the number of bits of the access (8, 16, 32 or 64).
+type Bus interface {
+	Read(address uint64, width uint) (uint64, error)
+	Write(address uint64, width uint, value uint64) error
+}
+
+// Handle : a register of the device accessed through a Bus
+type Handle struct {
+	ResolvedRegister
+
+	// Bus used for the accesses.
+	Bus Bus
+}
+
+// Handle : Get a handle on a register by its "peripheral.register" name
+func (dev *Device) Handle(bus Bus, path string) (*Handle, error) {
+	r, err := dev.ResolveRegister(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Handle{ResolvedRegister: *r, Bus: bus}, nil
+}
+
+// Read : Read the register
+func (h *Handle) Read() (uint64, error) {
+	return h.Bus.Read(h.Address, h.Size)
+}
+
+// Write : Write the register
+func (h *Handle) Write(value uint64) error {
+	return h.Bus.Write(h.Address, h.Size, value&h.Mask())
+}
+
+// GetField : Read the register and get the value of a field
+func (h *Handle) GetField(name string) (uint64, error) {
+	f := h.Field(name)
+	if f == nil {
+		return 0, fmt.Errorf("register %s has no field %s", h.Path(), name)
+	}
+	value, err := h.Read()
+	return f.Extract(value), err
+}
+
+// SetField : Read-modify-write the register to change the value of a field
+// The other fields written with side effects (oneToClear, zeroToSet, ...)
+// are given the value without effect.
+func (h *Handle) SetField(name string, value uint64) error {
+	f := h.Field(name)
+	if f == nil {
+		return fmt.Errorf("register %s has no field %s", h.Path(), name)
+	}
+	if value > bitMask(f.Width()) {
+		return fmt.Errorf("value 0x%X does not fit in field %s.%s", value, h.Path(), name)
+	}
+	var current uint64
+	if h.Access != AccessWriteOnly && h.Access != AccessWriteOnce {
+		var err error
+		if current, err = h.Read(); err != nil {
+			return err
+		}
+	}
+	for _, o := range h.Fields {
+		if o.Name == name {
+			continue
+		}
+		switch o.ModifiedWriteValues {
+		case ModifiedWriteValuesOneToClear, ModifiedWriteValuesOneToSet, ModifiedWriteValuesOneToToggle:
+			current &^= o.Mask()
+		case ModifiedWriteValuesZeroToClear, ModifiedWriteValuesZeroToSet, ModifiedWriteValuesZeroToToggle:
+			current |= o.Mask()
+		}
+	}
+	return h.Write(f.Insert(current, value))
+}
+
+// BusAccess : an access seen on a bus
+type BusAccess struct {
+	// Time of the access, relative to the start of the trace.
+	Time time.Duration
+
+	Address uint64
+	Width   uint
+	Value   uint64
+	Write   bool
+}
+
+// String : Describe the access
+func (a BusAccess) String() string {
+	dir := "R"
+	if a.Write {
+		dir = "W"
+	}
+	return fmt.Sprintf("%s 0x%08X/%d 0x%X", dir, a.Address, a.Width, a.Value)
+}
+
+// Recorder : a Bus recording the accesses made to another Bus
+type Recorder struct {
+	// Bus receiving the accesses.
+	Bus Bus
+
+	// Recorded accesses.
+	Accesses []BusAccess
+
+	start time.Time
+}
+
+// NewRecorder : Create a Recorder on a Bus
+func NewRecorder(bus Bus) *Recorder {
+	return &Recorder{Bus: bus, start: time.Now()}
+}
+
+// Read : Read from the Bus and record the access
+func (rec *Recorder) Read(address uint64, width uint) (uint64, error) {
+	value, err := rec.Bus.Read(address, width)
+	if err == nil {
+		rec.Accesses = append(rec.Accesses, BusAccess{time.Since(rec.start), address, width, value, false})
+	}
+	return value, err
+}
+
+// Write : Write to the Bus and record the access
+func (rec *Recorder) Write(address uint64, width uint, value uint64) error {
+	err := rec.Bus.Write(address, width, value)
+	if err == nil {
+		rec.Accesses = append(rec.Accesses, BusAccess{time.Since(rec.start), address, width, value, true})
+	}
+	return err
+}
+
+// Replay : a Bus playing back a recorded trace
+// Reads return the recorded values and writes must match the trace.
+type Replay struct {
+	// Accesses to play back.
+	Accesses []BusAccess
+
+	// Index of the next expected access.
+	Position int
+}
+
+// next : Check the next access of the trace
+func (rep *Replay) next(access BusAccess) (BusAccess, error) {
+	if rep.Position >= len(rep.Accesses) {
+		return access, fmt.Errorf("unexpected %v after end of trace", access)
+	}
+	want := rep.Accesses[rep.Position]
+	if want.Write != access.Write || want.Address != access.Address || want.Width != access.Width ||
+		(access.Write && want.Value != access.Value) {
+		return want, fmt.Errorf("access %d is %v, trace has %v", rep.Position, access, want)
+	}
+	rep.Position++
+	return want, nil
+}
+
+// Read : Get the recorded value of the next read
+func (rep *Replay) Read(address uint64, width uint) (uint64, error) {
+	a, err := rep.next(BusAccess{Address: address, Width: width})
+	if err != nil {
+		return 0, err
+	}
+	return a.Value, nil
+}
+
+// Write : Check the next write against the trace
+func (rep *Replay) Write(address uint64, width uint, value uint64) error {
+	_, err := rep.next(BusAccess{Address: address, Width: width, Value: value, Write: true})
+	return err
+}
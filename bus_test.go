@@ -0,0 +1,178 @@
+package svd
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// gdbRLE : Run-length encode a reply as gdbserver does
+// Counts that would give '#' or '$' are shortened.
+func gdbRLE(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		n := 1
+		for i+n < len(s) && s[i+n] == s[i] && n < 98 {
+			n++
+		}
+		repeat := n - 1
+		if repeat+29 == '#' || repeat+29 == '$' {
+			repeat = '"' - 29
+		}
+		if repeat < 3 {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		b.WriteByte(s[i])
+		b.WriteByte('*')
+		b.WriteByte(byte(repeat + 29))
+		i += repeat + 1
+	}
+	return b.String()
+}
+
+// serveGDB : minimal GDB remote serial protocol stub serving a Bus
+func serveGDB(conn net.Conn, bus Bus) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	for {
+		if c, err := rd.ReadByte(); err != nil {
+			return
+		} else if c != '$' {
+			continue
+		}
+		data, err := rd.ReadString('#')
+		if err != nil {
+			return
+		}
+		io.ReadFull(rd, make([]byte, 2))
+		io.WriteString(conn, "+")
+		data = strings.TrimSuffix(data, "#")
+		reply := "E01"
+		switch data[0] {
+		case 'm':
+			var addr, size uint64
+			fmt.Sscanf(data[1:], "%x,%x", &addr, &size)
+			if v, err := bus.Read(addr, uint(size*8)); err == nil {
+				b := make([]byte, size)
+				for i := range b {
+					b[i] = byte(v >> (8 * uint(i)))
+				}
+				reply = gdbRLE(hex.EncodeToString(b))
+			}
+		case 'M':
+			parts := strings.SplitN(data[1:], ":", 2)
+			args := strings.Split(parts[0], ",")
+			addr, _ := strconv.ParseUint(args[0], 16, 64)
+			b, _ := hex.DecodeString(parts[1])
+			var v uint64
+			for i := range b {
+				v |= uint64(b[i]) << (8 * uint(i))
+			}
+			if bus.Write(addr, uint(len(b)*8), v) == nil {
+				reply = "OK"
+			}
+		}
+		var sum byte
+		for i := 0; i < len(reply); i++ {
+			sum += reply[i]
+		}
+		fmt.Fprintf(conn, "$%s#%02x", reply, sum)
+		if _, err := rd.ReadByte(); err != nil {
+			return
+		}
+	}
+}
+
+func Test_gdbDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{"plain", "0102", "0102", false},
+		{"run-length", "0*\"00", "00000000", false},
+		{"escape", "a}\x03}]", "a#}", false},
+		{"run without character", "*\"", "", true},
+		{"truncated escape", "0}", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gdbDecode(tt.data)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("gdbDecode() = %q, %v, want %q, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHandle(t *testing.T) {
+	dev := simTestDevice()
+	sim, err := NewSimulator(dev)
+	if err != nil {
+		t.Fatalf("NewSimulator() error = %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback network: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			serveGDB(conn, sim)
+		}
+	}()
+	gdb, err := DialGDB(ln.Addr().String(), EndianLittle)
+	if err != nil {
+		t.Fatalf("DialGDB() error = %v", err)
+	}
+	defer gdb.Close()
+	rec := NewRecorder(gdb)
+
+	cr, err := dev.Handle(rec, "UART0.CR")
+	if err != nil {
+		t.Fatalf("Device.Handle() error = %v", err)
+	}
+	if err := cr.SetField("UARTEN", 1); err != nil {
+		t.Fatalf("Handle.SetField() error = %v", err)
+	}
+	if got := sim.Register("UART0.CR").Value; got != 0x301 {
+		t.Errorf("CR = 0x%X, want 0x301", got)
+	}
+	if err := cr.SetField("UARTEN", 2); err == nil {
+		t.Errorf("Handle.SetField() accepted a value wider than the field")
+	}
+
+	sim.Register("UART0.SR").Value = 0x3
+	sr, _ := dev.Handle(rec, "UART0.SR")
+	if err := sr.SetField("TXEMPTY", 0); err != nil {
+		t.Fatalf("Handle.SetField() error = %v", err)
+	}
+	if got, _ := sr.GetField("OVR"); got != 1 {
+		t.Errorf("OVR = %d, want 1 (not cleared by the read-modify-write)", got)
+	}
+	if _, err := dev.Handle(rec, "UART0.XX"); err == nil {
+		t.Errorf("Device.Handle() of unknown register succeeded")
+	}
+
+	replay := &Replay{Accesses: rec.Accesses}
+	cr.Bus = replay
+	if err := cr.SetField("UARTEN", 1); err != nil {
+		t.Errorf("replayed Handle.SetField() error = %v", err)
+	}
+	if err := cr.SetField("TXE", 0); err == nil {
+		t.Errorf("replay accepted a write not in the trace")
+	}
+
+	// a register of zeros comes run-length encoded
+	if got, err := gdb.Read(0x40000008, 32); err != nil || got != 0 {
+		t.Errorf("GDBBus.Read() of DR = 0x%X, %v, want 0", got, err)
+	}
+}
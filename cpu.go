@@ -75,16 +75,18 @@ const (
 type Region struct {
 	// Specify whether the Secure Attribution Units are enabled.
 	// Default value is true.
-	Enabled bool `xml:"enabled,attr,omitempty"`
+	Enabled *bool `xml:"enabled,attr,omitempty"`
 
 	// Identifiy the region with a name.
 	Name string `xml:"name,attr,omitempty"`
 
 	// Base address of the region.
-	Base uint `xml:"base"`
+	// The address must be 32-byte aligned.
+	Base string `xml:"base"`
 
 	// Limit address of the region.
-	Limit uint `xml:"limit"`
+	// The region ends with the 32-byte block starting at this address.
+	Limit string `xml:"limit"`
 
 	// Define the acces type of a region.
 	Access RegionAccessType `xml:"access"`
@@ -92,7 +94,8 @@ type Region struct {
 
 type SauRegionsConfigType struct {
 	// Specify whether the Secure Attribution Units are enabled.
-	Enabled bool `xml:"enabled,attr,omitempty"`
+	// Default value is true.
+	Enabled *bool `xml:"enabled,attr,omitempty"`
 
 	// Set the protection mode for disabled regions.
 	// When the complete SAU is disabled, the whole memory is treated
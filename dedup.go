@@ -0,0 +1,333 @@
+package svd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Derivation : an element replaced by a reference to an identical one
+type Derivation struct {
+	// Path of the replaced element: "peripheral" for a peripheral,
+	// "peripheral.register.field" for enumerated values.
+	Path string
+
+	// Reference written in its derivedFrom.
+	DerivedFrom string
+}
+
+// String : Describe the derivation
+func (d Derivation) String() string {
+	return d.Path + " derivedFrom " + d.DerivedFrom
+}
+
+// sameRegisterSet : Tell whether two peripherals hold the same registers
+// with the same register defaults.
+func sameRegisterSet(a, b *Peripheral) bool {
+	return a.Size == b.Size && a.Access == b.Access && a.Protection == b.Protection &&
+		a.ResetValue == b.ResetValue && a.ResetMask == b.ResetMask &&
+		reflect.DeepEqual(a.Registers, b.Registers)
+}
+
+// peripheralReferences : Get the peripherals named by a qualified path
+// The derivedFrom of registers, clusters, fields and enumerated values,
+// and the alternateRegister and alternateCluster, can start with a
+// peripheral name. These paths are searched in the peripheral registers
+// as written, so the peripherals they name must keep their name and
+// registers.
+func (dev *Device) peripheralReferences() map[string]bool {
+	names := make(map[string]bool)
+	for _, p := range dev.Peripherals.Peripheral {
+		names[p.Name] = true
+	}
+	refs := make(map[string]bool)
+	ref := func(path string) {
+		if i := strings.Index(path, "."); i > 0 && names[path[:i]] {
+			refs[path[:i]] = true
+		}
+	}
+	var walk func(registers []Register, clusters []Cluster)
+	walk = func(registers []Register, clusters []Cluster) {
+		for _, r := range registers {
+			ref(r.DerivedFrom)
+			ref(r.AlternateRegister)
+			if r.Fields == nil {
+				continue
+			}
+			for _, f := range r.Fields.Field {
+				ref(f.DerivedFrom)
+				if ev := f.EnumeratedValues; ev != nil {
+					ref(ev.DerivedFrom)
+				}
+			}
+		}
+		for _, c := range clusters {
+			ref(c.DerivedFrom)
+			ref(c.AlternateCluster)
+			walk(c.Register, c.Cluster)
+		}
+	}
+	for _, p := range dev.Peripherals.Peripheral {
+		if p.Registers != nil {
+			walk(p.Registers.Register, p.Registers.Cluster)
+		}
+	}
+	return refs
+}
+
+// derivePeripherals : Make the peripherals identical to a previous one derive from it
+func (dev *Device) derivePeripherals() (derived []Derivation) {
+	refs := dev.peripheralReferences()
+	periphs := dev.Peripherals.Peripheral
+	var bases []*Peripheral
+	for i := range periphs {
+		p := &periphs[i]
+		if p.DerivedFrom != "" || p.Registers == nil || p.Dim != 0 {
+			continue
+		}
+		var base *Peripheral
+		for _, b := range bases {
+			if sameRegisterSet(p, b) {
+				base = b
+				break
+			}
+		}
+		if base == nil || refs[p.Name] {
+			bases = append(bases, p)
+			continue
+		}
+		derived = append(derived, Derivation{Path: p.Name, DerivedFrom: base.Name})
+		p.DerivedFrom = base.Name
+		p.Registers = nil
+		p.Size, p.Access, p.Protection, p.ResetValue, p.ResetMask = 0, "", "", 0, 0
+		if p.Description == base.Description {
+			p.Description = ""
+		}
+		if p.GroupName == base.GroupName {
+			p.GroupName = ""
+		}
+		if p.Version == base.Version {
+			p.Version = ""
+		}
+		if p.HeaderStructName == base.HeaderStructName {
+			p.HeaderStructName = ""
+		}
+		if reflect.DeepEqual(p.AddressBlock, base.AddressBlock) {
+			p.AddressBlock = nil
+		}
+	}
+	return derived
+}
+
+// foldPeripherals : Rewrite identical peripherals at a regular stride as peripheral arrays
+// Members must have no interrupt, which an array could not tell apart,
+// must not be derived from outside the array and must not be named by a
+// qualified path (see peripheralReferences).
+func (dev *Device) foldPeripherals() (folds []ArrayFold, err error) {
+	periphs := dev.Peripherals.Peripheral
+	refs := dev.peripheralReferences()
+	effective := make([]*Peripheral, len(periphs))
+	elems := make([]foldElement, len(periphs))
+	for i := range periphs {
+		p := &periphs[i]
+		if effective[i], err = dev.derivedPeripheral(p, 0); err != nil {
+			return nil, err
+		}
+		base, err := ParseNumber(p.BaseAddress)
+		if err != nil {
+			return nil, fmt.Errorf("peripheral %s baseAddress: %v", p.Name, err)
+		}
+		elems[i] = foldElement{name: p.Name, offset: base, size: 1}
+		for _, ab := range effective[i].AddressBlock {
+			size, err := ParseNumber(ab.Size)
+			if err != nil {
+				return nil, fmt.Errorf("peripheral %s addressBlock size: %v", p.Name, err)
+			}
+			if end := uint64(ab.Offset) + size; end > elems[i].size {
+				elems[i].size = end
+			}
+		}
+		if p.Dim != 0 || p.AlternatePeripheral != "" || len(p.Interrupt) != 0 {
+			elems[i].name = "%s"
+		}
+	}
+	same := func(i, j int) bool {
+		a, b := *effective[i], *effective[j]
+		a.Name, a.BaseAddress, a.DerivedFrom = "", "", ""
+		b.Name, b.BaseAddress, b.DerivedFrom = "", "", ""
+		return reflect.DeepEqual(a, b)
+	}
+	var runs []foldRun
+	for _, run := range foldRuns(elems, same) {
+		members := make(map[string]bool)
+		for _, m := range run.members {
+			members[periphs[m].Name] = true
+		}
+		outside := false
+		for _, p := range periphs {
+			outside = outside || refs[p.Name] && members[p.Name] ||
+				members[p.DerivedFrom] && !members[p.Name]
+		}
+		if outside {
+			continue
+		}
+		runs = append(runs, run)
+		fold := ArrayFold{Scope: dev.Name, Name: run.name, Increment: run.inc}
+		for _, m := range run.members {
+			fold.Members = append(fold.Members, periphs[m].Name)
+		}
+		folds = append(folds, fold)
+	}
+	for _, run := range runs {
+		first := run.members[0]
+		p := periphs[first]
+		for _, m := range run.members[1:] {
+			if p.DerivedFrom == periphs[m].Name {
+				p = *effective[first]
+				p.DerivedFrom = ""
+			}
+		}
+		p.Name = run.name
+		p.Dim = uint(len(run.members))
+		p.DimIncrement = uint(run.inc)
+		p.DimIndex = foldDimIndex(run.indices)
+		periphs[first] = p
+	}
+	keep := foldKeep(len(periphs), runs)
+	var kept []Peripheral
+	for i, p := range periphs {
+		if keep[i] {
+			kept = append(kept, p)
+		}
+	}
+	dev.Peripherals.Peripheral = kept
+	return folds, nil
+}
+
+// deriveEnumeratedValues : Make the enumerated values identical to previous ones derive from them
+// Only enumerated values of a plain register at the top of a peripheral
+// can be referenced; they are named after their field when unnamed.
+func (dev *Device) deriveEnumeratedValues() (derived []Derivation) {
+	type enumSite struct {
+		path string
+		ev   *EnumeratedValues
+		base bool
+	}
+	var sites []enumSite
+	var walk func(scope string, registers []Register, clusters []Cluster, top bool)
+	walk = func(scope string, registers []Register, clusters []Cluster, top bool) {
+		for i := range registers {
+			r := &registers[i]
+			if r.Fields == nil {
+				continue
+			}
+			for j := range r.Fields.Field {
+				f := &r.Fields.Field[j]
+				ev := f.EnumeratedValues
+				if ev == nil || ev.DerivedFrom != "" || len(ev.EnumeratedValue) == 0 {
+					continue
+				}
+				path := scope + "." + r.Name + "." + f.Name
+				sites = append(sites, enumSite{path, ev, top && !strings.Contains(path, "%")})
+			}
+		}
+		for i := range clusters {
+			c := &clusters[i]
+			walk(scope+"."+c.Name, c.Register, c.Cluster, false)
+		}
+	}
+	for i := range dev.Peripherals.Peripheral {
+		p := &dev.Peripherals.Peripheral[i]
+		if p.Registers != nil {
+			walk(p.Name, p.Registers.Register, p.Registers.Cluster, true)
+		}
+	}
+	same := func(a, b *EnumeratedValues) bool {
+		return a.Usage == b.Usage && reflect.DeepEqual(a.EnumeratedValue, b.EnumeratedValue)
+	}
+	var bases []enumSite
+	for _, s := range sites {
+		if !s.base {
+			continue
+		}
+		known := false
+		for _, b := range bases {
+			known = known || same(s.ev, b.ev)
+		}
+		if !known {
+			bases = append(bases, s)
+		}
+	}
+	for _, s := range sites {
+		for _, b := range bases {
+			if b.ev == s.ev || !same(s.ev, b.ev) {
+				continue
+			}
+			if b.ev.Name == "" {
+				b.ev.Name = b.path[strings.LastIndex(b.path, ".")+1:]
+			}
+			ref := b.path + "." + b.ev.Name
+			derived = append(derived, Derivation{Path: s.path, DerivedFrom: ref})
+			*s.ev = EnumeratedValues{DerivedFrom: ref, Name: s.ev.Name, HeaderEnumName: s.ev.HeaderEnumName}
+			break
+		}
+	}
+	return derived
+}
+
+// cloneValue : Get a deep copy of a value
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(cloneValue(v.Field(i)))
+			}
+		}
+		return c
+	}
+	return v
+}
+
+// Deduplicate : Replace identical peripherals and enumerated values by derivations
+// A peripheral holding the same registers as a previous one derives from
+// it. Identical peripherals without interrupts, named alike but for an
+// index and at a regular stride then become a peripheral array. Last,
+// enumerated values identical to previous ones derive from them. With
+// dryRun the device is left unchanged and only the changes that would be
+// made are returned.
+func (dev *Device) Deduplicate(dryRun bool) (derived []Derivation, arrays []ArrayFold, err error) {
+	target := dev
+	if dryRun {
+		target = cloneValue(reflect.ValueOf(dev)).Interface().(*Device)
+	}
+	periphs := target.derivePeripherals()
+	if arrays, err = target.foldPeripherals(); err != nil {
+		return nil, nil, err
+	}
+	for _, d := range periphs {
+		if target.FindPeripheral(d.Path) != nil {
+			derived = append(derived, d)
+		}
+	}
+	derived = append(derived, target.deriveEnumeratedValues()...)
+	return derived, arrays, nil
+}
@@ -0,0 +1,128 @@
+package svd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func dedupTestDevice() *Device {
+	onOff := func() *EnumeratedValues {
+		return &EnumeratedValues{EnumeratedValue: []EnumeratedValue{
+			{Name: "OFF", Value: "0"},
+			{Name: "ON", Value: "1"},
+		}}
+	}
+	uart := func() *Registers {
+		return &Registers{Register: []Register{{
+			Name: "CR", AddressOffset: "0x0", Fields: &Fields{Field: []Field{
+				{Name: "EN", BitRange: "[0:0]", EnumeratedValues: onOff()},
+				{Name: "RXEN", BitRange: "[1:1]", EnumeratedValues: onOff()},
+			}},
+		}}}
+	}
+	gpio := func() *Registers {
+		return &Registers{Register: []Register{{Name: "DATA", AddressOffset: "0x0"}}}
+	}
+	block := []AddressBlock{{Offset: 0, Size: "0x400", Usage: UsageRegisters}}
+	dev := NewDevice("DedupDevice")
+	dev.Peripherals.Peripheral = []Peripheral{
+		{Name: "UART0", GroupName: "UART", BaseAddress: "0x40000000", AddressBlock: block,
+			Interrupt: []Interrupt{{Name: "UART0", Value: "1"}}, Registers: uart()},
+		{Name: "UART1", GroupName: "UART", BaseAddress: "0x40001000", AddressBlock: block,
+			Interrupt: []Interrupt{{Name: "UART1", Value: "2"}}, Registers: uart()},
+		{Name: "GPIOA", Description: "GPIO port", BaseAddress: "0x50000000", AddressBlock: block, Registers: gpio()},
+		{Name: "GPIOB", DerivedFrom: "GPIOA", BaseAddress: "0x50000400"},
+		{Name: "GPIOC", Description: "GPIO port", BaseAddress: "0x50000800", AddressBlock: block, Registers: gpio()},
+	}
+	return dev
+}
+
+func TestDevice_Deduplicate(t *testing.T) {
+	wantDerived := []Derivation{
+		{Path: "UART1", DerivedFrom: "UART0"},
+		{Path: "UART0.CR.RXEN", DerivedFrom: "UART0.CR.EN.EN"},
+	}
+	wantArrays := []ArrayFold{{Scope: "DedupDevice", Name: "GPIO%s",
+		Members: []string{"GPIOA", "GPIOB", "GPIOC"}, Increment: 0x400}}
+
+	dev := dedupTestDevice()
+	derived, arrays, err := dev.Deduplicate(true)
+	if err != nil {
+		t.Fatalf("Device.Deduplicate() error = %v", err)
+	}
+	if !reflect.DeepEqual(derived, wantDerived) || !reflect.DeepEqual(arrays, wantArrays) {
+		t.Errorf("Device.Deduplicate() dry run = %v %v, want %v %v", derived, arrays, wantDerived, wantArrays)
+	}
+	if !reflect.DeepEqual(dev, dedupTestDevice()) {
+		t.Errorf("Device.Deduplicate() dry run changed the device")
+	}
+	before, err := dev.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if derived, arrays, err = dev.Deduplicate(false); err != nil {
+		t.Fatalf("Device.Deduplicate() error = %v", err)
+	}
+	if !reflect.DeepEqual(derived, wantDerived) || !reflect.DeepEqual(arrays, wantArrays) {
+		t.Errorf("Device.Deduplicate() = %v %v, want %v %v", derived, arrays, wantDerived, wantArrays)
+	}
+	want := []Peripheral{
+		dedupTestDevice().Peripherals.Peripheral[0],
+		{Name: "UART1", DerivedFrom: "UART0", BaseAddress: "0x40001000",
+			Interrupt: []Interrupt{{Name: "UART1", Value: "2"}}},
+		{Name: "GPIO%s", Description: "GPIO port", BaseAddress: "0x50000000", Dim: 3, DimIncrement: 0x400,
+			DimIndex: "A-C", AddressBlock: []AddressBlock{{Offset: 0, Size: "0x400", Usage: UsageRegisters}},
+			Registers: &Registers{Register: []Register{{Name: "DATA", AddressOffset: "0x0"}}}},
+	}
+	fields := want[0].Registers.Register[0].Fields.Field
+	fields[0].EnumeratedValues.Name = "EN"
+	fields[1].EnumeratedValues = &EnumeratedValues{DerivedFrom: "UART0.CR.EN.EN"}
+	if !reflect.DeepEqual(dev.Peripherals.Peripheral, want) {
+		t.Errorf("Device.Deduplicate() peripherals = %+v, want %+v", dev.Peripherals.Peripheral, want)
+	}
+
+	after, err := dev.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before) {
+		t.Fatalf("Device.Resolve() gives %d peripherals, want %d", len(after), len(before))
+	}
+	for i := range before {
+		if after[i].Name != before[i].Name || after[i].BaseAddress != before[i].BaseAddress {
+			t.Errorf("peripheral %d = %s@0x%X, want %s@0x%X", i, after[i].Name, after[i].BaseAddress,
+				before[i].Name, before[i].BaseAddress)
+		}
+	}
+	cr := after[1].Register("CR")
+	if ev := cr.Field("RXEN").EnumeratedValues; ev == nil || len(ev.EnumeratedValue) != 2 {
+		t.Errorf("enumerated values of UART1.CR.RXEN = %+v", ev)
+	}
+}
+
+func TestDevice_Deduplicate_referencedMember(t *testing.T) {
+	block := []AddressBlock{{Offset: 0, Size: "0x400", Usage: UsageRegisters}}
+	uart := func(name, base string) Peripheral {
+		return Peripheral{Name: name, BaseAddress: base, AddressBlock: block,
+			Registers: &Registers{Register: []Register{{Name: "CR", AddressOffset: "0x0"}}}}
+	}
+	dev := NewDevice("RefDevice")
+	dev.Peripherals.Peripheral = []Peripheral{
+		uart("UART0", "0x40000000"),
+		uart("UART1", "0x40000400"),
+		uart("UART2", "0x40000800"),
+		{Name: "SPI", BaseAddress: "0x50000000", AddressBlock: block,
+			Registers: &Registers{Register: []Register{{Name: "CR", DerivedFrom: "UART1.CR", AddressOffset: "0x0"}}}},
+	}
+	_, arrays, err := dev.Deduplicate(false)
+	if err != nil {
+		t.Fatalf("Device.Deduplicate() error = %v", err)
+	}
+	if len(arrays) != 0 {
+		t.Errorf("Device.Deduplicate() folded %v, UART1 is referenced", arrays)
+	}
+	if _, err := dev.Resolve(); err != nil {
+		t.Errorf("Device.Resolve() after Deduplicate() error = %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package svd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// DocFormat : text format of the register reference
+type DocFormat string
+
+const (
+	DocMarkdown DocFormat = "markdown"
+	DocAsciiDoc DocFormat = "asciidoc"
+)
+
+// docWriter : markup of a text document
+type docWriter interface {
+	heading(level int, text, anchor string)
+	paragraph(text string)
+	table(header []string, rows [][]string)
+	link(text, file, anchor string) string
+	code(text string) string
+	lineBreak() string
+	Bytes() []byte
+}
+
+// markdownWriter : GitHub flavoured Markdown
+type markdownWriter struct{ bytes.Buffer }
+
+func (w *markdownWriter) heading(level int, text, anchor string) {
+	if anchor != "" {
+		fmt.Fprintf(w, "<a id=\"%s\"></a>\n\n", anchor)
+	}
+	fmt.Fprintf(w, "%s %s\n\n", strings.Repeat("#", level), text)
+}
+
+func (w *markdownWriter) paragraph(text string) {
+	if text != "" {
+		fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(text))
+	}
+}
+
+func (w *markdownWriter) table(header []string, rows [][]string) {
+	esc := strings.NewReplacer("|", "\\|", "\r", "", "\n", " ")
+	line := func(cells []string) {
+		for _, c := range cells {
+			fmt.Fprintf(w, "| %s ", esc.Replace(c))
+		}
+		w.WriteString("|\n")
+	}
+	line(header)
+	w.WriteString(strings.Repeat("|---", len(header)) + "|\n")
+	for _, r := range rows {
+		line(r)
+	}
+	w.WriteString("\n")
+}
+
+func (w *markdownWriter) link(text, file, anchor string) string {
+	return fmt.Sprintf("[%s](%s#%s)", text, file, anchor)
+}
+
+func (w *markdownWriter) code(text string) string {
+	return "`" + text + "`"
+}
+
+func (w *markdownWriter) lineBreak() string {
+	return "<br>"
+}
+
+// asciiDocWriter : AsciiDoc
+type asciiDocWriter struct{ bytes.Buffer }
+
+func (w *asciiDocWriter) heading(level int, text, anchor string) {
+	if anchor != "" {
+		fmt.Fprintf(w, "[[%s]]\n", anchor)
+	}
+	fmt.Fprintf(w, "%s %s\n\n", strings.Repeat("=", level), text)
+}
+
+func (w *asciiDocWriter) paragraph(text string) {
+	if text != "" {
+		fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(text))
+	}
+}
+
+func (w *asciiDocWriter) table(header []string, rows [][]string) {
+	esc := strings.NewReplacer("|", "\\|", "\r", "")
+	fmt.Fprintf(w, "[options=\"header\"]\n|===\n")
+	for _, c := range header {
+		fmt.Fprintf(w, "|%s ", esc.Replace(c))
+	}
+	w.WriteString("\n")
+	for _, r := range rows {
+		for _, c := range r {
+			fmt.Fprintf(w, "|%s ", esc.Replace(c))
+		}
+		w.WriteString("\n")
+	}
+	w.WriteString("|===\n\n")
+}
+
+func (w *asciiDocWriter) link(text, file, anchor string) string {
+	if file == "" {
+		return fmt.Sprintf("<<%s,%s>>", anchor, text)
+	}
+	return fmt.Sprintf("xref:%s#%s[%s]", file, anchor, text)
+}
+
+func (w *asciiDocWriter) code(text string) string {
+	return "`" + text + "`"
+}
+
+func (w *asciiDocWriter) lineBreak() string {
+	return " +\n"
+}
+
+// docPeripheralSection : Write the reference of a peripheral
+func docPeripheralSection(w docWriter, p docPeripheral, level int, file func(string) string) {
+	w.heading(level, p.Name, p.Name)
+	w.paragraph(p.Description)
+	info := [][]string{{"Base address", w.code(p.Base)}}
+	if p.Group != "" {
+		info = append(info, []string{"Group", p.Group})
+	}
+	if p.DerivedFrom != "" {
+		info = append(info, []string{"Derived from", w.link(p.DerivedFrom, file(p.DerivedInstance), p.DerivedInstance)})
+	}
+	for _, it := range p.Interrupts {
+		info = append(info, []string{"Interrupt", fmt.Sprintf("%s (%s) %s", it.Name, it.Value, it.Description)})
+	}
+	w.table([]string{"Property", "Value"}, info)
+	var summary [][]string
+	for _, r := range p.Registers {
+		summary = append(summary, []string{w.code(r.Offset), w.link(r.Name, file(p.Name), p.Name+"_"+r.Name),
+			string(r.Access), w.code(r.Reset), r.Description})
+	}
+	if len(summary) > 0 {
+		w.table([]string{"Offset", "Name", "Access", "Reset", "Description"}, summary)
+	}
+	for _, r := range p.Registers {
+		w.heading(level+1, p.Name+"."+r.Name, p.Name+"_"+r.Name)
+		w.paragraph(r.Description)
+		props := fmt.Sprintf("Address %s, offset %s, %d bits, %s, reset %s.",
+			w.code(r.Address), w.code(r.Offset), r.Size, r.Access, w.code(r.Reset))
+		if r.DerivedFrom != "" {
+			props += " Derived from " + r.DerivedFrom + "."
+		}
+		w.paragraph(props)
+		if len(r.Fields) == 0 {
+			continue
+		}
+		var rows [][]string
+		for _, f := range r.Fields {
+			var values []string
+			for _, e := range f.Enums {
+				v := e.Value
+				if e.IsDefault {
+					v = "default"
+				}
+				s := fmt.Sprintf("%s: %s", w.code(v), e.Name)
+				if e.Description != "" {
+					s += " - " + e.Description
+				}
+				values = append(values, s)
+			}
+			rows = append(rows, []string{f.Bits, f.Name, string(f.Access), w.code(f.Reset), f.Description,
+				strings.Join(values, w.lineBreak())})
+		}
+		w.table([]string{"Bits", "Name", "Access", "Reset", "Description", "Values"}, rows)
+	}
+}
+
+// Reference : Generate the register reference as Markdown or AsciiDoc
+// With split, every peripheral gets its own file next to an index file,
+// otherwise a single document named after the device is produced.
+func (dev *Device) Reference(format DocFormat, split bool) (files map[string][]byte, err error) {
+	pages, err := dev.documentation()
+	if err != nil {
+		return nil, err
+	}
+	ext := ".md"
+	newWriter := func() docWriter { return &markdownWriter{} }
+	if format == DocAsciiDoc {
+		ext = ".adoc"
+		newWriter = func() docWriter { return &asciiDocWriter{} }
+	} else if format != DocMarkdown {
+		return nil, fmt.Errorf("unknown documentation format %q", format)
+	}
+	file := func(string) string { return "" }
+	if split {
+		file = func(name string) string { return docFileRe.ReplaceAllString(name, "_") + ext }
+	}
+	files = make(map[string][]byte)
+	index := newWriter()
+	index.heading(1, dev.Name, "")
+	index.paragraph(dev.Description)
+	var rows [][]string
+	for _, p := range pages {
+		rows = append(rows, []string{index.link(p.Name, file(p.Name), p.Name), index.code(p.Base), p.Group, p.Description})
+	}
+	index.table([]string{"Peripheral", "Base address", "Group", "Description"}, rows)
+	for _, p := range pages {
+		if split {
+			w := newWriter()
+			docPeripheralSection(w, p, 1, file)
+			files[file(p.Name)] = w.Bytes()
+		} else {
+			docPeripheralSection(index, p, 2, file)
+		}
+	}
+	name := dev.Name + ext
+	if split {
+		name = "index" + ext
+	}
+	files[name] = index.Bytes()
+	return
+}
@@ -0,0 +1,215 @@
+package svd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func docTestDevice() *Device {
+	dev := NewDevice("DocDevice")
+	dev.Description = "Documented device"
+	dev.Peripherals.Peripheral = []Peripheral{
+		{
+			Name:        "UART",
+			Description: "Serial port",
+			GroupName:   "COM",
+			BaseAddress: "0x40000000",
+			Registers: &Registers{Register: []Register{
+				{
+					Name:          "CR",
+					Description:   "Control",
+					AddressOffset: "0x4",
+					ResetValue:    "0x00000001",
+					Fields: &Fields{Field: []Field{
+						{Name: "EN", Description: "Enable", BitRange: "[0:0]", EnumeratedValues: &EnumeratedValues{EnumeratedValue: []EnumeratedValue{
+							{Name: "off", Value: "0"},
+							{Name: "on", Description: "Running", Value: "1"},
+						}}},
+					}},
+				},
+			}},
+		},
+	}
+	return dev
+}
+
+func TestDevice_Reference(t *testing.T) {
+	tests := []struct {
+		name   string
+		format DocFormat
+		split  bool
+		want   map[string]string
+	}{
+		{
+			name:   "markdown",
+			format: DocMarkdown,
+			want: map[string]string{"DocDevice.md": "# DocDevice\n" +
+				"\n" +
+				"Documented device\n" +
+				"\n" +
+				"| Peripheral | Base address | Group | Description |\n" +
+				"|---|---|---|---|\n" +
+				"| [UART](#UART) | `0x40000000` | COM | Serial port |\n" +
+				"\n" +
+				"<a id=\"UART\"></a>\n" +
+				"\n" +
+				"## UART\n" +
+				"\n" +
+				"Serial port\n" +
+				"\n" +
+				"| Property | Value |\n" +
+				"|---|---|\n" +
+				"| Base address | `0x40000000` |\n" +
+				"| Group | COM |\n" +
+				"\n" +
+				"| Offset | Name | Access | Reset | Description |\n" +
+				"|---|---|---|---|---|\n" +
+				"| `0x004` | [CR](#UART_CR) | read-write | `0x00000001` | Control |\n" +
+				"\n" +
+				"<a id=\"UART_CR\"></a>\n" +
+				"\n" +
+				"### UART.CR\n" +
+				"\n" +
+				"Control\n" +
+				"\n" +
+				"Address `0x40000004`, offset `0x004`, 32 bits, read-write, reset `0x00000001`.\n" +
+				"\n" +
+				"| Bits | Name | Access | Reset | Description | Values |\n" +
+				"|---|---|---|---|---|---|\n" +
+				"| [0] | EN | read-write | `0x1` | Enable | `0`: off<br>`1`: on - Running |\n" +
+				"\n"},
+		},
+		{
+			name:   "asciidoc",
+			format: DocAsciiDoc,
+			want: map[string]string{"DocDevice.adoc": "= DocDevice\n" +
+				"\n" +
+				"Documented device\n" +
+				"\n" +
+				"[options=\"header\"]\n" +
+				"|===\n" +
+				"|Peripheral |Base address |Group |Description \n" +
+				"|<<UART,UART>> |`0x40000000` |COM |Serial port \n" +
+				"|===\n" +
+				"\n" +
+				"[[UART]]\n" +
+				"== UART\n" +
+				"\n" +
+				"Serial port\n" +
+				"\n" +
+				"[options=\"header\"]\n" +
+				"|===\n" +
+				"|Property |Value \n" +
+				"|Base address |`0x40000000` \n" +
+				"|Group |COM \n" +
+				"|===\n" +
+				"\n" +
+				"[options=\"header\"]\n" +
+				"|===\n" +
+				"|Offset |Name |Access |Reset |Description \n" +
+				"|`0x004` |<<UART_CR,CR>> |read-write |`0x00000001` |Control \n" +
+				"|===\n" +
+				"\n" +
+				"[[UART_CR]]\n" +
+				"=== UART.CR\n" +
+				"\n" +
+				"Control\n" +
+				"\n" +
+				"Address `0x40000004`, offset `0x004`, 32 bits, read-write, reset `0x00000001`.\n" +
+				"\n" +
+				"[options=\"header\"]\n" +
+				"|===\n" +
+				"|Bits |Name |Access |Reset |Description |Values \n" +
+				"|[0] |EN |read-write |`0x1` |Enable |`0`: off +\n" +
+				"`1`: on - Running \n" +
+				"|===\n" +
+				"\n"},
+		},
+		{
+			name:   "markdown split",
+			format: DocMarkdown,
+			split:  true,
+			want: map[string]string{
+				"index.md": "# DocDevice\n" +
+					"\n" +
+					"Documented device\n" +
+					"\n" +
+					"| Peripheral | Base address | Group | Description |\n" +
+					"|---|---|---|---|\n" +
+					"| [UART](UART.md#UART) | `0x40000000` | COM | Serial port |\n" +
+					"\n",
+				"UART.md": "<a id=\"UART\"></a>\n" +
+					"\n" +
+					"# UART\n" +
+					"\n" +
+					"Serial port\n" +
+					"\n" +
+					"| Property | Value |\n" +
+					"|---|---|\n" +
+					"| Base address | `0x40000000` |\n" +
+					"| Group | COM |\n" +
+					"\n" +
+					"| Offset | Name | Access | Reset | Description |\n" +
+					"|---|---|---|---|---|\n" +
+					"| `0x004` | [CR](UART.md#UART_CR) | read-write | `0x00000001` | Control |\n" +
+					"\n" +
+					"<a id=\"UART_CR\"></a>\n" +
+					"\n" +
+					"## UART.CR\n" +
+					"\n" +
+					"Control\n" +
+					"\n" +
+					"Address `0x40000004`, offset `0x004`, 32 bits, read-write, reset `0x00000001`.\n" +
+					"\n" +
+					"| Bits | Name | Access | Reset | Description | Values |\n" +
+					"|---|---|---|---|---|---|\n" +
+					"| [0] | EN | read-write | `0x1` | Enable | `0`: off<br>`1`: on - Running |\n" +
+					"\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := docTestDevice().Reference(tt.format, tt.split)
+			if err != nil {
+				t.Fatalf("Device.Reference() error = %v", err)
+			}
+			got := make(map[string]string)
+			for name, content := range files {
+				got[name] = string(content)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Device.Reference() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if _, err := docTestDevice().Reference("html", false); err == nil {
+		t.Errorf("Device.Reference() with an unknown format succeeded, want an error")
+	}
+}
+
+func TestDevice_Reference_derivedArray(t *testing.T) {
+	dev := docTestDevice()
+	uart := &dev.Peripherals.Peripheral[0]
+	uart.Name, uart.Dim, uart.DimIncrement = "UART%s", 2, 0x1000
+	dev.Peripherals.Peripheral = append(dev.Peripherals.Peripheral,
+		Peripheral{Name: "SPI", DerivedFrom: "UART%s", BaseAddress: "0x40010000"})
+	tests := []struct {
+		split bool
+		file  string
+		want  string
+	}{
+		{false, "DocDevice.md", "| Derived from | [UART%s](#UART0) |\n"},
+		{true, "SPI.md", "| Derived from | [UART%s](UART0.md#UART0) |\n"},
+	}
+	for _, tt := range tests {
+		files, err := dev.Reference(DocMarkdown, tt.split)
+		if err != nil {
+			t.Fatalf("Device.Reference() error = %v", err)
+		}
+		if got := string(files[tt.file]); !strings.Contains(got, tt.want) {
+			t.Errorf("Device.Reference() %s = %s, want %q", tt.file, got, tt.want)
+		}
+	}
+}
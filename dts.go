@@ -0,0 +1,152 @@
+package svd
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// DtsNode : a peripheral instance as a devicetree node
+type DtsNode struct {
+	// Node label, the lowercase instance name, e.g. uart0.
+	Label string
+
+	// Node name, the lowercase group or instance name, e.g. uart.
+	Name string
+
+	// Unit address, the base address of the instance.
+	Base uint64
+
+	// Compatible string, "<vendor>,<group>".
+	Compatible string
+
+	// Address and size of every address block.
+	Reg []AddressRange
+
+	// Interrupt numbers.
+	Interrupts []uint
+
+	// Peripheral the instance comes from.
+	Peripheral *Peripheral
+}
+
+// RegCells : Format the reg property cells
+func (n DtsNode) RegCells() string {
+	var cells []string
+	for _, r := range n.Reg {
+		cells = append(cells, fmt.Sprintf("0x%x 0x%x", r.First, r.Last-r.First+1))
+	}
+	return strings.Join(cells, " ")
+}
+
+// DtsTemplates : templates of the devicetree nodes
+// Templates are text/template executed with a DtsNode; the one named after
+// the group of the peripheral is used, or the one named "" by default.
+type DtsTemplates map[string]string
+
+// DtsDefaultNode : default template of a peripheral node
+// reg is left out for a peripheral without address block. Interrupts are
+// written as <number priority> pairs, as expected by the ARMv7-M NVIC
+// binding.
+const DtsDefaultNode = `{{.Label}}: {{.Name}}@{{printf "%x" .Base}} {
+	compatible = "{{.Compatible}}";
+{{- if .Reg}}
+	reg = <{{.RegCells}}>;
+{{- end}}
+{{- if .Interrupts}}
+	interrupts = <{{range $i, $n := .Interrupts}}{{if $i}} {{end}}{{$n}} 0{{end}}>;
+{{- end}}
+	status = "disabled";
+};
+`
+
+var dtsNameRe = regexp.MustCompile(`[^a-z0-9,._+-]+`)
+
+// dtsName : Make a lowercase node or compatible name
+func dtsName(s string) string {
+	return strings.Trim(dtsNameRe.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// dtsVendor : Make a compatible vendor prefix, such as arm-ltd for "ARM Ltd."
+// The prefix must not hold the ',' separator nor '.'.
+func dtsVendor(s string) string {
+	return strings.Trim(dtsNameRe.ReplaceAllString(strings.ToLower(strings.NewReplacer(".", "", ",", " ").Replace(s)), "-"), "-")
+}
+
+var dtsLabelRe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// dtsLabel : Make a lowercase node label
+// Labels only hold letters, digits and '_'.
+func dtsLabel(s string) string {
+	return strings.ToLower(dtsLabelRe.ReplaceAllString(s, "_"))
+}
+
+// Dtsi : Generate a devicetree include with a node per peripheral instance
+// Nodes are placed under /soc, sorted by base address, and rendered with
+// the template of their group, or DtsDefaultNode.
+func (dev *Device) Dtsi(templates DtsTemplates) ([]byte, error) {
+	tmpl := template.New("")
+	if _, ok := templates[""]; !ok {
+		template.Must(tmpl.New("").Parse(DtsDefaultNode))
+	}
+	for group, text := range templates {
+		if _, err := tmpl.New(group).Parse(text); err != nil {
+			return nil, fmt.Errorf("template of group %q: %v", group, err)
+		}
+	}
+	instances, err := dev.peripheralInstances()
+	if err != nil {
+		return nil, err
+	}
+	vendor := dtsVendor(dev.Vendor)
+	if vendor == "" {
+		vendor = dtsVendor(dev.VendorID)
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "/* %s peripherals, generated from its SVD description */\n\n", dev.Name)
+	b.WriteString("/ {\n\tsoc {\n\t\t#address-cells = <1>;\n\t\t#size-cells = <1>;\n")
+	b.WriteString("\t\tcompatible = \"simple-bus\";\n\t\tranges;\n")
+	for _, inst := range instances {
+		group := inst.group
+		if group == "" {
+			group = inst.name
+		}
+		n := DtsNode{
+			Label:      dtsLabel(inst.name),
+			Name:       dtsName(group),
+			Base:       inst.base,
+			Compatible: dtsName(group),
+			Reg:        inst.ranges,
+			Peripheral: inst.peripheral,
+		}
+		if vendor != "" {
+			n.Compatible = vendor + "," + n.Compatible
+		}
+		for _, it := range inst.peripheral.Interrupt {
+			num, err := ParseNumber(it.Value)
+			if err != nil {
+				return nil, fmt.Errorf("peripheral %s interrupt %s: %v", inst.name, it.Name, err)
+			}
+			n.Interrupts = append(n.Interrupts, uint(num))
+		}
+		t := tmpl.Lookup(inst.group)
+		if t == nil {
+			t = tmpl.Lookup("")
+		}
+		var node bytes.Buffer
+		if err := t.Execute(&node, n); err != nil {
+			return nil, fmt.Errorf("peripheral %s: %v", inst.name, err)
+		}
+		b.WriteString("\n")
+		for _, line := range strings.Split(strings.TrimRight(node.String(), "\n"), "\n") {
+			if line != "" {
+				line = "\t\t" + line
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	b.WriteString("\t};\n};\n")
+	return b.Bytes(), nil
+}
@@ -0,0 +1,130 @@
+package svd
+
+import (
+	"testing"
+)
+
+func dtsTestDevice() *Device {
+	dev := NewDevice("DtsDevice")
+	dev.Vendor = "ARM Ltd."
+	dev.Peripherals.Peripheral = []Peripheral{
+		{
+			Name:         "TIMER0",
+			GroupName:    "TIMER",
+			BaseAddress:  "0x40010000",
+			AddressBlock: []AddressBlock{{Offset: 0, Size: "0x100", Usage: UsageRegisters}},
+			Interrupt:    []Interrupt{{Name: "TIMER0", Value: "8"}},
+		},
+		{Name: "TIMER1", DerivedFrom: "TIMER0", BaseAddress: "0x40011000"},
+		{Name: "SYSCTRL", BaseAddress: "0x40000000"},
+	}
+	return dev
+}
+
+func TestDevice_Dtsi(t *testing.T) {
+	tests := []struct {
+		name      string
+		templates DtsTemplates
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "default",
+			want: `/* DtsDevice peripherals, generated from its SVD description */
+
+/ {
+	soc {
+		#address-cells = <1>;
+		#size-cells = <1>;
+		compatible = "simple-bus";
+		ranges;
+
+		sysctrl: sysctrl@40000000 {
+			compatible = "arm-ltd,sysctrl";
+			status = "disabled";
+		};
+
+		timer0: timer@40010000 {
+			compatible = "arm-ltd,timer";
+			reg = <0x40010000 0x100>;
+			interrupts = <8 0>;
+			status = "disabled";
+		};
+
+		timer1: timer@40011000 {
+			compatible = "arm-ltd,timer";
+			reg = <0x40011000 0x100>;
+			status = "disabled";
+		};
+	};
+};
+`,
+		},
+		{
+			name:      "group template",
+			templates: DtsTemplates{"TIMER": "{{.Label}}: timer@{{printf \"%x\" .Base}} { compatible = \"{{.Compatible}}\"; };\n"},
+			want: `/* DtsDevice peripherals, generated from its SVD description */
+
+/ {
+	soc {
+		#address-cells = <1>;
+		#size-cells = <1>;
+		compatible = "simple-bus";
+		ranges;
+
+		sysctrl: sysctrl@40000000 {
+			compatible = "arm-ltd,sysctrl";
+			status = "disabled";
+		};
+
+		timer0: timer@40010000 { compatible = "arm-ltd,timer"; };
+
+		timer1: timer@40011000 { compatible = "arm-ltd,timer"; };
+	};
+};
+`,
+		},
+		{
+			name:      "invalid template",
+			templates: DtsTemplates{"TIMER": "{{.Label"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dtsTestDevice().Dtsi(tt.templates)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Device.Dtsi() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("Device.Dtsi() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_dtsVendor(t *testing.T) {
+	for vendor, want := range map[string]string{
+		"ARM Ltd.":           "arm-ltd",
+		"STMicroelectronics": "stmicroelectronics",
+		"Foo, Inc.":          "foo-inc",
+		"":                   "",
+	} {
+		if got := dtsVendor(vendor); got != want {
+			t.Errorf("dtsVendor(%q) = %q, want %q", vendor, got, want)
+		}
+	}
+}
+
+func Test_dtsLabel(t *testing.T) {
+	for name, want := range map[string]string{
+		"UART0":      "uart0",
+		"GPIO.A":     "gpio_a",
+		"TIM$1":      "tim_1",
+		"SPI-1 CTRL": "spi_1_ctrl",
+	} {
+		if got := dtsLabel(name); got != want {
+			t.Errorf("dtsLabel(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package svd
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// dimIndexOf : Get the index substituted in a dim name to give a name
+func dimIndexOf(pattern, name string) (string, bool) {
+	for _, placeholder := range []string{"[%s]", "%s"} {
+		i := strings.Index(pattern, placeholder)
+		if i < 0 {
+			continue
+		}
+		prefix, suffix := pattern[:i], pattern[i+len(placeholder):]
+		if len(name) > len(prefix)+len(suffix) && strings.HasPrefix(name, prefix) && strings.HasSuffix(name, suffix) {
+			return name[len(prefix) : len(name)-len(suffix)], true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// flatField : Get a field with its effective properties written
+func flatField(f ResolvedField) Field {
+	access := f.Access
+	field := Field{
+		Name:            f.Name,
+		Description:     f.Description,
+		BitRange:        fmt.Sprintf("[%d:%d]", f.Msb, f.Lsb),
+		Access:          &access,
+		WriteConstraint: f.Field.WriteConstraint,
+	}
+	if mwv := f.ModifiedWriteValues; mwv != "" && mwv != ModifiedWriteValuesModify {
+		field.ModifiedWriteValues = &mwv
+	}
+	if action := f.ReadAction; action != "" {
+		field.ReadAction = &action
+	}
+	if f.EnumeratedValues != nil {
+		ev := *f.EnumeratedValues
+		ev.DerivedFrom = ""
+		field.EnumeratedValues = &ev
+	}
+	return field
+}
+
+// flatRegister : Get a register with its effective properties written
+func flatRegister(r ResolvedRegister) Register {
+	reg := Register{
+		Name:                r.Name,
+		DisplayName:         r.Register.DisplayName,
+		Description:         r.Description,
+		AlternateGroup:      r.Register.AlternateGroup,
+		AlternateRegister:   r.Register.AlternateRegister,
+		AddressOffset:       fmt.Sprintf("0x%X", r.Offset),
+		Size:                strconv.FormatUint(uint64(r.Size), 10),
+		Access:              r.Access,
+		Protection:          r.Register.Protection,
+		ResetValue:          FormatHex(r.ResetValue, r.Size),
+		ResetMask:           FormatHex(r.ResetMask, r.Size),
+		DataType:            r.Register.DataType,
+		ModifiedWriteValues: r.ModifiedWriteValues,
+		WriteConstraint:     r.Register.WriteConstraint,
+		ReadAction:          r.ReadAction,
+	}
+	if reg.ModifiedWriteValues == ModifiedWriteValuesModify {
+		reg.ModifiedWriteValues = ""
+	}
+	if idx, ok := dimIndexOf(r.Register.Name, r.Name); ok {
+		reg.DisplayName = dimName(reg.DisplayName, idx)
+	}
+	if len(r.Fields) > 0 {
+		reg.Fields = &Fields{}
+		for _, f := range r.Fields {
+			reg.Fields.Field = append(reg.Fields.Field, flatField(f))
+		}
+	}
+	return reg
+}
+
+// Flatten : Get an equivalent device without derivation, array nor cluster
+// Derivations are materialized, arrays of peripherals, clusters, registers
+// and fields are expanded, and registers of clusters are inlined with the
+// names given by Resolve. Every register and field carries its effective
+// size, access and reset, so the device level defaults are dropped. The
+// interrupts of a peripheral array go to its first instance. The result
+// shares no memory with the device.
+func (dev *Device) Flatten() (*Device, error) {
+	periphs, err := dev.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	flat := *dev
+	flat.Size, flat.Access, flat.Protection, flat.ResetValue, flat.ResetMask = 0, "", "", "", ""
+	flat.Peripherals = Peripherals{}
+	var last *Peripheral
+	for _, rp := range periphs {
+		p := *rp.Peripheral
+		p.DerivedFrom = ""
+		p.Dim, p.DimIncrement, p.DimIndex, p.DimName, p.DimArrayIndex = 0, 0, "", "", nil
+		p.Size, p.Access, p.Protection, p.ResetValue, p.ResetMask = 0, "", "", 0, 0
+		p.Name = rp.Name
+		p.BaseAddress = fmt.Sprintf("0x%08X", rp.BaseAddress)
+		if rp.Peripheral == last {
+			p.Interrupt = nil
+		}
+		last = rp.Peripheral
+		p.Registers = nil
+		if len(rp.Registers) > 0 {
+			p.Registers = &Registers{}
+			for _, r := range rp.Registers {
+				p.Registers.Register = append(p.Registers.Register, flatRegister(r))
+			}
+		}
+		flat.Peripherals.Peripheral = append(flat.Peripherals.Peripheral, p)
+	}
+	return cloneValue(reflect.ValueOf(&flat)).Interface().(*Device), nil
+}
@@ -0,0 +1,84 @@
+package svd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDevice_Flatten(t *testing.T) {
+	dev := NewDevice("FlatDevice")
+	dev.Size, dev.Access, dev.ResetValue = 16, AccessReadOnly, "0x1"
+	w1c, rw := ModifiedWriteValuesOneToClear, AccessReadWrite
+	dev.Peripherals.Peripheral = []Peripheral{
+		{Name: "TIM%s", BaseAddress: "0x40000000", Dim: 2, DimIncrement: 0x100, DimIndex: "A-B",
+			Interrupt: []Interrupt{{Name: "TIM", Value: "3"}},
+			Registers: &Registers{
+				Register: []Register{{
+					Name: "SR", AddressOffset: "0x0", Fields: &Fields{Field: []Field{
+						{Name: "UIF", BitRange: "[0:0]", ModifiedWriteValues: &w1c, EnumeratedValues: &EnumeratedValues{
+							Name: "flag", EnumeratedValue: []EnumeratedValue{{Name: "SET", Value: "1"}},
+						}},
+						{Name: "CCIF", BitRange: "[1:1]", Access: &rw,
+							EnumeratedValues: &EnumeratedValues{DerivedFrom: "flag"}},
+					}},
+				}},
+				Cluster: []Cluster{{
+					Name: "CH%s", AddressOffset: "0x10", Dim: "2", DimIncrement: "0x8", Size: "32",
+					Register: []Register{{Name: "CCR[%s]", DisplayName: "CCR%s", AddressOffset: "0x0", Dim: "2", DimIncrement: "4",
+						ResetValue: "0x00050001", ResetMask: "0xF1"}},
+				}},
+			}},
+		{Name: "TIMC", DerivedFrom: "TIM%s", BaseAddress: "0x40000400"},
+	}
+
+	flat, err := dev.Flatten()
+	if err != nil {
+		t.Fatalf("Device.Flatten() error = %v", err)
+	}
+	if flat.Size != 0 || flat.Access != "" || flat.ResetValue != "" {
+		t.Errorf("Device.Flatten() kept device defaults %d %s %s", flat.Size, flat.Access, flat.ResetValue)
+	}
+	var names []string
+	for _, p := range flat.Peripherals.Peripheral {
+		names = append(names, p.Name+"@"+p.BaseAddress)
+	}
+	wantNames := []string{"TIMA@0x40000000", "TIMB@0x40000100", "TIMC@0x40000400"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("Device.Flatten() peripherals = %v, want %v", names, wantNames)
+	}
+	if len(flat.Peripherals.Peripheral[0].Interrupt) != 1 || len(flat.Peripherals.Peripheral[1].Interrupt) != 0 {
+		t.Errorf("Device.Flatten() interrupts = %+v", flat.Peripherals.Peripheral[:2])
+	}
+
+	ro := AccessReadOnly
+	flag := []EnumeratedValue{{Name: "SET", Value: "1"}}
+	want := &Registers{Register: []Register{
+		{Name: "SR", AddressOffset: "0x0", Size: "16", Access: ro, ResetValue: "0x0001", ResetMask: "0xFFFF",
+			Fields: &Fields{Field: []Field{
+				{Name: "UIF", BitRange: "[0:0]", Access: &ro, ModifiedWriteValues: &w1c,
+					EnumeratedValues: &EnumeratedValues{Name: "flag", EnumeratedValue: flag}},
+				{Name: "CCIF", BitRange: "[1:1]", Access: &rw,
+					EnumeratedValues: &EnumeratedValues{Name: "flag", EnumeratedValue: flag}},
+			}}},
+		{Name: "CH0_CCR0", DisplayName: "CCR0", AddressOffset: "0x10", Size: "32", Access: ro, ResetValue: "0x00050001", ResetMask: "0x000000F1"},
+		{Name: "CH0_CCR1", DisplayName: "CCR1", AddressOffset: "0x14", Size: "32", Access: ro, ResetValue: "0x00050001", ResetMask: "0x000000F1"},
+		{Name: "CH1_CCR0", DisplayName: "CCR0", AddressOffset: "0x18", Size: "32", Access: ro, ResetValue: "0x00050001", ResetMask: "0x000000F1"},
+		{Name: "CH1_CCR1", DisplayName: "CCR1", AddressOffset: "0x1C", Size: "32", Access: ro, ResetValue: "0x00050001", ResetMask: "0x000000F1"},
+	}}
+	for _, p := range flat.Peripherals.Peripheral {
+		if !reflect.DeepEqual(p.Registers, want) {
+			t.Errorf("Device.Flatten() %s registers = %+v, want %+v", p.Name, p.Registers, want)
+		}
+	}
+
+	data, err := flat.SVD()
+	if err != nil {
+		t.Fatalf("Device.SVD() error = %v", err)
+	}
+	for _, tag := range []string{"derivedFrom", "<dim>", "<cluster>"} {
+		if strings.Contains(string(data), tag) {
+			t.Errorf("Device.SVD() of the flattened device contains %s", tag)
+		}
+	}
+}
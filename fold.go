@@ -0,0 +1,454 @@
+package svd
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ArrayFold : a sequence of registers or fields rewritten as a dim array
+type ArrayFold struct {
+	// Path of the scope holding the sequence: "peripheral", "peripheral.cluster"
+	// for registers, "peripheral.register" for fields.
+	Scope string
+
+	// Name of the array, with its %s placeholder.
+	Name string
+
+	// Names of the folded elements, in array order.
+	Members []string
+
+	// Offset between elements, in bytes for registers and bits for fields.
+	Increment uint64
+
+	// Field tells whether the elements are fields.
+	Field bool
+}
+
+// String : Describe the fold
+func (f ArrayFold) String() string {
+	unit := "bytes"
+	if f.Field {
+		unit = "bits"
+	}
+	return fmt.Sprintf("%s: %s[%d] += %d %s (%s)", f.Scope, f.Name, len(f.Members), f.Increment, unit,
+		strings.Join(f.Members, ", "))
+}
+
+// foldElement : a register or field that may be folded into an array
+type foldElement struct {
+	name   string
+	offset uint64
+	size   uint64
+
+	// Texts allowed to differ by the index, e.g. displayName and description.
+	texts []string
+}
+
+// foldRun : a sequence of elements found foldable
+type foldRun struct {
+	name    string
+	members []int
+	indices []string
+	inc     uint64
+	texts   []string
+}
+
+// foldCandidates : Get the array names a name may be an element of, with its index
+// Every run of digits is a candidate index, as is an upper case letter
+// ending the name or followed by an underscore.
+func foldCandidates(name string) (names, indices []string) {
+	if strings.Contains(name, "%s") {
+		return nil, nil
+	}
+	for i := 0; i < len(name); i++ {
+		j := i
+		for j < len(name) && name[j] >= '0' && name[j] <= '9' {
+			j++
+		}
+		if j == i && name[i] >= 'A' && name[i] <= 'Z' && (i+1 == len(name) || name[i+1] == '_') && i > 0 {
+			j = i + 1
+		}
+		if j > i {
+			names = append(names, name[:i]+"%s"+name[j:])
+			indices = append(indices, name[i:j])
+			i = j - 1
+		}
+	}
+	return
+}
+
+// foldText : Get a text whose %s substituted by each index gives the texts
+func foldText(texts, indices []string) (string, bool) {
+	same := true
+	for _, t := range texts[1:] {
+		same = same && t == texts[0]
+	}
+	if same {
+		return texts[0], !strings.Contains(texts[0], "%s")
+	}
+	first := texts[0]
+	for k := strings.Index(first, indices[0]); k >= 0; {
+		tmpl := first[:k] + "%s" + first[k+len(indices[0]):]
+		ok := !strings.Contains(first, "%s")
+		for n := range texts {
+			ok = ok && dimName(tmpl, indices[n]) == texts[n]
+		}
+		if ok {
+			return tmpl, true
+		}
+		next := strings.Index(first[k+1:], indices[0])
+		if next < 0 {
+			break
+		}
+		k += 1 + next
+	}
+	return "", false
+}
+
+// foldDimIndex : Get the shortest dimIndex listing the indices
+func foldDimIndex(indices []string) DimIndex {
+	numeric, letters, zero := true, true, true
+	for n, idx := range indices {
+		v, err := strconv.Atoi(idx)
+		numeric = numeric && err == nil && strconv.Itoa(v) == idx &&
+			(n == 0 || strconv.Itoa(v-1) == indices[n-1])
+		zero = zero && idx == strconv.Itoa(n)
+		letters = letters && len(idx) == 1 && idx[0] >= 'A' && idx[0] <= 'Z' &&
+			(n == 0 || idx[0] == indices[n-1][0]+1)
+	}
+	switch {
+	case zero:
+		return ""
+	case numeric || letters:
+		return DimIndex(indices[0] + "-" + indices[len(indices)-1])
+	}
+	return DimIndex(strings.Join(indices, ","))
+}
+
+// foldRuns : Find the sequences of identical elements at a constant stride
+// same tells whether two elements are identical but for their name, offset
+// and texts. Longer sequences are preferred; an element is folded once.
+func foldRuns(elems []foldElement, same func(i, j int) bool) []foldRun {
+	groups := make(map[string][]int)
+	index := make(map[string][]string)
+	var names []string
+	for i, e := range elems {
+		cands, indices := foldCandidates(e.name)
+		for k, name := range cands {
+			if _, ok := groups[name]; !ok {
+				names = append(names, name)
+			}
+			groups[name] = append(groups[name], i)
+			index[name] = append(index[name], indices[k])
+		}
+	}
+	var runs []foldRun
+	for _, name := range names {
+		members, indices := groups[name], index[name]
+		order := make([]int, len(members))
+		for k := range order {
+			order[k] = k
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return elems[members[order[a]]].offset < elems[members[order[b]]].offset
+		})
+		for i := 0; i < len(order); {
+			first := members[order[i]]
+			j := i + 1
+			var inc uint64
+			for ; j < len(order); j++ {
+				prev, cur := members[order[j-1]], members[order[j]]
+				d := elems[cur].offset - elems[prev].offset
+				if j == i+1 {
+					inc = d
+				}
+				if d != inc || d < elems[first].size || !same(first, cur) {
+					break
+				}
+			}
+			if j-i < 2 {
+				i++
+				continue
+			}
+			run := foldRun{name: name, inc: inc}
+			for _, k := range order[i:j] {
+				run.members = append(run.members, members[k])
+				run.indices = append(run.indices, indices[k])
+			}
+			ok := true
+			for t := range elems[first].texts {
+				var texts []string
+				for _, m := range run.members {
+					texts = append(texts, elems[m].texts[t])
+				}
+				text, found := foldText(texts, run.indices)
+				ok = ok && found
+				run.texts = append(run.texts, text)
+			}
+			if ok {
+				runs = append(runs, run)
+				i = j
+			} else {
+				i++
+			}
+		}
+	}
+	sort.SliceStable(runs, func(a, b int) bool {
+		if len(runs[a].members) != len(runs[b].members) {
+			return len(runs[a].members) > len(runs[b].members)
+		}
+		return runs[a].members[0] < runs[b].members[0]
+	})
+	used := make(map[int]bool)
+	var chosen []foldRun
+	for _, run := range runs {
+		free := true
+		for _, m := range run.members {
+			free = free && !used[m]
+		}
+		if !free {
+			continue
+		}
+		for _, m := range run.members {
+			used[m] = true
+		}
+		chosen = append(chosen, run)
+	}
+	sort.Slice(chosen, func(a, b int) bool {
+		return chosen[a].members[0] < chosen[b].members[0]
+	})
+	return chosen
+}
+
+// foldKeep : Get which elements remain once the runs are folded into their first member
+func foldKeep(n int, runs []foldRun) []bool {
+	keep := make([]bool, n)
+	for i := range keep {
+		keep[i] = true
+	}
+	for _, run := range runs {
+		for _, m := range run.members[1:] {
+			keep[m] = false
+		}
+	}
+	return keep
+}
+
+// foldReferences : Get the names appearing in the element references of the device
+// Every component of the derivedFrom paths of registers, clusters, fields
+// and enumerated values, and every alternateRegister and alternateCluster
+// is listed: an element with such a name may be referenced and must keep it.
+func (dev *Device) foldReferences() map[string]bool {
+	refs := make(map[string]bool)
+	ref := func(path string) {
+		for _, name := range strings.Split(path, ".") {
+			if name != "" {
+				refs[name] = true
+			}
+		}
+	}
+	var walk func(registers []Register, clusters []Cluster)
+	walk = func(registers []Register, clusters []Cluster) {
+		for _, r := range registers {
+			ref(r.DerivedFrom)
+			ref(r.AlternateRegister)
+			if r.Fields == nil {
+				continue
+			}
+			for _, f := range r.Fields.Field {
+				ref(f.DerivedFrom)
+				if ev := f.EnumeratedValues; ev != nil {
+					ref(ev.DerivedFrom)
+				}
+			}
+		}
+		for _, c := range clusters {
+			ref(c.DerivedFrom)
+			ref(c.AlternateCluster)
+			walk(c.Register, c.Cluster)
+		}
+	}
+	for _, p := range dev.Peripherals.Peripheral {
+		if p.Registers != nil {
+			walk(p.Registers.Register, p.Registers.Cluster)
+		}
+	}
+	return refs
+}
+
+// unreferencedRuns : Get the runs none of whose members is referenced
+func unreferencedRuns(runs []foldRun, name func(int) string, refs map[string]bool) (kept []foldRun) {
+	for _, run := range runs {
+		referenced := false
+		for _, m := range run.members {
+			referenced = referenced || refs[name(m)]
+		}
+		if !referenced {
+			kept = append(kept, run)
+		}
+	}
+	return kept
+}
+
+// foldFields : Fold the field sequences of a register
+// Fields named by refs are not folded.
+func foldFields(scope string, r *Register, refs map[string]bool, dryRun bool) (folds []ArrayFold, err error) {
+	if r.Fields == nil {
+		return nil, nil
+	}
+	fields := r.Fields.Field
+	elems := make([]foldElement, len(fields))
+	for i, f := range fields {
+		lsb, msb, err := f.Bits()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", scope, err)
+		}
+		elems[i] = foldElement{name: f.Name, offset: uint64(lsb), size: uint64(msb - lsb + 1),
+			texts: []string{f.Description}}
+		if f.Dim != "" || f.DerivedFrom != "" {
+			elems[i].name = "%s"
+		}
+	}
+	same := func(i, j int) bool {
+		a, b := fields[i], fields[j]
+		a.Name, a.Description, b.Name, b.Description = "", "", "", ""
+		a.BitRange, a.BitOffset, a.Lsb, a.Msb = "", "", "", ""
+		b.BitRange, b.BitOffset, b.Lsb, b.Msb = "", "", "", ""
+		return elems[i].size == elems[j].size && reflect.DeepEqual(a, b)
+	}
+	runs := unreferencedRuns(foldRuns(elems, same), func(i int) string { return fields[i].Name }, refs)
+	for _, run := range runs {
+		fold := ArrayFold{Scope: scope, Name: run.name, Increment: run.inc, Field: true}
+		for _, m := range run.members {
+			fold.Members = append(fold.Members, fields[m].Name)
+		}
+		folds = append(folds, fold)
+		if dryRun {
+			continue
+		}
+		f := &fields[run.members[0]]
+		f.Name, f.Description = run.name, run.texts[0]
+		f.Dim = strconv.Itoa(len(run.members))
+		f.DimIncrement = strconv.FormatUint(run.inc, 10)
+		f.DimIndex = foldDimIndex(run.indices)
+	}
+	if dryRun || len(runs) == 0 {
+		return folds, nil
+	}
+	keep := foldKeep(len(fields), runs)
+	var kept []Field
+	for i, f := range fields {
+		if keep[i] {
+			kept = append(kept, f)
+		}
+	}
+	r.Fields.Field = kept
+	return folds, nil
+}
+
+// foldRegisters : Fold the register sequences of a peripheral or cluster, and their fields
+// Registers and fields named by refs are not folded.
+func foldRegisters(scope string, registers *[]Register, clusters []Cluster, def defaults, refs map[string]bool, dryRun bool) (folds []ArrayFold, err error) {
+	regs := *registers
+	for i := range regs {
+		f, err := foldFields(scope+"."+regs[i].Name, &regs[i], refs, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		folds = append(folds, f...)
+	}
+	elems := make([]foldElement, len(regs))
+	for i, r := range regs {
+		offset, err := ParseNumber(r.AddressOffset)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s addressOffset: %v", scope, r.Name, err)
+		}
+		size := uint64(def.size)
+		if r.Size != "" {
+			if size, err = ParseNumber(r.Size); err != nil {
+				return nil, fmt.Errorf("%s.%s size: %v", scope, r.Name, err)
+			}
+		}
+		elems[i] = foldElement{name: r.Name, offset: offset, size: (size + 7) / 8,
+			texts: []string{r.DisplayName, r.Description}}
+		if r.Dim != "" || r.DerivedFrom != "" || r.AlternateGroup != "" || r.AlternateRegister != "" {
+			elems[i].name = "%s"
+		}
+	}
+	same := func(i, j int) bool {
+		a, b := regs[i], regs[j]
+		a.Name, a.DisplayName, a.Description, a.AddressOffset = "", "", "", ""
+		b.Name, b.DisplayName, b.Description, b.AddressOffset = "", "", "", ""
+		return elems[i].size == elems[j].size && reflect.DeepEqual(a, b)
+	}
+	runs := unreferencedRuns(foldRuns(elems, same), func(i int) string { return regs[i].Name }, refs)
+	for _, run := range runs {
+		fold := ArrayFold{Scope: scope, Name: run.name, Increment: run.inc}
+		for _, m := range run.members {
+			fold.Members = append(fold.Members, regs[m].Name)
+		}
+		folds = append(folds, fold)
+		if dryRun {
+			continue
+		}
+		r := &regs[run.members[0]]
+		r.Name, r.DisplayName, r.Description = run.name, run.texts[0], run.texts[1]
+		r.Dim = strconv.Itoa(len(run.members))
+		r.DimIncrement = fmt.Sprintf("0x%X", run.inc)
+		r.DimIndex = foldDimIndex(run.indices)
+	}
+	if !dryRun && len(runs) > 0 {
+		keep := foldKeep(len(regs), runs)
+		var kept []Register
+		for i, r := range regs {
+			if keep[i] {
+				kept = append(kept, r)
+			}
+		}
+		*registers = kept
+	}
+	for i := range clusters {
+		c := &clusters[i]
+		cdef, err := def.cluster(c)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s %v", scope, c.Name, err)
+		}
+		f, err := foldRegisters(scope+"."+c.Name, &c.Register, c.Cluster, cdef, refs, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		folds = append(folds, f...)
+	}
+	return folds, nil
+}
+
+// FoldArrays : Rewrite sequences of identical registers or fields as dim arrays
+// A sequence is made of elements named alike but for a numeric or letter
+// index (PWM0CLK_SSR, PWM1CLK_SSR...), with identical properties and
+// evenly spaced offsets. Descriptions may differ by the index, which then
+// becomes a %s placeholder. Sequences with an element named by a
+// derivedFrom, alternateRegister or alternateCluster are left as they
+// are. With dryRun the device is left unchanged and only the folds that
+// would be made are returned.
+func (dev *Device) FoldArrays(dryRun bool) (folds []ArrayFold, err error) {
+	top, err := dev.defaults()
+	if err != nil {
+		return nil, err
+	}
+	refs := dev.foldReferences()
+	for i := range dev.Peripherals.Peripheral {
+		p := &dev.Peripherals.Peripheral[i]
+		if p.Registers == nil {
+			continue
+		}
+		f, err := foldRegisters(p.Name, &p.Registers.Register, p.Registers.Cluster, top.peripheral(p), refs, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		folds = append(folds, f...)
+	}
+	return folds, nil
+}
@@ -0,0 +1,144 @@
+package svd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func foldTestDevice() *Device {
+	dev := NewDevice("FoldDevice")
+	dev.Peripherals.Peripheral = []Peripheral{{
+		Name:        "DMA",
+		BaseAddress: "0x40020000",
+		Registers: &Registers{Register: []Register{
+			{Name: "CTRL", AddressOffset: "0x0", Fields: &Fields{Field: []Field{
+				{Name: "EN3", Description: "Enable channel 3", BitRange: "[3:3]"},
+				{Name: "EN2", Description: "Enable channel 2", BitRange: "[2:2]"},
+				{Name: "EN1", Description: "Enable channel 1", BitRange: "[1:1]"},
+				{Name: "EN0", Description: "Enable channel 0", BitRange: "[0:0]"},
+				{Name: "MODE", BitRange: "[9:8]"},
+			}}},
+			{Name: "CH0_CFG", Description: "Channel 0 configuration", AddressOffset: "0x10"},
+			{Name: "CH0_CNT", AddressOffset: "0x14", Access: AccessReadOnly},
+			{Name: "CH1_CFG", Description: "Channel 1 configuration", AddressOffset: "0x20"},
+			{Name: "CH1_CNT", AddressOffset: "0x24", Access: AccessReadOnly},
+			{Name: "CH2_CFG", Description: "Channel 2 configuration", AddressOffset: "0x30"},
+			{Name: "CH2_CNT", AddressOffset: "0x34", Access: AccessWriteOnly},
+			{Name: "PORTA", AddressOffset: "0x40", Size: "16"},
+			{Name: "PORTB", AddressOffset: "0x42", Size: "16"},
+		}},
+	}}
+	return dev
+}
+
+func TestDevice_FoldArrays(t *testing.T) {
+	dev := foldTestDevice()
+	wantFolds := []ArrayFold{
+		{Scope: "DMA.CTRL", Name: "EN%s", Members: []string{"EN0", "EN1", "EN2", "EN3"}, Increment: 1, Field: true},
+		{Scope: "DMA", Name: "CH%s_CFG", Members: []string{"CH0_CFG", "CH1_CFG", "CH2_CFG"}, Increment: 0x10},
+		{Scope: "DMA", Name: "CH%s_CNT", Members: []string{"CH0_CNT", "CH1_CNT"}, Increment: 0x10},
+		{Scope: "DMA", Name: "PORT%s", Members: []string{"PORTA", "PORTB"}, Increment: 2},
+	}
+	folds, err := dev.FoldArrays(true)
+	if err != nil {
+		t.Fatalf("Device.FoldArrays() error = %v", err)
+	}
+	if !reflect.DeepEqual(folds, wantFolds) {
+		t.Errorf("Device.FoldArrays() dry run = %v, want %v", folds, wantFolds)
+	}
+	if !reflect.DeepEqual(dev, foldTestDevice()) {
+		t.Errorf("Device.FoldArrays() dry run changed the device")
+	}
+	before, err := dev.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if folds, err = dev.FoldArrays(false); err != nil {
+		t.Fatalf("Device.FoldArrays() error = %v", err)
+	}
+	if !reflect.DeepEqual(folds, wantFolds) {
+		t.Errorf("Device.FoldArrays() = %v, want %v", folds, wantFolds)
+	}
+	want := []Register{
+		{Name: "CTRL", AddressOffset: "0x0", Fields: &Fields{Field: []Field{
+			{Name: "EN%s", Description: "Enable channel %s", BitRange: "[0:0]", Dim: "4", DimIncrement: "1"},
+			{Name: "MODE", BitRange: "[9:8]"},
+		}}},
+		{Name: "CH%s_CFG", Description: "Channel %s configuration", AddressOffset: "0x10", Dim: "3", DimIncrement: "0x10"},
+		{Name: "CH%s_CNT", AddressOffset: "0x14", Access: AccessReadOnly, Dim: "2", DimIncrement: "0x10"},
+		{Name: "CH2_CNT", AddressOffset: "0x34", Access: AccessWriteOnly},
+		{Name: "PORT%s", AddressOffset: "0x40", Size: "16", Dim: "2", DimIncrement: "0x2", DimIndex: "A-B"},
+	}
+	if got := dev.Peripherals.Peripheral[0].Registers.Register; !reflect.DeepEqual(got, want) {
+		t.Errorf("Device.FoldArrays() registers = %+v, want %+v", got, want)
+	}
+
+	after, err := dev.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := make(map[string]ResolvedRegister)
+	for _, r := range after[0].Registers {
+		index[r.Name] = r
+	}
+	for _, w := range before[0].Registers {
+		g, ok := index[w.Name]
+		if !ok || g.Offset != w.Offset || g.Description != w.Description || len(g.Fields) != len(w.Fields) {
+			t.Errorf("register %s = %+v, want %+v", w.Name, g, w)
+		}
+	}
+	ctrl := index["CTRL"]
+	if f := ctrl.Field("EN2"); f == nil || f.Lsb != 2 || f.Description != "Enable channel 2" {
+		t.Errorf("field CTRL.EN2 = %+v", f)
+	}
+}
+
+func TestDevice_FoldArrays_referenced(t *testing.T) {
+	dev := foldTestDevice()
+	regs := &dev.Peripherals.Peripheral[0].Registers.Register
+	*regs = append(*regs,
+		Register{Name: "ALIAS", AddressOffset: "0x50", DerivedFrom: "CH1_CFG"},
+		Register{Name: "PORTC", AddressOffset: "0x40", Size: "16", AlternateRegister: "PORTA"},
+		Register{Name: "STAT", AddressOffset: "0x54", Fields: &Fields{Field: []Field{
+			{Name: "EN0", BitRange: "[0:0]", EnumeratedValues: &EnumeratedValues{DerivedFrom: "DMA.CTRL.EN1.MODES"}},
+		}}},
+	)
+	for i := 0; i < 4; i++ {
+		(*regs)[0].Fields.Field[i].EnumeratedValues = &EnumeratedValues{Name: "MODES",
+			EnumeratedValue: []EnumeratedValue{{Name: "ON", Value: "1"}}}
+	}
+
+	wantFolds := []ArrayFold{
+		{Scope: "DMA", Name: "CH%s_CNT", Members: []string{"CH0_CNT", "CH1_CNT"}, Increment: 0x10},
+	}
+	folds, err := dev.FoldArrays(false)
+	if err != nil {
+		t.Fatalf("Device.FoldArrays() error = %v", err)
+	}
+	if !reflect.DeepEqual(folds, wantFolds) {
+		t.Errorf("Device.FoldArrays() = %v, want %v", folds, wantFolds)
+	}
+	if _, err := dev.Resolve(); err != nil {
+		t.Errorf("Device.Resolve() after folding error = %v", err)
+	}
+}
+
+func Test_foldDimIndex(t *testing.T) {
+	tests := []struct {
+		indices []string
+		want    DimIndex
+	}{
+		{[]string{"0", "1", "2"}, ""},
+		{[]string{"1", "2", "3"}, "1-3"},
+		{[]string{"A", "B", "C"}, "A-C"},
+		{[]string{"0", "2", "4"}, "0,2,4"},
+		{[]string{"01", "02"}, "01,02"},
+		{[]string{"A", "C"}, "A,C"},
+	}
+	for _, tt := range tests {
+		if got := foldDimIndex(tt.indices); got != tt.want {
+			t.Errorf("foldDimIndex(%v) = %q, want %q", tt.indices, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package svd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FormatOptions : options of the canonical SVD formatter
+type FormatOptions struct {
+	// Sort peripherals by base address, clusters and registers by
+	// address offset and fields by bit position.
+	Sort bool
+
+	// Column after which descriptions are wrapped, 0 to keep them on
+	// one line.
+	Wrap int
+}
+
+// canonicalHex : Rewrite a number as 0x... with upper case digits
+// Numbers which cannot be parsed are only trimmed.
+func canonicalHex(s string) string {
+	if n, err := ParseNumber(s); err == nil {
+		return fmt.Sprintf("0x%X", n)
+	}
+	return strings.TrimSpace(s)
+}
+
+// canonicalDecimal : Rewrite a number in decimal
+func canonicalDecimal(s string) string {
+	if n, err := ParseNumber(s); err == nil {
+		return strconv.FormatUint(n, 10)
+	}
+	return strings.TrimSpace(s)
+}
+
+// canonicalValue : Rewrite a value padded to a register width
+// Values with 'do not care' bits are kept in binary.
+func canonicalValue(s string, width uint) string {
+	if s == "" {
+		return ""
+	}
+	if n, err := ParseNumber(s); err == nil {
+		return FormatHex(n, width)
+	}
+	return canonicalBinary(s)
+}
+
+// canonicalBinary : Rewrite a binary number as 0b..., other numbers as canonicalHex
+func canonicalBinary(s string) string {
+	str := strings.TrimSpace(s)
+	for _, prefix := range []string{"#", "0b", "0B"} {
+		if strings.HasPrefix(str, prefix) {
+			return "0b" + strings.ToLower(str[len(prefix):])
+		}
+	}
+	if _, err := strconv.ParseUint(str, 10, 64); err == nil {
+		return str
+	}
+	return canonicalHex(str)
+}
+
+// canonicalText : Collapse the white space of a description
+func canonicalText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// canonicalFields : Normalize the fields of a register
+func canonicalFields(fields []Field, opts FormatOptions) {
+	for i := range fields {
+		f := &fields[i]
+		f.Description = canonicalText(f.Description)
+		if lsb, msb, err := f.Bits(); err == nil {
+			f.BitOffset, f.BitWidth, f.Lsb, f.Msb = "", "", "", ""
+			f.BitRange = fmt.Sprintf("[%d:%d]", msb, lsb)
+		}
+		if f.Dim != "" {
+			f.Dim = canonicalDecimal(f.Dim)
+			f.DimIncrement = canonicalDecimal(f.DimIncrement)
+		}
+		if ev := f.EnumeratedValues; ev != nil {
+			for j := range ev.EnumeratedValue {
+				v := &ev.EnumeratedValue[j]
+				v.Description = canonicalText(v.Description)
+				if v.Value != "" {
+					v.Value = canonicalBinary(v.Value)
+				}
+			}
+		}
+	}
+	if opts.Sort {
+		sort.SliceStable(fields, func(a, b int) bool {
+			la, _, _ := fields[a].Bits()
+			lb, _, _ := fields[b].Bits()
+			return la < lb
+		})
+	}
+}
+
+// canonicalRegisters : Normalize the registers and clusters of a scope
+func canonicalRegisters(registers []Register, clusters []Cluster, def defaults, opts FormatOptions) error {
+	for i := range registers {
+		r := &registers[i]
+		r.Description = canonicalText(r.Description)
+		r.AddressOffset = canonicalHex(r.AddressOffset)
+		width := def.size
+		if r.Size != "" {
+			r.Size = canonicalDecimal(r.Size)
+			n, err := ParseNumber(r.Size)
+			if err != nil {
+				return fmt.Errorf("register %s size: %v", r.Name, err)
+			}
+			width = uint(n)
+		}
+		if r.Dim != "" {
+			r.Dim = canonicalDecimal(r.Dim)
+			r.DimIncrement = canonicalHex(r.DimIncrement)
+		}
+		r.ResetValue = canonicalValue(r.ResetValue, width)
+		r.ResetMask = canonicalValue(r.ResetMask, width)
+		if r.Fields != nil {
+			canonicalFields(r.Fields.Field, opts)
+		}
+	}
+	for i := range clusters {
+		c := &clusters[i]
+		c.Description = canonicalText(c.Description)
+		c.AddressOffset = canonicalHex(c.AddressOffset)
+		if c.Size != "" {
+			c.Size = canonicalDecimal(c.Size)
+		}
+		if c.Dim != "" {
+			c.Dim = canonicalDecimal(c.Dim)
+			c.DimIncrement = canonicalHex(c.DimIncrement)
+		}
+		cdef, err := def.cluster(c)
+		if err != nil {
+			return fmt.Errorf("cluster %s: %v", c.Name, err)
+		}
+		c.ResetValue = canonicalValue(c.ResetValue, cdef.size)
+		c.ResetMask = canonicalValue(c.ResetMask, cdef.size)
+		if err := canonicalRegisters(c.Register, c.Cluster, cdef, opts); err != nil {
+			return err
+		}
+	}
+	if opts.Sort {
+		offset := func(s string) uint64 {
+			n, _ := ParseNumber(s)
+			return n
+		}
+		sort.SliceStable(registers, func(a, b int) bool {
+			return offset(registers[a].AddressOffset) < offset(registers[b].AddressOffset)
+		})
+		sort.SliceStable(clusters, func(a, b int) bool {
+			return offset(clusters[a].AddressOffset) < offset(clusters[b].AddressOffset)
+		})
+	}
+	return nil
+}
+
+// canonicalize : Normalize the numbers, descriptions and order of a device
+func (dev *Device) canonicalize(opts FormatOptions) error {
+	dev.Description = canonicalText(dev.Description)
+	top, err := dev.defaults()
+	if err != nil {
+		return err
+	}
+	dev.ResetValue = canonicalValue(dev.ResetValue, top.size)
+	dev.ResetMask = canonicalValue(dev.ResetMask, top.size)
+	width := dev.Width
+	if width == 0 {
+		width = 32
+	}
+	periphs := dev.Peripherals.Peripheral
+	for i := range periphs {
+		p := &periphs[i]
+		p.Description = canonicalText(p.Description)
+		if n, err := ParseNumber(p.BaseAddress); err == nil {
+			p.BaseAddress = FormatHex(n, width)
+		}
+		for j := range p.AddressBlock {
+			p.AddressBlock[j].Size = canonicalHex(p.AddressBlock[j].Size)
+		}
+		for j := range p.Interrupt {
+			p.Interrupt[j].Description = canonicalText(p.Interrupt[j].Description)
+			p.Interrupt[j].Value = canonicalDecimal(p.Interrupt[j].Value)
+		}
+		if p.Registers != nil {
+			if err := canonicalRegisters(p.Registers.Register, p.Registers.Cluster, top.peripheral(p), opts); err != nil {
+				return fmt.Errorf("peripheral %s: %v", p.Name, err)
+			}
+		}
+	}
+	if opts.Sort {
+		sort.SliceStable(periphs, func(a, b int) bool {
+			na, _ := ParseNumber(periphs[a].BaseAddress)
+			nb, _ := ParseNumber(periphs[b].BaseAddress)
+			return na < nb
+		})
+	}
+	return nil
+}
+
+var descriptionLineRe = regexp.MustCompile(`(?m)^( *)<description>(.*)</description>$`)
+
+// wrapDescriptions : Wrap the description elements of an indented document
+// Continuation lines are indented one level deeper than the element.
+func wrapDescriptions(doc []byte, column int) []byte {
+	return descriptionLineRe.ReplaceAllFunc(doc, func(line []byte) []byte {
+		if len(line) <= column {
+			return line
+		}
+		m := descriptionLineRe.FindSubmatch(line)
+		indent := string(m[1])
+		var b strings.Builder
+		b.WriteString(indent + "<description>")
+		n := b.Len()
+		for i, word := range strings.Fields(string(m[2])) {
+			if i > 0 && n+1+len(word) > column {
+				b.WriteString("\n" + indent + "  ")
+				n = len(indent) + 2
+			} else if i > 0 {
+				b.WriteByte(' ')
+				n++
+			}
+			b.WriteString(word)
+			n += len(word)
+		}
+		b.WriteString("</description>")
+		return []byte(b.String())
+	})
+}
+
+// Format : Rewrite an SVD document in canonical form
+// Elements are written in schema order with a two space indentation;
+// addresses and offsets are written in hexadecimal, reset values padded
+// to the register size, sizes and dims in decimal and bit positions as
+// bitRange. White space in descriptions is collapsed, then descriptions
+// are wrapped if asked. Formatting a canonical document gives it back
+// unchanged.
+func Format(svd []byte, opts FormatOptions) ([]byte, error) {
+	var dev Device
+	if err := xml.Unmarshal(svd, &dev); err != nil {
+		return nil, err
+	}
+	// namespaced attributes are not read back by encoding/xml
+	if def := NewDevice(""); dev.Xs == "" {
+		dev.Xs, dev.NoNamespaceSchemaLocation = def.Xs, def.NoNamespaceSchemaLocation
+	}
+	if err := dev.canonicalize(opts); err != nil {
+		return nil, err
+	}
+	out, err := dev.SVD()
+	if err != nil {
+		return nil, err
+	}
+	out = append(bytes.TrimRight(out, "\n"), '\n')
+	if opts.Wrap > 0 {
+		out = wrapDescriptions(out, opts.Wrap)
+	}
+	return out, nil
+}
@@ -0,0 +1,135 @@
+package svd
+
+import (
+	"strings"
+	"testing"
+)
+
+const formatTestSource = `<?xml version="1.0" encoding="utf-8"?>
+<device schemaVersion="1.3" xmlns:xs="http://www.w3.org/2001/XMLSchema-instance" xs:noNamespaceSchemaLocation="CMSIS-SVD.xsd">
+<name>Fmt</name><version>1</version><description>  Formatter
+   test device </description>
+<addressUnitBits>8</addressUnitBits><width>32</width>
+<peripherals>
+<peripheral><name>UART1</name><baseAddress>0X40001000</baseAddress>
+<interrupt><name>UART1</name><value>0x11</value></interrupt>
+</peripheral>
+<peripheral><name>UART0</name><baseAddress>1073741824</baseAddress>
+<addressBlock><offset>0</offset><size>4096</size><usage>registers</usage></addressBlock>
+<registers>
+<register><name>SR</name><description>Status register, with a description long enough to be wrapped</description><addressOffset>4</addressOffset><size>0x10</size><resetValue>#101</resetValue>
+<fields>
+<field><name>OVR</name><bitOffset>1</bitOffset><bitWidth>1</bitWidth></field>
+<field><name>TXE</name><lsb>0</lsb><msb>0</msb>
+<enumeratedValues><enumeratedValue><name>EMPTY</name><value>#1</value></enumeratedValue>
+<enumeratedValue><name>ANY</name><value>0bX</value></enumeratedValue></enumeratedValues></field>
+</fields></register>
+<register><name>CR</name><addressOffset>0x0</addressOffset><resetValue>0x3ff</resetValue></register>
+</registers>
+</peripheral>
+</peripherals>
+</device>`
+
+func TestFormat(t *testing.T) {
+	got, err := Format([]byte(formatTestSource), FormatOptions{Sort: true, Wrap: 60})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<device xmlns:xs="http://www.w3.org/2001/XMLSchema-instance" xs:noNamespaceSchemaLocation="CMSIS-SVD.xsd" schemaVersion="1.3">
+  <name>Fmt</name>
+  <version>1</version>
+  <description>Formatter test device</description>
+  <cpu>
+    <name></name>
+    <revision></revision>
+    <endian></endian>
+    <mpuPresent>false</mpuPresent>
+    <fpuPresent>false</fpuPresent>
+    <nvicPrioBits></nvicPrioBits>
+    <vendorSystickConfig>false</vendorSystickConfig>
+  </cpu>
+  <addressUnitBits>8</addressUnitBits>
+  <width>32</width>
+  <peripherals>
+    <peripheral>
+      <name>UART0</name>
+      <baseAddress>0x40000000</baseAddress>
+      <addressBlock>
+        <offset>0</offset>
+        <size>0x1000</size>
+        <usage>registers</usage>
+      </addressBlock>
+      <registers>
+        <register>
+          <name>CR</name>
+          <addressOffset>0x0</addressOffset>
+          <resetValue>0x000003FF</resetValue>
+        </register>
+        <register>
+          <name>SR</name>
+          <description>Status register, with a description
+            long enough to be wrapped</description>
+          <addressOffset>0x4</addressOffset>
+          <size>16</size>
+          <resetValue>0x0005</resetValue>
+          <fields>
+            <field>
+              <name>TXE</name>
+              <bitRange>[0:0]</bitRange>
+              <enumeratedValues>
+                <enumeratedValue>
+                  <name>EMPTY</name>
+                  <value>0b1</value>
+                </enumeratedValue>
+                <enumeratedValue>
+                  <name>ANY</name>
+                  <value>0bx</value>
+                </enumeratedValue>
+              </enumeratedValues>
+            </field>
+            <field>
+              <name>OVR</name>
+              <bitRange>[1:1]</bitRange>
+            </field>
+          </fields>
+        </register>
+      </registers>
+    </peripheral>
+    <peripheral>
+      <name>UART1</name>
+      <baseAddress>0x40001000</baseAddress>
+      <interrupt>
+        <name>UART1</name>
+        <value>17</value>
+      </interrupt>
+    </peripheral>
+  </peripherals>
+</device>
+`
+	if string(got) != want {
+		t.Errorf("Format() = %s\nwant %s", got, want)
+	}
+	again, err := Format(got, FormatOptions{Sort: true, Wrap: 60})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if string(again) != string(got) {
+		t.Errorf("Format() is not idempotent:\n%s", again)
+	}
+}
+
+func TestFormat_keepsOrder(t *testing.T) {
+	got, err := Format([]byte(formatTestSource), FormatOptions{})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	s := string(got)
+	if strings.Index(s, "<name>UART1</name>") > strings.Index(s, "<name>UART0</name>") ||
+		strings.Index(s, "<name>SR</name>") > strings.Index(s, "<name>CR</name>") {
+		t.Errorf("Format() without Sort reordered elements:\n%s", s)
+	}
+	if !strings.Contains(s, "<description>Status register, with a description long enough to be wrapped</description>") {
+		t.Errorf("Format() without Wrap wrapped a description:\n%s", s)
+	}
+}
@@ -0,0 +1,185 @@
+package svd
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// GDBBus : a Bus accessing a target through the GDB remote serial protocol
+// It talks to a gdbserver, OpenOCD, pyOCD or any compatible stub.
+type GDBBus struct {
+	// Byte order of the target memory.
+	Endian EndianType
+
+	conn io.ReadWriter
+	rd   *bufio.Reader
+}
+
+// DialGDB : Connect to a GDB server listening on a TCP address
+func DialGDB(address string, endian EndianType) (*GDBBus, error) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return NewGDBBus(conn, endian), nil
+}
+
+// NewGDBBus : Create a GDBBus on an established connection
+func NewGDBBus(conn io.ReadWriter, endian EndianType) *GDBBus {
+	return &GDBBus{Endian: endian, conn: conn, rd: bufio.NewReader(conn)}
+}
+
+// Close : Close the connection if it can be closed
+func (g *GDBBus) Close() error {
+	if c, ok := g.conn.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// send : Send a packet until it is acknowledged
+func (g *GDBBus) send(data string) error {
+	var sum byte
+	for i := 0; i < len(data); i++ {
+		sum += data[i]
+	}
+	packet := fmt.Sprintf("$%s#%02x", data, sum)
+	for retry := 0; retry < 3; retry++ {
+		if _, err := io.WriteString(g.conn, packet); err != nil {
+			return err
+		}
+		ack, err := g.rd.ReadByte()
+		if err != nil {
+			return err
+		}
+		if ack == '+' {
+			return nil
+		}
+	}
+	return fmt.Errorf("gdb: packet %q not acknowledged", data)
+}
+
+// receive : Receive a packet, acknowledge it and decode it
+func (g *GDBBus) receive() (string, error) {
+	for {
+		c, err := g.rd.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if c != '$' {
+			continue
+		}
+		data, err := g.rd.ReadString('#')
+		if err != nil {
+			return "", err
+		}
+		data = data[:len(data)-1]
+		cs := make([]byte, 2)
+		if _, err := io.ReadFull(g.rd, cs); err != nil {
+			return "", err
+		}
+		var sum byte
+		for i := 0; i < len(data); i++ {
+			sum += data[i]
+		}
+		if fmt.Sprintf("%02x", sum) != strings.ToLower(string(cs)) {
+			if _, err := io.WriteString(g.conn, "-"); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if _, err := io.WriteString(g.conn, "+"); err != nil {
+			return "", err
+		}
+		return gdbDecode(data)
+	}
+}
+
+// gdbDecode : Expand the escapes and run-length encoding of a packet
+// "}x" stands for x^0x20, "x*n" for x followed by n-29 more copies of x.
+func gdbDecode(data string) (string, error) {
+	var out []byte
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '}':
+			if i++; i == len(data) {
+				return "", fmt.Errorf("gdb: truncated escape in %q", data)
+			}
+			out = append(out, data[i]^0x20)
+		case '*':
+			if i++; i == len(data) || len(out) == 0 || data[i] < 29 {
+				return "", fmt.Errorf("gdb: invalid run-length encoding in %q", data)
+			}
+			last := out[len(out)-1]
+			for n := int(data[i]) - 29; n > 0; n-- {
+				out = append(out, last)
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return string(out), nil
+}
+
+// command : Send a packet and get the reply
+func (g *GDBBus) command(data string) (string, error) {
+	if err := g.send(data); err != nil {
+		return "", err
+	}
+	reply, err := g.receive()
+	if err != nil {
+		return "", err
+	}
+	if len(reply) == 3 && reply[0] == 'E' {
+		return "", fmt.Errorf("gdb: %q failed with error %s", data, reply[1:])
+	}
+	return reply, nil
+}
+
+// Read : Read memory with the 'm' packet
+func (g *GDBBus) Read(address uint64, width uint) (uint64, error) {
+	size := int(width / 8)
+	reply, err := g.command(fmt.Sprintf("m%x,%x", address, size))
+	if err != nil {
+		return 0, err
+	}
+	data, err := hex.DecodeString(reply)
+	if err != nil || len(data) != size {
+		return 0, fmt.Errorf("gdb: invalid reply %q to memory read", reply)
+	}
+	var value uint64
+	for i := 0; i < size; i++ {
+		b := data[i]
+		if g.Endian == EndianBig {
+			b = data[size-1-i]
+		}
+		value |= uint64(b) << (8 * uint(i))
+	}
+	return value, nil
+}
+
+// Write : Write memory with the 'M' packet
+func (g *GDBBus) Write(address uint64, width uint, value uint64) error {
+	size := int(width / 8)
+	data := make([]byte, size)
+	for i := 0; i < size; i++ {
+		b := byte(value >> (8 * uint(i)))
+		if g.Endian == EndianBig {
+			data[size-1-i] = b
+		} else {
+			data[i] = b
+		}
+	}
+	reply, err := g.command(fmt.Sprintf("M%x,%x:%s", address, size, hex.EncodeToString(data)))
+	if err != nil {
+		return err
+	}
+	if reply != "OK" {
+		return fmt.Errorf("gdb: invalid reply %q to memory write", reply)
+	}
+	return nil
+}
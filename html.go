@@ -0,0 +1,360 @@
+package svd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// docField : a field as shown in the documentation
+type docField struct {
+	Name        string
+	Bits        string
+	Access      AccessType
+	Reset       string
+	Description string
+	DerivedFrom string
+	Enums       []EnumeratedValue
+	EnumsFrom   string
+}
+
+// docRegister : a register as shown in the documentation
+type docRegister struct {
+	Name        string
+	Offset      string
+	Address     string
+	Size        uint
+	Access      AccessType
+	Reset       string
+	ResetMask   string
+	Description string
+	DerivedFrom string
+	DerivedLink string
+	Diagram     template.HTML
+	Fields      []docField
+}
+
+// docLink : a link to a peripheral page
+type docLink struct {
+	Name string
+	File string
+}
+
+// docPeripheral : a peripheral instance as shown in the documentation
+type docPeripheral struct {
+	Name        string
+	File        string
+	Group       string
+	Base        string
+	Description string
+	DerivedFrom string
+
+	// Instance documenting the peripheral derived from, the first one of an array.
+	DerivedInstance string
+	DerivedLink     string
+	Derived         []docLink
+	Blocks          []AddressBlock
+	Interrupts      []Interrupt
+	Registers       []docRegister
+}
+
+var docFileRe = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// docFile : Get the page name of a peripheral
+func docFile(name string) string {
+	return docFileRe.ReplaceAllString(name, "_") + ".html"
+}
+
+// bitsLabel : Format a bit range
+func bitsLabel(lsb, msb uint) string {
+	if lsb == msb {
+		return fmt.Sprintf("[%d]", lsb)
+	}
+	return fmt.Sprintf("[%d:%d]", msb, lsb)
+}
+
+// documentation : Build the documentation model of the device
+func (dev *Device) documentation() (pages []docPeripheral, err error) {
+	periphs, err := dev.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	// page of each peripheral as written, the first instance of an array
+	firstOf := make(map[string]string)
+	for _, p := range periphs {
+		if _, ok := firstOf[p.Peripheral.Name]; !ok {
+			firstOf[p.Peripheral.Name] = p.Name
+		}
+	}
+	instance := func(name string) string {
+		if first, ok := firstOf[name]; ok {
+			return first
+		}
+		return name
+	}
+	link := func(name string) string {
+		return docFile(instance(name))
+	}
+	derived := make(map[string][]docLink)
+	for _, p := range periphs {
+		if d := p.Peripheral.DerivedFrom; d != "" {
+			derived[d] = append(derived[d], docLink{p.Name, docFile(p.Name)})
+		}
+	}
+	for _, p := range periphs {
+		src := p.Peripheral
+		page := docPeripheral{
+			Name:        p.Name,
+			File:        docFile(p.Name),
+			Group:       src.GroupName,
+			Base:        FormatHex(p.BaseAddress, 32),
+			Description: src.Description,
+			DerivedFrom: src.DerivedFrom,
+			Derived:     derived[src.Name],
+			Blocks:      src.AddressBlock,
+			Interrupts:  src.Interrupt,
+		}
+		if src.DerivedFrom != "" {
+			page.DerivedInstance = instance(src.DerivedFrom)
+			page.DerivedLink = docFile(page.DerivedInstance)
+		}
+		for _, r := range p.Registers {
+			reg := docRegister{
+				Name:        r.Name,
+				Offset:      FormatHex(r.Offset, 12),
+				Address:     FormatHex(r.Address, 32),
+				Size:        r.Size,
+				Access:      r.Access,
+				Reset:       FormatHex(r.ResetValue, r.Size),
+				ResetMask:   FormatHex(r.ResetMask, r.Size),
+				Description: r.Description,
+				DerivedFrom: r.Register.DerivedFrom,
+			}
+			svg, err := r.BitfieldSVG()
+			reg.Diagram = template.HTML(svg)
+			if err != nil {
+				reg.Diagram += template.HTML("<p>" + template.HTMLEscapeString(err.Error()) + "</p>")
+			}
+			if d := reg.DerivedFrom; d != "" {
+				reg.DerivedLink = "#" + d
+				if i := strings.LastIndex(d, "."); i >= 0 {
+					reg.DerivedLink = link(d[:i]) + "#" + d[i+1:]
+				}
+			}
+			for _, f := range r.Fields {
+				df := docField{
+					Name:        f.Name,
+					Bits:        bitsLabel(f.Lsb, f.Msb),
+					Access:      f.Access,
+					Reset:       fmt.Sprintf("0x%X", f.Extract(r.ResetValue)),
+					Description: f.Description,
+					DerivedFrom: f.Field.DerivedFrom,
+				}
+				if f.Mask()&r.ResetMask != f.Mask() {
+					df.Reset = "-"
+				}
+				if ev := f.EnumeratedValues; ev != nil {
+					df.Enums = ev.EnumeratedValue
+					if f.Field.EnumeratedValues != nil {
+						df.EnumsFrom = f.Field.EnumeratedValues.DerivedFrom
+					}
+				}
+				reg.Fields = append(reg.Fields, df)
+			}
+			page.Registers = append(page.Registers, reg)
+		}
+		pages = append(pages, page)
+	}
+	return
+}
+
+const htmlStyle = `body{font-family:sans-serif;margin:0;display:flex;color:#222}
+nav{width:16em;min-height:100vh;background:#f3f3f3;padding:1em;box-sizing:border-box}
+nav a{display:block;text-decoration:none;color:#035;padding:1px 0}
+main{flex:1;padding:1em 2em;max-width:70em}
+table{border-collapse:collapse;margin:.5em 0 1em}
+th,td{border:1px solid #bbb;padding:2px 6px;text-align:left;vertical-align:top}
+th{background:#e8e8e8}
+code{font-family:monospace}
+div.bits{overflow-x:auto}
+table.enums{margin:0;font-size:.9em}
+section.register{border-top:1px solid #ccc;margin-top:1.5em}
+#search{width:100%;box-sizing:border-box;margin-bottom:.5em}
+#results a{font-size:.85em}
+`
+
+const htmlSearch = `(function () {
+  var input = document.getElementById("search");
+  var results = document.getElementById("results");
+  input.addEventListener("input", function () {
+    var q = input.value.toLowerCase();
+    results.innerHTML = "";
+    if (q.length < 2) { return; }
+    var n = 0;
+    for (var i = 0; i < searchIndex.length && n < 50; i++) {
+      var e = searchIndex[i];
+      if (e.n.toLowerCase().indexOf(q) < 0 && e.d.toLowerCase().indexOf(q) < 0) { continue; }
+      var a = document.createElement("a");
+      a.href = e.u;
+      a.textContent = e.n;
+      a.title = e.d;
+      results.appendChild(a);
+      n++;
+    }
+  });
+})();
+`
+
+const htmlLayout = `{{define "nav"}}<nav>
+<input id="search" type="search" placeholder="Search...">
+<div id="results"></div>
+<p><a href="index.html"><b>{{.Device.Name}}</b></a></p>
+{{range .Pages}}<a href="{{.File}}">{{.Name}}</a>
+{{end}}</nav>{{end}}
+{{define "head"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.}}</title>
+<link rel="stylesheet" href="style.css"></head>{{end}}
+{{define "foot"}}<script src="search-index.js"></script>
+<script src="search.js"></script>
+</body></html>
+{{end}}
+{{define "index"}}{{template "head" .Device.Name}}<body>
+{{template "nav" .}}<main>
+<h1>{{.Device.Name}}</h1>
+<p>{{.Device.Description}}</p>
+<table>
+<tr><th>Vendor</th><td>{{.Device.Vendor}}</td></tr>
+<tr><th>Version</th><td>{{.Device.Version}}</td></tr>
+<tr><th>CPU</th><td>{{.Device.Cpu.Name}} {{.Device.Cpu.Revision}} {{.Device.Cpu.Endian}}</td></tr>
+</table>
+<h2>Peripherals</h2>
+<table>
+<tr><th>Name</th><th>Base address</th><th>Group</th><th>Description</th></tr>
+{{range .Pages}}<tr><td><a href="{{.File}}">{{.Name}}</a></td><td><code>{{.Base}}</code></td><td>{{.Group}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+{{if .Interrupts}}<h2>Interrupts</h2>
+<table>
+<tr><th>Number</th><th>Name</th><th>Peripherals</th><th>Description</th></tr>
+{{range .Interrupts}}<tr><td>{{.Number}}</td><td>{{.Name}}</td><td>{{range .Peripherals}}<a href="{{docFile .}}">{{.}}</a> {{end}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>{{end}}
+</main>
+{{template "foot"}}{{end}}
+{{define "peripheral"}}{{template "head" .Page.Name}}<body>
+{{template "nav" .}}<main>{{with .Page}}
+<h1>{{.Name}}</h1>
+<p>{{.Description}}</p>
+<table>
+<tr><th>Base address</th><td><code>{{.Base}}</code></td></tr>
+{{if .Group}}<tr><th>Group</th><td>{{.Group}}</td></tr>{{end}}
+{{if .DerivedFrom}}<tr><th>Derived from</th><td><a href="{{.DerivedLink}}">{{.DerivedFrom}}</a></td></tr>{{end}}
+{{if .Derived}}<tr><th>Derived by</th><td>{{range .Derived}}<a href="{{.File}}">{{.Name}}</a> {{end}}</td></tr>{{end}}
+{{range .Blocks}}<tr><th>Address block</th><td>offset <code>0x{{printf "%X" .Offset}}</code>, size <code>{{.Size}}</code>, {{.Usage}}</td></tr>{{end}}
+</table>
+{{if .Interrupts}}<h2>Interrupts</h2>
+<table><tr><th>Number</th><th>Name</th><th>Description</th></tr>
+{{range .Interrupts}}<tr><td>{{.Value}}</td><td>{{.Name}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>{{end}}
+<h2>Registers</h2>
+<table>
+<tr><th>Offset</th><th>Name</th><th>Access</th><th>Reset</th><th>Description</th></tr>
+{{range .Registers}}<tr><td><code>{{.Offset}}</code></td><td><a href="#{{.Name}}">{{.Name}}</a></td><td>{{.Access}}</td><td><code>{{.Reset}}</code></td><td>{{.Description}}</td></tr>
+{{end}}</table>
+{{range .Registers}}{{$reg := .Name}}<section class="register" id="{{.Name}}">
+<h3>{{.Name}}</h3>
+<p>{{.Description}}</p>
+<p>Address <code>{{.Address}}</code>, offset <code>{{.Offset}}</code>, {{.Size}} bits, {{.Access}},
+reset <code>{{.Reset}}</code> (mask <code>{{.ResetMask}}</code>){{if .DerivedFrom}}, derived from <a href="{{.DerivedLink}}">{{.DerivedFrom}}</a>{{end}}</p>
+<div class="bits">{{.Diagram}}</div>
+{{if .Fields}}<table>
+<tr><th>Bits</th><th>Name</th><th>Access</th><th>Reset</th><th>Description</th></tr>
+{{range .Fields}}<tr><td>{{.Bits}}</td><td id="{{$reg}}.{{.Name}}">{{.Name}}</td><td>{{.Access}}</td><td><code>{{.Reset}}</code></td><td>{{.Description}}
+{{if .DerivedFrom}}<br>Derived from {{.DerivedFrom}}{{end}}
+{{if .Enums}}{{if .EnumsFrom}}<br>Values from {{.EnumsFrom}}{{end}}<table class="enums">
+{{range .Enums}}<tr><td><code>{{if .IsDefault}}default{{else}}{{.Value}}{{end}}</code></td><td>{{.Name}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>{{end}}</td></tr>
+{{end}}</table>{{end}}
+</section>
+{{end}}{{end}}</main>
+{{template "foot"}}{{end}}`
+
+var htmlTemplates = template.Must(template.New("html").Funcs(template.FuncMap{"docFile": docFile}).Parse(htmlLayout))
+
+// searchEntry : an entry of the search index
+type searchEntry struct {
+	Name        string `json:"n"`
+	URL         string `json:"u"`
+	Description string `json:"d"`
+}
+
+// HTML : Generate a static and searchable HTML documentation
+// The result maps file names to their content: index.html, one page per
+// peripheral instance, the style sheet and the search scripts.
+// No external resource is referenced so the site works offline.
+func (dev *Device) HTML() (files map[string][]byte, err error) {
+	pages, err := dev.documentation()
+	if err != nil {
+		return nil, err
+	}
+	its, _ := dev.Interrupts()
+	files = map[string][]byte{
+		"style.css": []byte(htmlStyle),
+		"search.js": []byte(htmlSearch),
+	}
+	var index []searchEntry
+	for _, p := range pages {
+		index = append(index, searchEntry{p.Name, p.File, p.Description})
+		for _, r := range p.Registers {
+			index = append(index, searchEntry{p.Name + "." + r.Name, p.File + "#" + r.Name, r.Description})
+			for _, f := range r.Fields {
+				index = append(index, searchEntry{p.Name + "." + r.Name + "." + f.Name, p.File + "#" + r.Name + "." + f.Name, f.Description})
+			}
+		}
+	}
+	js, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+	files["search-index.js"] = append(append([]byte("var searchIndex = "), js...), ";\n"...)
+	data := struct {
+		Device     *Device
+		Pages      []docPeripheral
+		Interrupts []DeviceInterrupt
+		Page       docPeripheral
+	}{Device: dev, Pages: pages, Interrupts: its}
+	var b bytes.Buffer
+	if err = htmlTemplates.ExecuteTemplate(&b, "index", data); err != nil {
+		return nil, err
+	}
+	files["index.html"] = b.Bytes()
+	for _, p := range pages {
+		var b bytes.Buffer
+		data.Page = p
+		if err = htmlTemplates.ExecuteTemplate(&b, "peripheral", data); err != nil {
+			return nil, err
+		}
+		files[p.File] = b.Bytes()
+	}
+	return
+}
+
+// WriteFiles : Write generated files into a directory
+func WriteFiles(dir string, files map[string][]byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package svd
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func htmlTestDevice() *Device {
+	dev := NewDevice("DocDevice")
+	dev.Peripherals.Peripheral = []Peripheral{
+		{
+			Name:         "TIM%s",
+			Description:  "Timer",
+			Dim:          2,
+			DimIncrement: 0x1000,
+			BaseAddress:  "0x40000000",
+			Registers: &Registers{Register: []Register{
+				{
+					Name:          "CTRL",
+					Description:   "Control",
+					AddressOffset: "0x0",
+					Fields: &Fields{Field: []Field{
+						{Name: "EN", Description: "Enable", BitRange: "[0:0]"},
+					}},
+				},
+			}},
+		},
+		{
+			Name:        "LPTIM",
+			DerivedFrom: "TIM%s",
+			BaseAddress: "0x40010000",
+		},
+	}
+	return dev
+}
+
+func TestDevice_HTML(t *testing.T) {
+	files, err := htmlTestDevice().HTML()
+	if err != nil {
+		t.Fatalf("Device.HTML() error = %v", err)
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	wantNames := []string{"LPTIM.html", "TIM0.html", "TIM1.html", "index.html", "search-index.js", "search.js", "style.css"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("Device.HTML() files = %v, want %v", names, wantNames)
+	}
+
+	js := strings.TrimSuffix(strings.TrimPrefix(string(files["search-index.js"]), "var searchIndex = "), ";\n")
+	var index []searchEntry
+	if err := json.Unmarshal([]byte(js), &index); err != nil {
+		t.Fatalf("search index: %v", err)
+	}
+	wantIndex := []searchEntry{
+		{"TIM0", "TIM0.html", "Timer"},
+		{"TIM0.CTRL", "TIM0.html#CTRL", "Control"},
+		{"TIM0.CTRL.EN", "TIM0.html#CTRL.EN", "Enable"},
+		{"TIM1", "TIM1.html", "Timer"},
+		{"TIM1.CTRL", "TIM1.html#CTRL", "Control"},
+		{"TIM1.CTRL.EN", "TIM1.html#CTRL.EN", "Enable"},
+		{"LPTIM", "LPTIM.html", "Timer"},
+		{"LPTIM.CTRL", "LPTIM.html#CTRL", "Control"},
+		{"LPTIM.CTRL.EN", "LPTIM.html#CTRL.EN", "Enable"},
+	}
+	if !reflect.DeepEqual(index, wantIndex) {
+		t.Errorf("search index = %v, want %v", index, wantIndex)
+	}
+
+	for _, tt := range []struct {
+		file string
+		want string
+	}{
+		{"LPTIM.html", `<tr><th>Derived from</th><td><a href="TIM0.html">TIM%s</a></td></tr>`},
+		{"TIM0.html", `<tr><th>Derived by</th><td><a href="LPTIM.html">LPTIM</a> </td></tr>`},
+		{"TIM1.html", `<tr><th>Derived by</th><td><a href="LPTIM.html">LPTIM</a> </td></tr>`},
+		{"index.html", `href="TIM1.html"`},
+	} {
+		if !strings.Contains(string(files[tt.file]), tt.want) {
+			t.Errorf("%s does not contain %s:\n%s", tt.file, tt.want, files[tt.file])
+		}
+	}
+}
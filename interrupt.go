@@ -0,0 +1,272 @@
+package svd
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+// maxInterrupts : the number of external interrupts a Cortex-M NVIC supports at most
+const maxInterrupts = 496
+
+// DeviceInterrupt : an interrupt of the device with its number resolved
+type DeviceInterrupt struct {
+	Interrupt
+
+	// Interrupt number (position in the external interrupt vectors).
+	Number uint
+
+	// Names of the peripherals listing this interrupt.
+	Peripherals []string
+}
+
+// Interrupts : Collect the interrupts of all peripherals
+// Interrupts shared across peripherals are listed once, sorted by number.
+// Numbers above <deviceNumInterrupts> (or above the NVIC limit of 496
+// interrupts when not specified), unparsable values and numbers used with
+// different names are reported as errors.
+func (dev *Device) Interrupts() (its []DeviceInterrupt, errs []error) {
+	byNumber := make(map[uint]int)
+	byName := make(map[string]int)
+	for _, p := range dev.Peripherals.Peripheral {
+		for _, it := range p.Interrupt {
+			n, err := ParseNumber(it.Value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("peripheral %s interrupt %s: %v", p.Name, it.Name, err))
+				continue
+			}
+			num := uint(n)
+			if i, ok := byNumber[num]; ok {
+				if its[i].Name != it.Name {
+					errs = append(errs, fmt.Errorf("interrupt %d is %s in peripheral %s but %s in peripheral %s",
+						num, its[i].Name, strings.Join(its[i].Peripherals, ","), it.Name, p.Name))
+					continue
+				}
+				its[i].Peripherals = append(its[i].Peripherals, p.Name)
+				if its[i].Description == "" {
+					its[i].Description = it.Description
+				}
+				continue
+			}
+			if i, ok := byName[it.Name]; ok {
+				errs = append(errs, fmt.Errorf("interrupt %s is %d in peripheral %s but %d in peripheral %s",
+					it.Name, its[i].Number, strings.Join(its[i].Peripherals, ","), num, p.Name))
+				continue
+			}
+			if dev.Cpu.DeviceNumInterrupts != 0 && num >= dev.Cpu.DeviceNumInterrupts {
+				errs = append(errs, fmt.Errorf("interrupt %s (%d) is out of range, deviceNumInterrupts is %d",
+					it.Name, num, dev.Cpu.DeviceNumInterrupts))
+			} else if dev.Cpu.DeviceNumInterrupts == 0 && num >= maxInterrupts {
+				errs = append(errs, fmt.Errorf("interrupt %s (%d) is out of range, the NVIC supports %d interrupts",
+					it.Name, num, maxInterrupts))
+			}
+			byNumber[num] = len(its)
+			byName[it.Name] = len(its)
+			its = append(its, DeviceInterrupt{Interrupt: it, Number: num, Peripherals: []string{p.Name}})
+		}
+	}
+	sort.SliceStable(its, func(i, j int) bool { return its[i].Number < its[j].Number })
+	return
+}
+
+// Vector : an entry of the vector table
+type Vector struct {
+	// Name of the exception or interrupt, empty for a reserved entry.
+	Name string
+
+	// Name of the handler function, empty for a reserved entry.
+	Handler string
+
+	// Comment of the entry.
+	Description string
+}
+
+// VectorTable : the exception vectors followed by the interrupt vectors
+type VectorTable struct {
+	// Name of the device.
+	Device string
+
+	// Processor exceptions, vector 1 (Reset) to 15 (SysTick).
+	// Empty for processors without Cortex-M style vector table.
+	Exceptions []Vector
+
+	// Device interrupts starting with interrupt 0.
+	Interrupts []Vector
+}
+
+// cortexMExceptions : processor exceptions, index 0 is vector 1 (Reset)
+func cortexMExceptions(name CpuName) []Vector {
+	var mainline, security bool
+	switch name {
+	case CpuNameCM0, CpuNameCM0p, CpuNameCM1, CpuNameSC000, CpuNameCM23:
+	case CpuNameCM3, CpuNameCM4, CpuNameCM7, CpuNameSC300:
+		mainline = true
+	case CpuNameCM33, CpuNameCM35P, CpuNameCM55:
+		mainline = true
+		security = true
+	default:
+		return nil
+	}
+	exc := func(name, description string) Vector {
+		return Vector{Name: name, Handler: name + "_Handler", Description: description}
+	}
+	vectors := make([]Vector, 15)
+	vectors[0] = exc("Reset", "Reset Handler")
+	vectors[1] = exc("NMI", "Non Maskable Interrupt")
+	vectors[2] = exc("HardFault", "Hard Fault Interrupt")
+	if mainline {
+		vectors[3] = exc("MemManage", "Memory Management Interrupt")
+		vectors[4] = exc("BusFault", "Bus Fault Interrupt")
+		vectors[5] = exc("UsageFault", "Usage Fault Interrupt")
+		vectors[11] = exc("DebugMon", "Debug Monitor Interrupt")
+	}
+	if security {
+		vectors[6] = exc("SecureFault", "Secure Fault Interrupt")
+	}
+	vectors[10] = exc("SVC", "SV Call Interrupt")
+	vectors[13] = exc("PendSV", "Pend SV Interrupt")
+	vectors[14] = exc("SysTick", "System Tick Interrupt")
+	return vectors
+}
+
+// VectorTable : Build the vector table of the device
+// The number of interrupt vectors is <deviceNumInterrupts>, or the
+// highest interrupt number plus one when not specified. Interrupts out of
+// range are reported by Interrupts and left out of the table.
+func (dev *Device) VectorTable() (vt VectorTable, errs []error) {
+	its, errs := dev.Interrupts()
+	vt.Device = dev.Name
+	vt.Exceptions = cortexMExceptions(dev.Cpu.Name)
+	limit := dev.Cpu.DeviceNumInterrupts
+	if limit == 0 {
+		limit = maxInterrupts
+	}
+	for len(its) > 0 && its[len(its)-1].Number >= limit {
+		its = its[:len(its)-1]
+	}
+	count := dev.Cpu.DeviceNumInterrupts
+	if len(its) > 0 && its[len(its)-1].Number >= count {
+		count = its[len(its)-1].Number + 1
+	}
+	vt.Interrupts = make([]Vector, count)
+	for _, it := range its {
+		vt.Interrupts[it.Number] = Vector{
+			Name:        it.Name,
+			Handler:     it.Name + "_IRQHandler",
+			Description: it.Description,
+		}
+	}
+	return
+}
+
+// handlers : Get the distinct handler names of the table
+func (vt VectorTable) handlers() (names []string) {
+	for _, v := range append(append([]Vector{}, vt.Exceptions...), vt.Interrupts...) {
+		if v.Handler != "" {
+			names = append(names, v.Handler)
+		}
+	}
+	return
+}
+
+// blockComment : Get a description fit for a /* */ comment on one line
+func blockComment(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "*/", "* /")
+}
+
+// C : Generate the vector table as a C startup array
+// Every handler is declared weak and aliased to Default_Handler.
+func (vt VectorTable) C() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "/* Vector table of %s, generated from its SVD description */\n\n", vt.Device)
+	b.WriteString("extern unsigned long __StackTop;\n\n")
+	b.WriteString("void Default_Handler(void)\n{\n  while (1);\n}\n\n")
+	for _, h := range vt.handlers() {
+		fmt.Fprintf(&b, "void %s(void) __attribute__((weak, alias(\"Default_Handler\")));\n", h)
+	}
+	b.WriteString("\ntypedef void (*vector_t)(void);\n\n")
+	b.WriteString("const vector_t __Vectors[] __attribute__((section(\".isr_vector\"), used)) = {\n")
+	b.WriteString("  (vector_t)&__StackTop,\n")
+	entry := func(v Vector, comment string) {
+		if v.Handler == "" {
+			fmt.Fprintf(&b, "  0, /* %s Reserved */\n", comment)
+		} else {
+			fmt.Fprintf(&b, "  %s, /* %s %s */\n", v.Handler, comment, blockComment(v.Description))
+		}
+	}
+	for i, v := range vt.Exceptions {
+		entry(v, fmt.Sprintf("%d:", i-15))
+	}
+	for i, v := range vt.Interrupts {
+		entry(v, fmt.Sprintf("%d:", i))
+	}
+	b.WriteString("};\n")
+	return b.Bytes()
+}
+
+// Asm : Generate the vector table as a GNU assembler .word table
+// Every handler is declared weak and set to Default_Handler.
+func (vt VectorTable) Asm() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "/* Vector table of %s, generated from its SVD description */\n\n", vt.Device)
+	b.WriteString("    .syntax unified\n    .thumb\n\n")
+	b.WriteString("    .section .isr_vector,\"a\",%progbits\n")
+	b.WriteString("    .align 2\n    .globl __Vectors\n    .type __Vectors, %object\n__Vectors:\n")
+	b.WriteString("    .word __StackTop\n")
+	entry := func(v Vector, comment string) {
+		if v.Handler == "" {
+			fmt.Fprintf(&b, "    .word 0 /* %s Reserved */\n", comment)
+		} else {
+			fmt.Fprintf(&b, "    .word %s /* %s %s */\n", v.Handler, comment, blockComment(v.Description))
+		}
+	}
+	for i, v := range vt.Exceptions {
+		entry(v, fmt.Sprintf("%d:", i-15))
+	}
+	for i, v := range vt.Interrupts {
+		entry(v, fmt.Sprintf("%d:", i))
+	}
+	b.WriteString("    .size __Vectors, . - __Vectors\n\n")
+	b.WriteString("    .text\n    .thumb_func\n    .weak Default_Handler\n    .type Default_Handler, %function\n")
+	b.WriteString("Default_Handler:\n    b .\n    .size Default_Handler, . - Default_Handler\n\n")
+	for _, h := range vt.handlers() {
+		fmt.Fprintf(&b, "    .weak %s\n    .thumb_set %s, Default_Handler\n", h, h)
+	}
+	return b.Bytes()
+}
+
+// Go : Generate the interrupt numbers and a handler map for Go/TinyGo
+// IRQ_max is only declared when the device has interrupts. The source is
+// gofmt'ed, an error is returned if it does not parse (invalid package name).
+func (vt VectorTable) Go(pkg string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated from the %s SVD description. DO NOT EDIT.\n\n", vt.Device)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString("// Interrupt numbers.\nconst (\n")
+	for i, v := range vt.Interrupts {
+		if v.Name != "" {
+			fmt.Fprintf(&b, "\tIRQ_%s = %d // %s\n", v.Name, i, strings.Join(strings.Fields(v.Description), " "))
+		}
+	}
+	if len(vt.Interrupts) > 0 {
+		fmt.Fprintf(&b, "\n\t// Highest interrupt number on this device.\n\tIRQ_max = %d\n", len(vt.Interrupts)-1)
+	}
+	b.WriteString(")\n\n")
+	b.WriteString("// InterruptNames maps each interrupt number to its name.\nvar InterruptNames = map[int]string{\n")
+	for _, v := range vt.Interrupts {
+		if v.Name != "" {
+			fmt.Fprintf(&b, "\tIRQ_%s: %q,\n", v.Name, v.Name)
+		}
+	}
+	b.WriteString("}\n\n")
+	b.WriteString("// InterruptHandlers maps interrupt numbers to their handler.\nvar InterruptHandlers = map[int]func(){}\n\n")
+	b.WriteString("// HandleInterrupt calls the handler registered for an interrupt, if any.\n")
+	b.WriteString("func HandleInterrupt(irq int) {\n\tif h, ok := InterruptHandlers[irq]; ok {\n\t\th()\n\t}\n}\n")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("vector table of %s: %v", vt.Device, err)
+	}
+	return src, nil
+}
@@ -0,0 +1,159 @@
+package svd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDevice_Interrupts(t *testing.T) {
+	tests := []struct {
+		name     string
+		numIrq   uint
+		periphs  []Peripheral
+		want     []DeviceInterrupt
+		wantErrs []string
+	}{
+		{
+			name: "shared and sorted",
+			periphs: []Peripheral{
+				{Name: "TIMER0", Interrupt: []Interrupt{{Name: "TIMER", Value: "3"}}},
+				{Name: "UART0", Interrupt: []Interrupt{{Name: "UART0", Value: "0x1"}}},
+				{Name: "TIMER1", Interrupt: []Interrupt{{Name: "TIMER", Description: "Timer Interrupt", Value: "3"}}},
+			},
+			want: []DeviceInterrupt{
+				{Interrupt: Interrupt{Name: "UART0", Value: "0x1"}, Number: 1, Peripherals: []string{"UART0"}},
+				{Interrupt: Interrupt{Name: "TIMER", Description: "Timer Interrupt", Value: "3"}, Number: 3, Peripherals: []string{"TIMER0", "TIMER1"}},
+			},
+		},
+		{
+			name:   "conflicts and range",
+			numIrq: 4,
+			periphs: []Peripheral{
+				{Name: "UART0", Interrupt: []Interrupt{{Name: "UART0", Value: "1"}}},
+				{Name: "UART1", Interrupt: []Interrupt{{Name: "UART1", Value: "1"}, {Name: "UART0", Value: "2"}}},
+				{Name: "ADC", Interrupt: []Interrupt{{Name: "ADC", Value: "4"}, {Name: "DAC", Value: "x"}}},
+			},
+			want: []DeviceInterrupt{
+				{Interrupt: Interrupt{Name: "UART0", Value: "1"}, Number: 1, Peripherals: []string{"UART0"}},
+				{Interrupt: Interrupt{Name: "ADC", Value: "4"}, Number: 4, Peripherals: []string{"ADC"}},
+			},
+			wantErrs: []string{
+				"interrupt 1 is UART0 in peripheral UART0 but UART1 in peripheral UART1",
+				"interrupt UART0 is 1 in peripheral UART0 but 2 in peripheral UART1",
+				"interrupt ADC (4) is out of range, deviceNumInterrupts is 4",
+				"peripheral ADC interrupt DAC: invalid number \"x\"",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := Device{}
+			dev.Cpu.DeviceNumInterrupts = tt.numIrq
+			dev.Peripherals.Peripheral = tt.periphs
+			got, errs := dev.Interrupts()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Device.Interrupts() = %v, want %v", got, tt.want)
+			}
+			var gotErrs []string
+			for _, err := range errs {
+				gotErrs = append(gotErrs, err.Error())
+			}
+			if !reflect.DeepEqual(gotErrs, tt.wantErrs) {
+				t.Errorf("Device.Interrupts() errors = %q, want %q", gotErrs, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestDevice_VectorTable(t *testing.T) {
+	tests := []struct {
+		name      string
+		numIrq    uint
+		wantCount int
+		wantErrs  int
+	}{
+		{"highest interrupt", 0, 3, 1},
+		{"deviceNumInterrupts", 8, 8, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := Device{Name: "DEV"}
+			dev.Cpu.DeviceNumInterrupts = tt.numIrq
+			dev.Peripherals.Peripheral = []Peripheral{
+				{Name: "UART0", Interrupt: []Interrupt{{Name: "UART0", Description: "UART 0", Value: "2"}}},
+				{Name: "TIMER", Interrupt: []Interrupt{{Name: "TIMER", Value: "1000000000"}}},
+			}
+			vt, errs := dev.VectorTable()
+			if len(vt.Interrupts) != tt.wantCount {
+				t.Fatalf("Device.VectorTable() has %d interrupts, want %d", len(vt.Interrupts), tt.wantCount)
+			}
+			if len(errs) != tt.wantErrs {
+				t.Errorf("Device.VectorTable() errors = %v, want %d errors", errs, tt.wantErrs)
+			}
+			want := Vector{Name: "UART0", Handler: "UART0_IRQHandler", Description: "UART 0"}
+			if !reflect.DeepEqual(vt.Interrupts[2], want) {
+				t.Errorf("Device.VectorTable() interrupt 2 = %v, want %v", vt.Interrupts[2], want)
+			}
+		})
+	}
+}
+
+func TestVectorTable_renderers(t *testing.T) {
+	dev := Device{Name: "DEV"}
+	dev.Cpu.Name = CpuNameCM0
+	dev.Peripherals.Peripheral = []Peripheral{
+		{Name: "UART0", Interrupt: []Interrupt{{Name: "UART0", Description: "UART 0\n  interrupt */", Value: "2"}}},
+	}
+	vt, errs := dev.VectorTable()
+	if len(errs) != 0 {
+		t.Fatalf("Device.VectorTable() errors = %v", errs)
+	}
+	tests := []struct {
+		name string
+		got  []byte
+		want []string
+	}{
+		{"C", vt.C(), []string{
+			"void UART0_IRQHandler(void) __attribute__((weak, alias(\"Default_Handler\")));\n",
+			"  (vector_t)&__StackTop,\n  Reset_Handler, /* -15: Reset Handler */\n",
+			"  0, /* -12: Reserved */\n",
+			"  SysTick_Handler, /* -1: System Tick Interrupt */\n  0, /* 0: Reserved */\n  0, /* 1: Reserved */\n  UART0_IRQHandler, /* 2: UART 0 interrupt * / */\n",
+		}},
+		{"Asm", vt.Asm(), []string{
+			"__Vectors:\n    .word __StackTop\n    .word Reset_Handler /* -15: Reset Handler */\n",
+			"    .word 0 /* 1: Reserved */\n    .word UART0_IRQHandler /* 2: UART 0 interrupt * / */\n",
+			"    .weak UART0_IRQHandler\n    .thumb_set UART0_IRQHandler, Default_Handler\n",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, want := range tt.want {
+				if !strings.Contains(string(tt.got), want) {
+					t.Errorf("VectorTable.%s() lacks %q", tt.name, want)
+				}
+			}
+		})
+	}
+
+	got, err := vt.Go("dev")
+	if err != nil {
+		t.Fatalf("VectorTable.Go() error = %v", err)
+	}
+	for _, want := range []string{"package dev\n", "\tIRQ_UART0 = 2 // UART 0 interrupt */\n",
+		"\tIRQ_max = 2\n", "\tIRQ_UART0: \"UART0\",\n"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("VectorTable.Go() lacks %q", want)
+		}
+	}
+	got, err = VectorTable{Device: "EMPTY"}.Go("empty")
+	if err != nil {
+		t.Fatalf("VectorTable.Go() without interrupts error = %v", err)
+	}
+	if strings.Contains(string(got), "IRQ_max") {
+		t.Errorf("VectorTable.Go() without interrupts declares IRQ_max:\n%s", got)
+	}
+	if _, err := vt.Go("1dev"); err == nil {
+		t.Errorf("VectorTable.Go(1dev) error = nil, want an error")
+	}
+}
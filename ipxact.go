@@ -0,0 +1,686 @@
+package svd
+
+// source : IEEE 1685-2014 and IEEE 1685-2022 (IP-XACT)
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// IPXACTVersion : revision of the IP-XACT standard
+type IPXACTVersion string
+
+const (
+	IPXACT2014 IPXACTVersion = "1685-2014"
+	IPXACT2022 IPXACTVersion = "1685-2022"
+)
+
+// namespace : Get the XML namespace of the revision
+func (v IPXACTVersion) namespace() string {
+	return "http://www.accellera.org/XMLSchema/IPXACT/" + string(v)
+}
+
+// Mapping between SVD and IP-XACT:
+//
+//	device                    component (vendor, name, version, description)
+//	peripheral instance       addressBlock of the memoryMap named after the device
+//	  baseAddress             baseAddress
+//	  addressBlock            range (the blocks are merged) and usage
+//	  groupName               typeIdentifier
+//	register                  register (arrays are expanded on export)
+//	  access                  access (2014), accessPolicies/accessPolicy/access (2022)
+//	  resetValue/resetMask    resets/reset of every field
+//	field                     field with bitOffset and bitWidth
+//	  access                  access (2014), fieldAccessPolicy/access (2022)
+//	  modifiedWriteValues     modifiedWriteValue, values are the same
+//	  readAction              readAction, modifyExternal becomes modify
+//	  writeConstraint         writeValueConstraint
+//	  enumeratedValues        enumeratedValues, usage moves to every value
+//
+// Usage "registers" is "register" in IP-XACT, "buffer" is "memory".
+// A register without field becomes a register with a single field of the
+// same name, folded back on import.
+
+// ipxactReset : the reset value of a field
+type ipxactReset struct {
+	ResetTypeRef string `xml:"resetTypeRef,attr,omitempty"`
+	Value        string `xml:"value"`
+	Mask         string `xml:"mask,omitempty"`
+}
+
+// ipxactWriteConstraint : the values a field accepts
+type ipxactWriteConstraint struct {
+	WriteAsRead         bool   `xml:"writeAsRead,omitempty"`
+	UseEnumeratedValues bool   `xml:"useEnumeratedValues,omitempty"`
+	Minimum             string `xml:"minimum,omitempty"`
+	Maximum             string `xml:"maximum,omitempty"`
+}
+
+// ipxactEnumeratedValue : a named value of a field
+type ipxactEnumeratedValue struct {
+	Usage       string `xml:"usage,attr,omitempty"`
+	Name        string `xml:"name"`
+	Description string `xml:"description,omitempty"`
+	Value       string `xml:"value"`
+}
+
+// ipxactFieldAccessPolicy : the access of a field (2022)
+type ipxactFieldAccessPolicy struct {
+	ModeRef              []string               `xml:"modeRef,omitempty"`
+	Access               AccessType             `xml:"access,omitempty"`
+	ModifiedWriteValue   ModifiedWriteValues    `xml:"modifiedWriteValue,omitempty"`
+	WriteValueConstraint *ipxactWriteConstraint `xml:"writeValueConstraint,omitempty"`
+	ReadAction           ReadAction             `xml:"readAction,omitempty"`
+	Reserved             string                 `xml:"reserved,omitempty"`
+}
+
+// ipxactField : a field of a register
+// Elements are in the order of the 2014 schema; MarshalXML follows the
+// 2022 order when v2022 is set.
+type ipxactField struct {
+	Name                 string                    `xml:"name"`
+	Description          string                    `xml:"description,omitempty"`
+	BitOffset            string                    `xml:"bitOffset"`
+	Resets               []ipxactReset             `xml:"resets>reset,omitempty"`
+	TypeIdentifier       string                    `xml:"typeIdentifier,omitempty"`
+	BitWidth             string                    `xml:"bitWidth"`
+	Volatile             bool                      `xml:"volatile,omitempty"`
+	Access               AccessType                `xml:"access,omitempty"`
+	EnumeratedValues     []ipxactEnumeratedValue   `xml:"enumeratedValues>enumeratedValue,omitempty"`
+	ModifiedWriteValue   ModifiedWriteValues       `xml:"modifiedWriteValue,omitempty"`
+	WriteValueConstraint *ipxactWriteConstraint    `xml:"writeValueConstraint,omitempty"`
+	ReadAction           ReadAction                `xml:"readAction,omitempty"`
+	Reserved             string                    `xml:"reserved,omitempty"`
+	AccessPolicies       []ipxactFieldAccessPolicy `xml:"fieldAccessPolicies>fieldAccessPolicy,omitempty"`
+
+	v2022 bool
+}
+
+// MarshalXML : Encode the field in the element order of its revision
+func (f ipxactField) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain ipxactField
+	if !f.v2022 {
+		return e.EncodeElement(plain(f), start)
+	}
+	return e.EncodeElement(struct {
+		Name             string                    `xml:"name"`
+		Description      string                    `xml:"description,omitempty"`
+		BitOffset        string                    `xml:"bitOffset"`
+		TypeIdentifier   string                    `xml:"typeIdentifier,omitempty"`
+		BitWidth         string                    `xml:"bitWidth"`
+		Volatile         bool                      `xml:"volatile,omitempty"`
+		Resets           []ipxactReset             `xml:"resets>reset,omitempty"`
+		AccessPolicies   []ipxactFieldAccessPolicy `xml:"fieldAccessPolicies>fieldAccessPolicy,omitempty"`
+		EnumeratedValues []ipxactEnumeratedValue   `xml:"enumeratedValues>enumeratedValue,omitempty"`
+	}{f.Name, f.Description, f.BitOffset, f.TypeIdentifier, f.BitWidth, f.Volatile, f.Resets,
+		f.AccessPolicies, f.EnumeratedValues}, start)
+}
+
+// ipxactAccessPolicy : the access of a register (2022)
+type ipxactAccessPolicy struct {
+	ModeRef []string   `xml:"modeRef,omitempty"`
+	Access  AccessType `xml:"access,omitempty"`
+}
+
+// ipxactArray : the dimensions of a register array (2022)
+type ipxactArray struct {
+	Dim    []string `xml:"dim"`
+	Stride string   `xml:"stride,omitempty"`
+}
+
+// ipxactRegister : a register of an address block
+type ipxactRegister struct {
+	Name           string               `xml:"name"`
+	Description    string               `xml:"description,omitempty"`
+	Array          *ipxactArray         `xml:"array,omitempty"`
+	Dim            []string             `xml:"dim,omitempty"`
+	AddressOffset  string               `xml:"addressOffset"`
+	TypeIdentifier string               `xml:"typeIdentifier,omitempty"`
+	Size           string               `xml:"size"`
+	Volatile       bool                 `xml:"volatile,omitempty"`
+	Access         AccessType           `xml:"access,omitempty"`
+	AccessPolicies []ipxactAccessPolicy `xml:"accessPolicies>accessPolicy,omitempty"`
+	Fields         []ipxactField        `xml:"field"`
+}
+
+// ipxactRegisterFile : a group of registers, only detected on import
+type ipxactRegisterFile struct {
+	Name string `xml:"name"`
+}
+
+// ipxactAddressBlock : a range of registers or memory
+type ipxactAddressBlock struct {
+	Name           string               `xml:"name"`
+	Description    string               `xml:"description,omitempty"`
+	BaseAddress    string               `xml:"baseAddress"`
+	TypeIdentifier string               `xml:"typeIdentifier,omitempty"`
+	Range          string               `xml:"range"`
+	Width          string               `xml:"width"`
+	Usage          string               `xml:"usage,omitempty"`
+	Registers      []ipxactRegister     `xml:"register,omitempty"`
+	RegisterFiles  []ipxactRegisterFile `xml:"registerFile,omitempty"`
+}
+
+// ipxactMemoryMap : the address blocks seen from a bus interface
+type ipxactMemoryMap struct {
+	Name            string               `xml:"name"`
+	Description     string               `xml:"description,omitempty"`
+	AddressBlocks   []ipxactAddressBlock `xml:"addressBlock"`
+	AddressUnitBits uint                 `xml:"addressUnitBits,omitempty"`
+}
+
+// ipxactComponent : an IP-XACT component
+// The description ends the component in 2014 and follows its version in
+// 2022; MarshalXML places it according to v2022.
+type ipxactComponent struct {
+	XMLName     xml.Name          `xml:"component"`
+	Xmlns       string            `xml:"xmlns,attr,omitempty"`
+	Vendor      string            `xml:"vendor"`
+	Library     string            `xml:"library"`
+	Name        string            `xml:"name"`
+	Version     string            `xml:"version"`
+	MemoryMaps  []ipxactMemoryMap `xml:"memoryMaps>memoryMap,omitempty"`
+	Description string            `xml:"description,omitempty"`
+
+	v2022 bool
+}
+
+// MarshalXML : Encode the component in the element order of its revision
+func (c ipxactComponent) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain ipxactComponent
+	start.Name = xml.Name{Local: "component"}
+	if !c.v2022 {
+		return e.EncodeElement(plain(c), start)
+	}
+	start.Attr = []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: c.Xmlns}}
+	return e.EncodeElement(struct {
+		Vendor      string            `xml:"vendor"`
+		Library     string            `xml:"library"`
+		Name        string            `xml:"name"`
+		Version     string            `xml:"version"`
+		Description string            `xml:"description,omitempty"`
+		MemoryMaps  []ipxactMemoryMap `xml:"memoryMaps>memoryMap,omitempty"`
+	}{c.Vendor, c.Library, c.Name, c.Version, c.Description, c.MemoryMaps}, start)
+}
+
+var ipxactLiteralRe = regexp.MustCompile(`^([0-9]*)'[sS]?([hHdDbBoO])([0-9a-fA-FxXzZ?]+)$`)
+
+// parseIPXACTNumber : Parse an IP-XACT number
+// Both SystemVerilog literals ('h1F, 8'd31) and SVD numbers are accepted.
+func parseIPXACTNumber(s string) (uint64, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "_", "")
+	m := ipxactLiteralRe.FindStringSubmatch(s)
+	if m == nil {
+		return ParseNumber(s)
+	}
+	base := map[string]int{"h": 16, "d": 10, "b": 2, "o": 8}[strings.ToLower(m[2])]
+	n, err := strconv.ParseUint(m[3], base, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
+
+// ipxactNumber : Format a number as a SystemVerilog literal
+func ipxactNumber(n uint64) string {
+	return fmt.Sprintf("'h%X", n)
+}
+
+// IPXACT : Export the device as an IP-XACT component
+// Peripheral instances become the address blocks of a single memory map.
+// Information without IP-XACT equivalent is dropped and reported in diags.
+func (dev *Device) IPXACT(version IPXACTVersion) (data []byte, diags []error, err error) {
+	if version != IPXACT2014 && version != IPXACT2022 {
+		return nil, nil, fmt.Errorf("unknown IP-XACT version %q", version)
+	}
+	v2022 := version == IPXACT2022
+	periphs, err := dev.Resolve()
+	if err != nil {
+		return nil, nil, err
+	}
+	lossy := func(format string, a ...interface{}) {
+		diags = append(diags, fmt.Errorf(format, a...))
+	}
+	vendor := dev.Vendor
+	if vendor == "" {
+		vendor = dev.VendorID
+	}
+	c := ipxactComponent{
+		Xmlns:       version.namespace(),
+		Vendor:      vendor,
+		Library:     dev.Series,
+		Name:        dev.Name,
+		Version:     dev.Version,
+		Description: dev.Description,
+		v2022:       v2022,
+	}
+	if c.Library == "" {
+		c.Library = "svd"
+	}
+	if dev.Cpu.Name != "" {
+		lossy("cpu section is not exported")
+	}
+	if dev.LicenseText != "" {
+		lossy("licenseText is not exported")
+	}
+	m := ipxactMemoryMap{Name: dev.Name, AddressUnitBits: dev.AddressUnitBits}
+	width := dev.Width
+	if width == 0 {
+		width = 32
+	}
+	for _, p := range periphs {
+		src := p.Peripheral
+		if len(src.Interrupt) > 0 {
+			lossy("peripheral %s: interrupts are not exported", p.Name)
+		}
+		if src.Protection != "" {
+			lossy("peripheral %s: protection is not exported", p.Name)
+		}
+		b := ipxactAddressBlock{
+			Name:           p.Name,
+			Description:    src.Description,
+			BaseAddress:    ipxactNumber(p.BaseAddress),
+			TypeIdentifier: src.GroupName,
+			Width:          strconv.FormatUint(uint64(width), 10),
+			Usage:          "register",
+		}
+		if b.TypeIdentifier == "" {
+			if parent := dev.FindPeripheral(src.DerivedFrom); parent != nil {
+				b.TypeIdentifier = parent.GroupName
+			}
+		}
+		var end uint64
+		blocks := src.AddressBlock
+		if len(blocks) > 1 {
+			lossy("peripheral %s: %d address blocks merged into one", p.Name, len(blocks))
+		}
+		for i, ab := range blocks {
+			size, err := ParseNumber(ab.Size)
+			if err != nil {
+				return nil, nil, fmt.Errorf("peripheral %s addressBlock size: %v", p.Name, err)
+			}
+			if e := uint64(ab.Offset) + size; e > end {
+				end = e
+			}
+			usage := map[UsageType]string{UsageRegisters: "register", UsageBuffer: "memory", UsageReserved: "reserved"}[ab.Usage]
+			if i == 0 {
+				b.Usage = usage
+			} else if usage != b.Usage {
+				lossy("peripheral %s: address block usage %s is exported as %s", p.Name, ab.Usage, b.Usage)
+			}
+		}
+		for _, r := range p.Registers {
+			if e := r.Offset + uint64(r.Size/8); e > end {
+				end = e
+			}
+			b.Registers = append(b.Registers, dev.ipxactRegister(r, v2022, lossy))
+		}
+		b.Range = ipxactNumber(end)
+		m.AddressBlocks = append(m.AddressBlocks, b)
+	}
+	c.MemoryMaps = []ipxactMemoryMap{m}
+	data, err = xml.MarshalIndent(c, "", "  ")
+	return append([]byte(xml.Header), data...), diags, err
+}
+
+// ipxactRegister : Convert a resolved register
+func (dev *Device) ipxactRegister(r ResolvedRegister, v2022 bool, lossy func(string, ...interface{})) ipxactRegister {
+	reg := ipxactRegister{
+		Name:          r.Name,
+		Description:   r.Description,
+		AddressOffset: ipxactNumber(r.Offset),
+		Size:          strconv.FormatUint(uint64(r.Size), 10),
+	}
+	if v2022 {
+		reg.AccessPolicies = []ipxactAccessPolicy{{Access: r.Access}}
+	} else {
+		reg.Access = r.Access
+	}
+	if src := r.Register; src != nil {
+		if src.AlternateGroup != "" || src.AlternateRegister != "" {
+			lossy("register %s: alternate register exported as a separate register", r.Path())
+		}
+		if src.DataType != "" {
+			lossy("register %s: dataType is not exported", r.Path())
+		}
+		if src.Protection != "" {
+			lossy("register %s: protection is not exported", r.Path())
+		}
+	}
+	var covered uint64
+	for _, f := range r.bitFields() {
+		w := f.Width()
+		mask := bitMask(w) << f.Lsb
+		covered |= mask
+		field := ipxactField{
+			Name:        f.Name,
+			Description: f.Description,
+			BitOffset:   strconv.FormatUint(uint64(f.Lsb), 10),
+			BitWidth:    strconv.FormatUint(uint64(w), 10),
+			Volatile:    f.uvmVolatile(),
+			v2022:       v2022,
+		}
+		if r.ResetMask&mask != 0 {
+			reset := ipxactReset{Value: ipxactNumber(r.ResetValue & mask >> f.Lsb)}
+			if r.ResetMask&mask != mask {
+				reset.Mask = ipxactNumber(r.ResetMask & mask >> f.Lsb)
+			}
+			field.Resets = []ipxactReset{reset}
+		}
+		action := f.ReadAction
+		if action == ReadActionModifyExternal {
+			lossy("field %s.%s: readAction modifyExternal exported as modify", r.Path(), f.Name)
+			action = ReadActionModify
+		}
+		var constraint *ipxactWriteConstraint
+		if f.Field != nil && f.Field.WriteConstraint != nil {
+			wc := f.Field.WriteConstraint
+			constraint = &ipxactWriteConstraint{WriteAsRead: wc.WriteAsRead, UseEnumeratedValues: wc.UseEnumeratedValues}
+			if wc.Range != nil {
+				constraint.Minimum = strconv.FormatUint(uint64(wc.Range.Minimum), 10)
+				constraint.Maximum = strconv.FormatUint(uint64(wc.Range.Maximum), 10)
+			}
+		}
+		if v2022 {
+			field.AccessPolicies = []ipxactFieldAccessPolicy{{
+				Access:               f.Access,
+				ModifiedWriteValue:   f.ModifiedWriteValues,
+				WriteValueConstraint: constraint,
+				ReadAction:           action,
+			}}
+		} else {
+			field.Access = f.Access
+			field.ModifiedWriteValue = f.ModifiedWriteValues
+			field.WriteValueConstraint = constraint
+			field.ReadAction = action
+		}
+		if ev := f.EnumeratedValues; ev != nil {
+			for _, e := range ev.EnumeratedValue {
+				if e.IsDefault {
+					lossy("field %s.%s: default enumerated value %s is not exported", r.Path(), f.Name, e.Name)
+					continue
+				}
+				value, err := ParseNumber(e.Value)
+				if err != nil {
+					lossy("field %s.%s: enumerated value %s (%s) is not exported", r.Path(), f.Name, e.Name, e.Value)
+					continue
+				}
+				field.EnumeratedValues = append(field.EnumeratedValues, ipxactEnumeratedValue{
+					Usage:       ev.Usage,
+					Name:        e.Name,
+					Description: e.Description,
+					Value:       ipxactNumber(value),
+				})
+			}
+		}
+		reg.Fields = append(reg.Fields, field)
+	}
+	if r.ResetValue&r.ResetMask&^covered != 0 {
+		lossy("register %s: reset value of bits outside fields is not exported", r.Path())
+	}
+	return reg
+}
+
+// ReadIPXACT : Import the memory maps of an IP-XACT component
+// Both the 2014 and 2022 revisions are accepted. Every address block
+// becomes a peripheral. Information without SVD equivalent is dropped and
+// reported in diags.
+func ReadIPXACT(r io.Reader) (dev *Device, diags []error, err error) {
+	var c ipxactComponent
+	if err = xml.NewDecoder(r).Decode(&c); err != nil {
+		return nil, nil, err
+	}
+	lossy := func(format string, a ...interface{}) {
+		diags = append(diags, fmt.Errorf(format, a...))
+	}
+	dev = NewDevice(c.Name)
+	dev.Vendor = c.Vendor
+	dev.Version = c.Version
+	dev.Description = c.Description
+	names := make(map[string]bool)
+	for _, m := range c.MemoryMaps {
+		if m.AddressUnitBits != 0 {
+			dev.AddressUnitBits = m.AddressUnitBits
+		}
+		for _, b := range m.AddressBlocks {
+			p, err := importIPXACTBlock(b, lossy)
+			if err != nil {
+				return nil, nil, err
+			}
+			if names[p.Name] {
+				lossy("address block %s of memory map %s renamed %s_%s", p.Name, m.Name, m.Name, p.Name)
+				p.Name = m.Name + "_" + p.Name
+			}
+			names[p.Name] = true
+			dev.Peripherals.Peripheral = append(dev.Peripherals.Peripheral, p)
+		}
+	}
+	return dev, diags, nil
+}
+
+// importIPXACTBlock : Convert an address block into a peripheral
+func importIPXACTBlock(b ipxactAddressBlock, lossy func(string, ...interface{})) (p Peripheral, err error) {
+	base, err := parseIPXACTNumber(b.BaseAddress)
+	if err != nil {
+		return p, fmt.Errorf("address block %s baseAddress: %v", b.Name, err)
+	}
+	size, err := parseIPXACTNumber(b.Range)
+	if err != nil {
+		return p, fmt.Errorf("address block %s range: %v", b.Name, err)
+	}
+	usage := map[string]UsageType{"register": UsageRegisters, "memory": UsageBuffer, "reserved": UsageReserved}[b.Usage]
+	if usage == "" {
+		usage = UsageRegisters
+	}
+	p = Peripheral{
+		Name:         b.Name,
+		Description:  b.Description,
+		GroupName:    b.TypeIdentifier,
+		BaseAddress:  fmt.Sprintf("0x%08X", base),
+		AddressBlock: []AddressBlock{{Offset: 0, Size: fmt.Sprintf("0x%X", size), Usage: usage}},
+	}
+	for _, rf := range b.RegisterFiles {
+		lossy("address block %s: register file %s is not imported", b.Name, rf.Name)
+	}
+	if len(b.Registers) == 0 {
+		return p, nil
+	}
+	p.Registers = &Registers{}
+	for _, ir := range b.Registers {
+		r, err := importIPXACTRegister(b.Name, ir, lossy)
+		if err != nil {
+			return p, err
+		}
+		p.Registers.Register = append(p.Registers.Register, r)
+	}
+	return p, nil
+}
+
+// importIPXACTRegister : Convert a register
+func importIPXACTRegister(block string, ir ipxactRegister, lossy func(string, ...interface{})) (r Register, err error) {
+	path := block + "." + ir.Name
+	offset, err := parseIPXACTNumber(ir.AddressOffset)
+	if err != nil {
+		return r, fmt.Errorf("register %s addressOffset: %v", path, err)
+	}
+	size, err := parseIPXACTNumber(ir.Size)
+	if err != nil {
+		return r, fmt.Errorf("register %s size: %v", path, err)
+	}
+	r = Register{
+		Name:          ir.Name,
+		Description:   ir.Description,
+		AddressOffset: fmt.Sprintf("0x%X", offset),
+		Size:          fmt.Sprintf("%d", size),
+		Access:        ir.Access,
+	}
+	if len(ir.AccessPolicies) > 0 {
+		r.Access = ir.AccessPolicies[0].Access
+		if len(ir.AccessPolicies) > 1 {
+			lossy("register %s: only the first access policy is imported", path)
+		}
+	}
+	dims, stride := ir.Dim, ""
+	if ir.Array != nil {
+		dims, stride = ir.Array.Dim, ir.Array.Stride
+	}
+	if len(dims) > 0 {
+		count := uint64(1)
+		for _, d := range dims {
+			n, err := parseIPXACTNumber(d)
+			if err != nil {
+				return r, fmt.Errorf("register %s dim: %v", path, err)
+			}
+			count *= n
+		}
+		if len(dims) > 1 {
+			lossy("register %s: %d-dimensional array flattened", path, len(dims))
+		}
+		increment := size / 8
+		if stride != "" {
+			if increment, err = parseIPXACTNumber(stride); err != nil {
+				return r, fmt.Errorf("register %s stride: %v", path, err)
+			}
+		}
+		r.Name += "[%s]"
+		r.Dim = strconv.FormatUint(count, 10)
+		r.DimIncrement = fmt.Sprintf("0x%X", increment)
+	}
+
+	var resetValue, resetMask uint64
+	for _, f := range ir.Fields {
+		field, err := importIPXACTField(path, f, lossy)
+		if err != nil {
+			return r, err
+		}
+		lsb, msb, _ := field.Bits()
+		found := false
+		for _, reset := range f.Resets {
+			if reset.ResetTypeRef != "" && !strings.EqualFold(reset.ResetTypeRef, "HARD") {
+				lossy("field %s.%s: reset %s is not imported", path, f.Name, reset.ResetTypeRef)
+				continue
+			}
+			if found {
+				continue
+			}
+			found = true
+			value, err := parseIPXACTNumber(reset.Value)
+			if err != nil {
+				return r, fmt.Errorf("field %s.%s reset: %v", path, f.Name, err)
+			}
+			mask := bitMask(msb - lsb + 1)
+			if reset.Mask != "" {
+				if mask, err = parseIPXACTNumber(reset.Mask); err != nil {
+					return r, fmt.Errorf("field %s.%s reset mask: %v", path, f.Name, err)
+				}
+			}
+			resetValue |= value & mask << lsb
+			resetMask |= mask << lsb
+		}
+		// a single field spanning the register stands for the register itself
+		if len(ir.Fields) == 1 && f.Name == ir.Name && lsb == 0 && uint64(msb+1) == size {
+			if field.Access != nil {
+				r.Access = *field.Access
+			}
+			if field.ModifiedWriteValues != nil {
+				r.ModifiedWriteValues = *field.ModifiedWriteValues
+			}
+			if field.ReadAction != nil {
+				r.ReadAction = *field.ReadAction
+			}
+			r.WriteConstraint = field.WriteConstraint
+			if field.EnumeratedValues != nil {
+				lossy("register %s: enumerated values of a register without field are not imported", path)
+			}
+			continue
+		}
+		if r.Fields == nil {
+			r.Fields = &Fields{}
+		}
+		r.Fields.Field = append(r.Fields.Field, field)
+	}
+	r.ResetValue = FormatHex(resetValue, uint(size))
+	r.ResetMask = FormatHex(resetMask, uint(size))
+	return r, nil
+}
+
+// importIPXACTField : Convert a field, except its reset value
+func importIPXACTField(register string, f ipxactField, lossy func(string, ...interface{})) (field Field, err error) {
+	path := register + "." + f.Name
+	offset, err := parseIPXACTNumber(f.BitOffset)
+	if err != nil {
+		return field, fmt.Errorf("field %s bitOffset: %v", path, err)
+	}
+	width, err := parseIPXACTNumber(f.BitWidth)
+	if err != nil {
+		return field, fmt.Errorf("field %s bitWidth: %v", path, err)
+	}
+	field = Field{
+		Name:        f.Name,
+		Description: f.Description,
+		BitOffset:   strconv.FormatUint(offset, 10),
+		BitWidth:    strconv.FormatUint(width, 10),
+	}
+	policy := ipxactFieldAccessPolicy{
+		Access:               f.Access,
+		ModifiedWriteValue:   f.ModifiedWriteValue,
+		WriteValueConstraint: f.WriteValueConstraint,
+		ReadAction:           f.ReadAction,
+		Reserved:             f.Reserved,
+	}
+	if len(f.AccessPolicies) > 0 {
+		policy = f.AccessPolicies[0]
+		if len(f.AccessPolicies) > 1 {
+			lossy("field %s: only the first access policy is imported", path)
+		}
+	}
+	if policy.Access != "" {
+		access := policy.Access
+		field.Access = &access
+	}
+	if policy.ModifiedWriteValue != "" {
+		mwv := policy.ModifiedWriteValue
+		field.ModifiedWriteValues = &mwv
+	}
+	if policy.ReadAction != "" {
+		action := policy.ReadAction
+		field.ReadAction = &action
+	}
+	if policy.Reserved != "" && policy.Reserved != "false" {
+		lossy("field %s: reserved flag is not imported", path)
+	}
+	if wc := policy.WriteValueConstraint; wc != nil {
+		field.WriteConstraint = &WriteConstraint{WriteAsRead: wc.WriteAsRead, UseEnumeratedValues: wc.UseEnumeratedValues}
+		if wc.Minimum != "" || wc.Maximum != "" {
+			min, err1 := parseIPXACTNumber(wc.Minimum)
+			max, err2 := parseIPXACTNumber(wc.Maximum)
+			if err1 != nil || err2 != nil {
+				return field, fmt.Errorf("field %s: invalid writeValueConstraint range", path)
+			}
+			field.WriteConstraint.Range = &Range{Minimum: uint(min), Maximum: uint(max)}
+		}
+	}
+	if len(f.EnumeratedValues) > 0 {
+		ev := &EnumeratedValues{Usage: f.EnumeratedValues[0].Usage}
+		for _, e := range f.EnumeratedValues {
+			value, err := parseIPXACTNumber(e.Value)
+			if err != nil {
+				return field, fmt.Errorf("field %s enumerated value %s: %v", path, e.Name, err)
+			}
+			if e.Usage != ev.Usage {
+				lossy("field %s: enumerated value %s usage %s merged into %s", path, e.Name, e.Usage, ev.Usage)
+			}
+			ev.EnumeratedValue = append(ev.EnumeratedValue, EnumeratedValue{
+				Name:        e.Name,
+				Description: e.Description,
+				Value:       fmt.Sprintf("0x%X", value),
+			})
+		}
+		field.EnumeratedValues = ev
+	}
+	return field, nil
+}
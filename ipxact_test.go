@@ -0,0 +1,195 @@
+package svd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDevice_IPXACT_roundTrip(t *testing.T) {
+	dev := simTestDevice()
+	want, err := dev.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []IPXACTVersion{IPXACT2014, IPXACT2022} {
+		t.Run(string(version), func(t *testing.T) {
+			data, _, err := dev.IPXACT(version)
+			if err != nil {
+				t.Fatalf("Device.IPXACT() error = %v", err)
+			}
+			if !bytes.Contains(data, []byte(version.namespace())) {
+				t.Errorf("Device.IPXACT() lacks the namespace %s", version.namespace())
+			}
+			imported, diags, err := ReadIPXACT(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ReadIPXACT() error = %v", err)
+			}
+			if len(diags) != 0 {
+				t.Errorf("ReadIPXACT() diags = %v", diags)
+			}
+			got, err := imported.Resolve()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("ReadIPXACT() has %d peripherals, want %d", len(got), len(want))
+			}
+			for i := range want {
+				for j, w := range want[i].Registers {
+					g := got[i].Registers[j]
+					// only the reset of the bits within fields is kept
+					var bits uint64
+					for _, f := range w.bitFields() {
+						bits |= f.Mask()
+					}
+					if g.Path() != w.Path() || g.Address != w.Address || g.Access != w.Access ||
+						g.ResetValue&g.ResetMask != w.ResetValue&w.ResetMask&bits || g.ResetMask != w.ResetMask&bits {
+						t.Errorf("register %s = %+v, want %+v", w.Path(), g, w)
+					}
+					if len(g.Fields) != len(w.Fields) {
+						t.Errorf("register %s has %d fields, want %d", w.Path(), len(g.Fields), len(w.Fields))
+						continue
+					}
+					for k, wf := range w.Fields {
+						gf := g.Fields[k]
+						if gf.Name != wf.Name || gf.Lsb != wf.Lsb || gf.Msb != wf.Msb || gf.Access != wf.Access ||
+							gf.ModifiedWriteValues != wf.ModifiedWriteValues || gf.ReadAction != wf.ReadAction {
+							t.Errorf("field %s.%s = %+v, want %+v", w.Path(), wf.Name, gf, wf)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestReadIPXACT(t *testing.T) {
+	const component = `<?xml version="1.0" encoding="UTF-8"?>
+<ipxact:component xmlns:ipxact="http://www.accellera.org/XMLSchema/IPXACT/1685-2014">
+  <ipxact:vendor>acme</ipxact:vendor>
+  <ipxact:library>ip</ipxact:library>
+  <ipxact:name>dma</ipxact:name>
+  <ipxact:version>1.0</ipxact:version>
+  <ipxact:memoryMaps>
+    <ipxact:memoryMap>
+      <ipxact:name>regs</ipxact:name>
+      <ipxact:addressBlock>
+        <ipxact:name>DMA</ipxact:name>
+        <ipxact:baseAddress>'h4000_2000</ipxact:baseAddress>
+        <ipxact:range>4096</ipxact:range>
+        <ipxact:width>32</ipxact:width>
+        <ipxact:register>
+          <ipxact:name>CH</ipxact:name>
+          <ipxact:dim>4</ipxact:dim>
+          <ipxact:addressOffset>'h10</ipxact:addressOffset>
+          <ipxact:size>32</ipxact:size>
+          <ipxact:field>
+            <ipxact:name>CH</ipxact:name>
+            <ipxact:bitOffset>0</ipxact:bitOffset>
+            <ipxact:resets><ipxact:reset><ipxact:value>8'hFF</ipxact:value></ipxact:reset></ipxact:resets>
+            <ipxact:bitWidth>32</ipxact:bitWidth>
+            <ipxact:access>read-write</ipxact:access>
+          </ipxact:field>
+        </ipxact:register>
+        <ipxact:register>
+          <ipxact:name>ISR</ipxact:name>
+          <ipxact:addressOffset>'h0</ipxact:addressOffset>
+          <ipxact:size>32</ipxact:size>
+          <ipxact:field>
+            <ipxact:name>DONE</ipxact:name>
+            <ipxact:bitOffset>4</ipxact:bitOffset>
+            <ipxact:resets>
+              <ipxact:reset><ipxact:value>0</ipxact:value></ipxact:reset>
+              <ipxact:reset resetTypeRef="SOFT"><ipxact:value>1</ipxact:value></ipxact:reset>
+            </ipxact:resets>
+            <ipxact:bitWidth>2</ipxact:bitWidth>
+            <ipxact:access>read-write</ipxact:access>
+            <ipxact:modifiedWriteValue>oneToClear</ipxact:modifiedWriteValue>
+            <ipxact:readAction>clear</ipxact:readAction>
+          </ipxact:field>
+        </ipxact:register>
+        <ipxact:registerFile>
+          <ipxact:name>DESC</ipxact:name>
+        </ipxact:registerFile>
+      </ipxact:addressBlock>
+    </ipxact:memoryMap>
+  </ipxact:memoryMaps>
+</ipxact:component>`
+	dev, diags, err := ReadIPXACT(strings.NewReader(component))
+	if err != nil {
+		t.Fatalf("ReadIPXACT() error = %v", err)
+	}
+	wantDiags := []string{
+		"address block DMA: register file DESC is not imported",
+		"field DMA.ISR.DONE: reset SOFT is not imported",
+	}
+	var gotDiags []string
+	for _, d := range diags {
+		gotDiags = append(gotDiags, d.Error())
+	}
+	if !reflect.DeepEqual(gotDiags, wantDiags) {
+		t.Errorf("ReadIPXACT() diags = %q, want %q", gotDiags, wantDiags)
+	}
+	rw := AccessReadWrite
+	w1c := ModifiedWriteValuesOneToClear
+	rc := ReadActionClear
+	want := Peripheral{
+		Name:         "DMA",
+		BaseAddress:  "0x40002000",
+		AddressBlock: []AddressBlock{{Offset: 0, Size: "0x1000", Usage: UsageRegisters}},
+		Registers: &Registers{Register: []Register{
+			{
+				Name:          "CH[%s]",
+				Dim:           "4",
+				DimIncrement:  "0x4",
+				AddressOffset: "0x10",
+				Size:          "32",
+				Access:        AccessReadWrite,
+				ResetValue:    "0x000000FF",
+				ResetMask:     "0xFFFFFFFF",
+			},
+			{
+				Name:          "ISR",
+				AddressOffset: "0x0",
+				Size:          "32",
+				ResetValue:    "0x00000000",
+				ResetMask:     "0x00000030",
+				Fields: &Fields{Field: []Field{{
+					Name:                "DONE",
+					BitOffset:           "4",
+					BitWidth:            "2",
+					Access:              &rw,
+					ModifiedWriteValues: &w1c,
+					ReadAction:          &rc,
+				}}},
+			},
+		}},
+	}
+	if got := dev.Peripherals.Peripheral[0]; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadIPXACT() peripheral = %+v, want %+v", got, want)
+	}
+}
+
+func Test_parseIPXACTNumber(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    uint64
+		wantErr bool
+	}{
+		{"'h4000_0000", 0x40000000, false},
+		{"32'hFF", 0xFF, false},
+		{"'b1010", 10, false},
+		{"8'd12", 12, false},
+		{"0x10", 16, false},
+		{"42", 42, false},
+		{"WIDTH-1", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseIPXACTNumber(tt.s)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseIPXACTNumber(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package svd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LinkerFlavor : linker the script is generated for
+type LinkerFlavor string
+
+const (
+	LinkerGNU LinkerFlavor = "gnu"
+	LinkerLLD LinkerFlavor = "lld"
+)
+
+// LinkerRegion : a memory region of the MEMORY command
+type LinkerRegion struct {
+	// Name of the region, e.g. FLASH.
+	Name string
+
+	// Attributes of the region, e.g. rx or rwx.
+	Attributes string
+
+	// First address of the region.
+	Origin uint64
+
+	// Number of bytes of the region.
+	Length uint64
+}
+
+var (
+	linkerSymbolRe    = regexp.MustCompile(`[^A-Za-z0-9_.$]`)
+	linkerAttributeRe = regexp.MustCompile(`^!?[rwxailRWXAIL]+(![rwxailRWXAIL]+)?$`)
+)
+
+// ParseLinkerRegions : Parse a memory region description
+// Each line gives a name, attributes, origin and length, separated by
+// spaces, e.g. "FLASH rx 0x08000000 512K". Blank lines and lines
+// starting with # are ignored.
+func ParseLinkerRegions(r io.Reader) (regions []LinkerRegion, err error) {
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		cols := strings.Fields(text)
+		if len(cols) != 4 {
+			return nil, fmt.Errorf("line %d: expected name, attributes, origin and length", line)
+		}
+		reg := LinkerRegion{Name: cols[0], Attributes: cols[1]}
+		if reg.Origin, err = ParseNumber(cols[2]); err != nil {
+			return nil, fmt.Errorf("line %d: origin: %v", line, err)
+		}
+		if reg.Length, err = ParseNumber(cols[3]); err != nil {
+			return nil, fmt.Errorf("line %d: length: %v", line, err)
+		}
+		regions = append(regions, reg)
+	}
+	return regions, sc.Err()
+}
+
+// validateLinkerRegions : Check names, attributes and overlaps of regions
+func validateLinkerRegions(regions []LinkerRegion) error {
+	sorted := append([]LinkerRegion{}, regions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Origin < sorted[j].Origin })
+	names := make(map[string]bool)
+	for i, reg := range sorted {
+		if reg.Name == "" || linkerSymbolRe.MatchString(reg.Name) {
+			return fmt.Errorf("invalid memory region name %q", reg.Name)
+		}
+		if names[reg.Name] {
+			return fmt.Errorf("memory region %s defined twice", reg.Name)
+		}
+		names[reg.Name] = true
+		if reg.Attributes != "" && !linkerAttributeRe.MatchString(reg.Attributes) {
+			return fmt.Errorf("memory region %s: invalid attributes %q", reg.Name, reg.Attributes)
+		}
+		if reg.Length == 0 {
+			return fmt.Errorf("memory region %s is empty", reg.Name)
+		}
+		if i > 0 {
+			prev := sorted[i-1]
+			if reg.Origin < prev.Origin+prev.Length {
+				return fmt.Errorf("memory regions %s and %s overlap", prev.Name, reg.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// linkerLength : Format a length, with a K or M suffix when exact
+func linkerLength(n uint64, flavor LinkerFlavor) string {
+	if flavor == LinkerGNU {
+		switch {
+		case n%(1<<20) == 0:
+			return fmt.Sprintf("%dM", n>>20)
+		case n%(1<<10) == 0:
+			return fmt.Sprintf("%dK", n>>10)
+		}
+	}
+	return fmt.Sprintf("0x%X", n)
+}
+
+// LinkerScript : Generate a linker script fragment for the device
+// The fragment holds a MEMORY command with the given regions, if any, and
+// a PROVIDE symbol at the base address of every peripheral instance.
+// The lld flavour sticks to plain hexadecimal numbers, the GNU flavour
+// uses K and M suffixes for lengths when exact.
+func (dev *Device) LinkerScript(regions []LinkerRegion, flavor LinkerFlavor) ([]byte, error) {
+	if flavor != LinkerGNU && flavor != LinkerLLD {
+		return nil, fmt.Errorf("unknown linker flavour %q", flavor)
+	}
+	if err := validateLinkerRegions(regions); err != nil {
+		return nil, err
+	}
+	instances, err := dev.peripheralInstances()
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "/* %s memory layout, generated from its SVD description */\n\n", dev.Name)
+	if len(regions) > 0 {
+		width := 0
+		for _, reg := range regions {
+			if n := len(reg.Name) + len(reg.Attributes); n > width {
+				width = n
+			}
+		}
+		b.WriteString("MEMORY\n{\n")
+		for _, reg := range regions {
+			head := reg.Name
+			if reg.Attributes != "" {
+				head += " (" + reg.Attributes + ")"
+			}
+			fmt.Fprintf(&b, "  %-*s : ORIGIN = 0x%08X, LENGTH = %s\n", width+3, head, reg.Origin,
+				linkerLength(reg.Length, flavor))
+		}
+		b.WriteString("}\n\n")
+	}
+	for _, inst := range instances {
+		fmt.Fprintf(&b, "PROVIDE(%s = 0x%08X);\n", linkerSymbolRe.ReplaceAllString(inst.name, "_"), inst.base)
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,106 @@
+package svd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLinkerRegions(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []LinkerRegion
+		wantErr bool
+	}{
+		{
+			name: "regions",
+			text: "# on-chip memories\nFLASH rx 0x00000000 128K\n\nRAM rwx 0x20000000 0x4000\n",
+			want: []LinkerRegion{
+				{Name: "FLASH", Attributes: "rx", Origin: 0, Length: 0x20000},
+				{Name: "RAM", Attributes: "rwx", Origin: 0x20000000, Length: 0x4000},
+			},
+		},
+		{name: "missing column", text: "FLASH rx 0x0\n", wantErr: true},
+		{name: "bad length", text: "FLASH rx 0x0 big\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLinkerRegions(strings.NewReader(tt.text))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLinkerRegions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLinkerRegions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDevice_LinkerScript(t *testing.T) {
+	dev := NewDevice("LinkDevice")
+	dev.Peripherals.Peripheral = []Peripheral{
+		{
+			Name:         "UART[%s]",
+			BaseAddress:  "0x40001000",
+			Dim:          2,
+			DimIncrement: 0x1000,
+			AddressBlock: []AddressBlock{{Offset: 0x10, Size: "0x100", Usage: UsageRegisters}},
+		},
+		{Name: "WDT", BaseAddress: "0x40000000"},
+	}
+	regions := []LinkerRegion{
+		{Name: "FLASH", Attributes: "rx", Origin: 0, Length: 0x20000},
+		{Name: "RAM", Attributes: "rwx", Origin: 0x20000000, Length: 0x4100},
+	}
+	tests := []struct {
+		name    string
+		regions []LinkerRegion
+		flavor  LinkerFlavor
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "gnu",
+			regions: regions,
+			flavor:  LinkerGNU,
+			want: "/* LinkDevice memory layout, generated from its SVD description */\n\n" +
+				"MEMORY\n{\n" +
+				"  FLASH (rx) : ORIGIN = 0x00000000, LENGTH = 128K\n" +
+				"  RAM (rwx)  : ORIGIN = 0x20000000, LENGTH = 0x4100\n" +
+				"}\n\n" +
+				"PROVIDE(WDT = 0x40000000);\n" +
+				"PROVIDE(UART0 = 0x40001000);\n" +
+				"PROVIDE(UART1 = 0x40002000);\n",
+		},
+		{
+			name:   "lld without regions",
+			flavor: LinkerLLD,
+			want: "/* LinkDevice memory layout, generated from its SVD description */\n\n" +
+				"PROVIDE(WDT = 0x40000000);\n" +
+				"PROVIDE(UART0 = 0x40001000);\n" +
+				"PROVIDE(UART1 = 0x40002000);\n",
+		},
+		{
+			name: "overlapping regions",
+			regions: []LinkerRegion{
+				{Name: "A", Origin: 0, Length: 0x100},
+				{Name: "B", Origin: 0x80, Length: 0x100},
+			},
+			flavor:  LinkerGNU,
+			wantErr: true,
+		},
+		{name: "unknown flavor", flavor: "msvc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dev.LinkerScript(tt.regions, tt.flavor)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Device.LinkerScript() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Device.LinkerScript() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
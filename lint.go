@@ -0,0 +1,570 @@
+package svd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// LintSeverity : importance of a lint result
+type LintSeverity string
+
+const (
+	LintError   LintSeverity = "error"
+	LintWarning LintSeverity = "warning"
+	LintNote    LintSeverity = "note"
+	// disables a rule
+	LintOff LintSeverity = "off"
+)
+
+// LintRule : a style or quality check
+type LintRule struct {
+	ID          string
+	Description string
+
+	// Severity of the results when not configured.
+	Severity LintSeverity
+}
+
+// LintRules : the rules applied by Lint
+var LintRules = []LintRule{
+	{"missing-description", "Peripherals, clusters, registers and fields should have a description.", LintWarning},
+	{"description-repeats-name", "A description should tell more than the name.", LintNote},
+	{"reset-mask-uncovered", "The bits with a defined reset value should be covered by fields.", LintWarning},
+	{"reset-outside-fields", "A reset value should not set bits outside the fields.", LintWarning},
+	{"enum-value-width", "Enumerated values must fit in the field width.", LintError},
+	{"reserved-name", "Names should not be C keywords nor RESERVED.", LintWarning},
+}
+
+// LintRuleConfig : the configuration of a rule
+type LintRuleConfig struct {
+	// Run the rule, nil for the default (enabled).
+	Enabled *bool
+
+	// Severity of the results, empty for the rule default.
+	Severity LintSeverity
+}
+
+// LintConfig : the configuration of Lint
+type LintConfig struct {
+	// Configuration by rule ID.
+	Rules map[string]LintRuleConfig
+}
+
+// LintResult : a problem found by a rule
+type LintResult struct {
+	Rule     string       `json:"rule"`
+	Severity LintSeverity `json:"severity"`
+
+	// "peripheral.cluster.register.field" name of the element, with the
+	// name of the enumerated value for enumerated values.
+	Path string `json:"path"`
+
+	// Line of the element in the document, 0 if unknown.
+	Line int `json:"line,omitempty"`
+
+	Message string `json:"message"`
+}
+
+// String : Describe the result
+func (r LintResult) String() string {
+	return fmt.Sprintf("line %d: %s: %s: %s [%s]", r.Line, r.Severity, r.Path, r.Message, r.Rule)
+}
+
+// lintRule : Get a rule by ID, nil if unknown
+func lintRule(id string) *LintRule {
+	for i := range LintRules {
+		if LintRules[i].ID == id {
+			return &LintRules[i]
+		}
+	}
+	return nil
+}
+
+// parseLintSeverity : Check a configured severity
+func parseLintSeverity(s string) (LintSeverity, error) {
+	switch sev := LintSeverity(s); sev {
+	case LintError, LintWarning, LintNote, LintOff:
+		return sev, nil
+	}
+	return "", fmt.Errorf("unknown severity %q", s)
+}
+
+// ParseLintConfig : Read a lint configuration
+// The configuration is written in a subset of YAML: a "rules" mapping of
+// rule IDs to either a severity, or "off", or a mapping setting "enabled"
+// and "severity". Comments start with #.
+//
+//	rules:
+//	  description-repeats-name: off
+//	  reset-outside-fields:
+//	    severity: error
+//	  reserved-name:
+//	    enabled: false
+func ParseLintConfig(r io.Reader) (config LintConfig, err error) {
+	config.Rules = make(map[string]LintRuleConfig)
+	scanner := bufio.NewScanner(r)
+	line, ruleIndent := 0, -1
+	rule, inRules := "", false
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if i := strings.Index(text, "#"); i == 0 || i > 0 && text[i-1] == ' ' {
+			text = text[:i]
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(text, " "), "\t") {
+			return config, fmt.Errorf("line %d: tabs are not allowed in indentation", line)
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		colon := strings.Index(text, ":")
+		if colon < 0 {
+			return config, fmt.Errorf("line %d: expected key: value", line)
+		}
+		key := strings.TrimSpace(text[:colon])
+		value := strings.Trim(strings.TrimSpace(text[colon+1:]), `"'`)
+		switch {
+		case indent == 0:
+			if key != "rules" || value != "" {
+				return config, fmt.Errorf("line %d: unknown key %q", line, key)
+			}
+			inRules = true
+		case !inRules:
+			return config, fmt.Errorf("line %d: unexpected indentation", line)
+		case ruleIndent < 0 || indent == ruleIndent:
+			ruleIndent = indent
+			if lintRule(key) == nil {
+				return config, fmt.Errorf("line %d: unknown rule %q", line, key)
+			}
+			rule = key
+			rc := config.Rules[rule]
+			if value != "" {
+				if rc.Severity, err = parseLintSeverity(value); err != nil {
+					return config, fmt.Errorf("line %d: %v", line, err)
+				}
+			}
+			config.Rules[rule] = rc
+		case indent > ruleIndent:
+			rc := config.Rules[rule]
+			switch key {
+			case "enabled":
+				enabled := value == "true"
+				if !enabled && value != "false" {
+					return config, fmt.Errorf("line %d: enabled must be true or false", line)
+				}
+				rc.Enabled = &enabled
+			case "severity":
+				if rc.Severity, err = parseLintSeverity(value); err != nil {
+					return config, fmt.Errorf("line %d: %v", line, err)
+				}
+			default:
+				return config, fmt.Errorf("line %d: unknown key %q", line, key)
+			}
+			config.Rules[rule] = rc
+		default:
+			return config, fmt.Errorf("line %d: unexpected indentation", line)
+		}
+	}
+	return config, scanner.Err()
+}
+
+// severity : Get the configured severity of a rule, LintOff if disabled
+func (config LintConfig) severity(rule LintRule) LintSeverity {
+	rc := config.Rules[rule.ID]
+	if rc.Enabled != nil && !*rc.Enabled {
+		return LintOff
+	}
+	if rc.Severity != "" {
+		return rc.Severity
+	}
+	return rule.Severity
+}
+
+// lintReservedNames : the C keywords, which generated headers cannot use as names
+var lintReservedNames = map[string]bool{
+	"auto": true, "break": true, "case": true, "char": true, "const": true, "continue": true,
+	"default": true, "do": true, "double": true, "else": true, "enum": true, "extern": true,
+	"float": true, "for": true, "goto": true, "if": true, "inline": true, "int": true,
+	"long": true, "register": true, "restrict": true, "return": true, "short": true,
+	"signed": true, "sizeof": true, "static": true, "struct": true, "switch": true,
+	"typedef": true, "union": true, "unsigned": true, "void": true, "volatile": true,
+	"while": true, "_Bool": true,
+}
+
+// linter : the state of a lint run
+type linter struct {
+	dev     *Device
+	config  LintConfig
+	results []LintResult
+}
+
+func (l *linter) report(id, path, format string, a ...interface{}) {
+	rule := lintRule(id)
+	sev := l.config.severity(*rule)
+	if sev == LintOff {
+		return
+	}
+	l.results = append(l.results, LintResult{Rule: id, Severity: sev, Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+// lintWords : Get the letters and digits of a text, in lower case
+func lintWords(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// element : Check the name and description of an element
+func (l *linter) element(path, name, description string, derived bool) {
+	if lintReservedNames[name] || strings.EqualFold(name, "reserved") {
+		l.report("reserved-name", path, "%s is a reserved word", name)
+	}
+	if description == "" {
+		if !derived {
+			l.report("missing-description", path, "no description")
+		}
+		return
+	}
+	if lintWords(description) == lintWords(name) {
+		l.report("description-repeats-name", path, "description %q repeats the name", description)
+	}
+}
+
+// register : Check a register and its fields
+func (l *linter) register(p *Peripheral, r *Register, path string, def defaults) {
+	l.element(path, r.Name, r.Description, r.DerivedFrom != "")
+	if r.Fields == nil {
+		return
+	}
+	for _, f := range r.Fields.Field {
+		fpath := path + "." + f.Name
+		l.element(fpath, f.Name, f.Description, f.DerivedFrom != "")
+		ev := f.EnumeratedValues
+		lsb, msb, err := f.Bits()
+		if ev == nil || err != nil {
+			continue
+		}
+		max := bitMask(msb - lsb + 1)
+		for _, v := range ev.EnumeratedValue {
+			value, dontCare, err := parseDontCare(v.Value)
+			if v.Value != "" && err == nil && (value|dontCare)&^max != 0 {
+				l.report("enum-value-width", fpath+"."+v.Name, "value %s does not fit in %d bits", v.Value, msb-lsb+1)
+			}
+		}
+	}
+	if r.DerivedFrom != "" {
+		return
+	}
+	regs, err := l.dev.resolveRegister(p, r, def)
+	if err != nil || len(regs) == 0 || len(regs[0].Fields) == 0 {
+		return
+	}
+	reg := regs[0]
+	var fields uint64
+	for _, f := range reg.Fields {
+		fields |= f.Mask()
+	}
+	if outside := reg.ResetMask &^ fields & reg.Mask(); outside != 0 {
+		l.report("reset-mask-uncovered", path, "reset mask bits %s are not covered by fields", FormatHex(outside, reg.Size))
+	}
+	if outside := reg.ResetValue & reg.ResetMask &^ fields & reg.Mask(); outside != 0 {
+		l.report("reset-outside-fields", path, "reset value sets bits %s outside fields", FormatHex(outside, reg.Size))
+	}
+}
+
+// scope : Check the registers and clusters of a peripheral or cluster
+func (l *linter) scope(p *Peripheral, registers []Register, clusters []Cluster, prefix string, def defaults) {
+	for i := range registers {
+		l.register(p, &registers[i], prefix+"."+registers[i].Name, def)
+	}
+	for i := range clusters {
+		c := &clusters[i]
+		path := prefix + "." + c.Name
+		l.element(path, c.Name, c.Description, c.DerivedFrom != "")
+		if cdef, err := def.cluster(c); err == nil {
+			l.scope(p, c.Register, c.Cluster, path, cdef)
+		}
+	}
+}
+
+// lintLocations : Get the lines of the elements of a document and its suppressions
+// A comment "svdlint:ignore rule-id ..." suppresses the given rules, or
+// all rules without ID, for the element holding the comment and the
+// elements inside it. Suppressions are given by path, "" for the device.
+func lintLocations(data []byte) (lines map[string]int, suppressed map[string][]string, err error) {
+	var newlines []int
+	for i, c := range data {
+		if c == '\n' {
+			newlines = append(newlines, i)
+		}
+	}
+	type frame struct {
+		element string
+		parent  *frame
+		named   bool
+		path    string
+		line    int
+		ignore  [][]string
+	}
+	lines = make(map[string]int)
+	suppressed = make(map[string][]string)
+	var device frame
+	var stack []*frame
+	var named []*frame
+	var text strings.Builder
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		offset := d.InputOffset()
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			f := &frame{element: t.Name.Local, parent: &device, line: sort.SearchInts(newlines, int(offset)) + 1}
+			if len(named) > 0 {
+				f.parent = named[len(named)-1]
+			}
+			switch f.element {
+			case "peripheral", "cluster", "register", "field":
+				f.named = true
+			case "enumeratedValue":
+				f.named = len(stack) > 0 && stack[len(stack)-1].element == "enumeratedValues"
+			}
+			if f.named {
+				named = append(named, f)
+			}
+			stack = append(stack, f)
+			text.Reset()
+		case xml.EndElement:
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if f.element == "name" && len(stack) > 0 && stack[len(stack)-1].named {
+				owner := stack[len(stack)-1]
+				owner.path = strings.TrimSpace(text.String())
+				if owner.parent != &device {
+					owner.path = owner.parent.path + "." + owner.path
+				}
+				if _, ok := lines[owner.path]; !ok {
+					lines[owner.path] = owner.line
+				}
+			}
+			if f.named {
+				named = named[:len(named)-1]
+				for _, rules := range f.ignore {
+					suppressed[f.path] = append(suppressed[f.path], rules...)
+				}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.Comment:
+			fields := strings.Fields(strings.ReplaceAll(string(t), ",", " "))
+			if len(fields) == 0 || fields[0] != "svdlint:ignore" {
+				continue
+			}
+			rules := fields[1:]
+			if len(rules) == 0 {
+				rules = []string{"*"}
+			}
+			owner := &device
+			if len(named) > 0 {
+				owner = named[len(named)-1]
+			}
+			owner.ignore = append(owner.ignore, rules)
+		}
+	}
+	for _, rules := range device.ignore {
+		suppressed[""] = append(suppressed[""], rules...)
+	}
+	return lines, suppressed, nil
+}
+
+// isSuppressed : Tell whether a suppression covers a result
+func isSuppressed(suppressed map[string][]string, r LintResult) bool {
+	for path, rules := range suppressed {
+		if path != "" && path != r.Path && !strings.HasPrefix(r.Path, path+".") {
+			continue
+		}
+		for _, rule := range rules {
+			if rule == "*" || rule == r.Rule {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Lint : Check the style and quality of an SVD document
+// The rules of LintRules are applied as configured, then the results are
+// located in the document and the ones suppressed by a comment
+// "svdlint:ignore rule-id ..." are dropped; see lintLocations.
+func Lint(svd []byte, config LintConfig) ([]LintResult, error) {
+	var dev Device
+	if err := xml.Unmarshal(svd, &dev); err != nil {
+		return nil, err
+	}
+	lines, suppressed, err := lintLocations(svd)
+	if err != nil {
+		return nil, err
+	}
+	for id := range config.Rules {
+		if lintRule(id) == nil {
+			return nil, fmt.Errorf("unknown rule %q", id)
+		}
+	}
+	top, err := dev.defaults()
+	if err != nil {
+		return nil, err
+	}
+	l := &linter{dev: &dev, config: config}
+	for i := range dev.Peripherals.Peripheral {
+		p := &dev.Peripherals.Peripheral[i]
+		l.element(p.Name, p.Name, p.Description, p.DerivedFrom != "")
+		if p.Registers != nil {
+			l.scope(p, p.Registers.Register, p.Registers.Cluster, p.Name, top.peripheral(p))
+		}
+	}
+	var results []LintResult
+	for _, r := range l.results {
+		if isSuppressed(suppressed, r) {
+			continue
+		}
+		r.Line = lines[r.Path]
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// Lint : Check the style and quality of the SVD output of the device
+// See Lint.
+func (dev Device) Lint(config LintConfig) ([]LintResult, error) {
+	data, err := dev.SVD()
+	if err != nil {
+		return nil, err
+	}
+	return Lint(data, config)
+}
+
+// LintJSON : Generate lint results as JSON
+func LintJSON(results []LintResult) ([]byte, error) {
+	if results == nil {
+		results = []LintResult{}
+	}
+	return json.MarshalIndent(results, "", "  ")
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// LintSARIF : Generate lint results as a SARIF 2.1.0 log
+// uri locates the linted document, relative to the repository root for
+// code review tools.
+func LintSARIF(results []LintResult, uri string) ([]byte, error) {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: "go-svd", InformationURI: "https://github.com/GPTechinno/go-svd"}},
+		Results: []sarifResult{},
+	}
+	for _, rule := range LintRules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{rule.Description},
+			DefaultConfiguration: sarifConfiguration{string(rule.Severity)},
+		})
+	}
+	for _, res := range results {
+		loc := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{uri}},
+			LogicalLocations: []sarifLogicalLocation{{res.Path}},
+		}
+		if res.Line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{res.Line}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    res.Rule,
+			Level:     string(res.Severity),
+			Message:   sarifMessage{res.Message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+	return json.MarshalIndent(log, "", "  ")
+}
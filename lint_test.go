@@ -0,0 +1,243 @@
+package svd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const lintTestDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<device schemaVersion="1.3">
+  <name>LintDevice</name>
+  <version>1.0</version>
+  <description>Lint test device</description>
+  <addressUnitBits>8</addressUnitBits>
+  <width>32</width>
+  <size>32</size>
+  <resetValue>0x00000000</resetValue>
+  <resetMask>0x000000FF</resetMask>
+  <peripherals>
+    <peripheral>
+      <name>TIMER</name>
+      <description>timer</description>
+      <baseAddress>0x40000000</baseAddress>
+      <registers>
+        <register>
+          <name>CTRL</name>
+          <description>Control register</description>
+          <addressOffset>0x0</addressOffset>
+          <resetValue>0x00000011</resetValue>
+          <fields>
+            <field>
+              <name>MODE</name>
+              <bitRange>[1:0]</bitRange>
+              <enumeratedValues>
+                <enumeratedValue>
+                  <name>fast</name>
+                  <value>4</value>
+                </enumeratedValue>
+              </enumeratedValues>
+            </field>
+          </fields>
+        </register>
+        <register>
+          <!-- svdlint:ignore reset-mask-uncovered -->
+          <name>int</name>
+          <description>Interrupt register</description>
+          <addressOffset>0x4</addressOffset>
+          <fields>
+            <field>
+              <name>EN</name>
+              <description>Enable</description>
+              <bitRange>[0:0]</bitRange>
+            </field>
+          </fields>
+        </register>
+      </registers>
+    </peripheral>
+    <peripheral derivedFrom="TIMER">
+      <!-- svdlint:ignore -->
+      <name>TIMER1</name>
+      <baseAddress>0x40001000</baseAddress>
+      <registers>
+        <register>
+          <name>STATUS</name>
+          <addressOffset>0x0</addressOffset>
+        </register>
+      </registers>
+    </peripheral>
+  </peripherals>
+</device>
+`
+
+func TestLint(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name    string
+		config  LintConfig
+		want    []LintResult
+		wantErr bool
+	}{
+		{
+			name: "default",
+			want: []LintResult{
+				{Rule: "description-repeats-name", Severity: LintNote, Path: "TIMER", Line: 12, Message: `description "timer" repeats the name`},
+				{Rule: "missing-description", Severity: LintWarning, Path: "TIMER.CTRL.MODE", Line: 23, Message: "no description"},
+				{Rule: "enum-value-width", Severity: LintError, Path: "TIMER.CTRL.MODE.fast", Line: 27, Message: "value 4 does not fit in 2 bits"},
+				{Rule: "reset-mask-uncovered", Severity: LintWarning, Path: "TIMER.CTRL", Line: 17, Message: "reset mask bits 0x000000FC are not covered by fields"},
+				{Rule: "reset-outside-fields", Severity: LintWarning, Path: "TIMER.CTRL", Line: 17, Message: "reset value sets bits 0x00000010 outside fields"},
+				{Rule: "reserved-name", Severity: LintWarning, Path: "TIMER.int", Line: 35, Message: "int is a reserved word"},
+			},
+		},
+		{
+			name: "configured",
+			config: LintConfig{Rules: map[string]LintRuleConfig{
+				"description-repeats-name": {Severity: LintOff},
+				"missing-description":      {Enabled: &disabled},
+				"reset-mask-uncovered":     {Enabled: &disabled},
+				"reset-outside-fields":     {Severity: LintError},
+				"reserved-name":            {Enabled: &disabled},
+			}},
+			want: []LintResult{
+				{Rule: "enum-value-width", Severity: LintError, Path: "TIMER.CTRL.MODE.fast", Line: 27, Message: "value 4 does not fit in 2 bits"},
+				{Rule: "reset-outside-fields", Severity: LintError, Path: "TIMER.CTRL", Line: 17, Message: "reset value sets bits 0x00000010 outside fields"},
+			},
+		},
+		{
+			name:    "unknown rule",
+			config:  LintConfig{Rules: map[string]LintRuleConfig{"unknown": {}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lint([]byte(lintTestDocument), tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Lint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLintConfig(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name    string
+		config  string
+		want    LintConfig
+		wantErr bool
+	}{
+		{
+			name: "rules",
+			config: `# lint configuration
+rules:
+  description-repeats-name: off
+  reset-outside-fields:
+    severity: error  # stricter
+  reserved-name:
+    enabled: false
+`,
+			want: LintConfig{Rules: map[string]LintRuleConfig{
+				"description-repeats-name": {Severity: LintOff},
+				"reset-outside-fields":     {Severity: LintError},
+				"reserved-name":            {Enabled: &disabled},
+			}},
+		},
+		{
+			name:    "unknown rule",
+			config:  "rules:\n  no-such-rule: error\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown severity",
+			config:  "rules:\n  reserved-name:\n    severity: fatal\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown key",
+			config:  "checks:\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLintConfig(strings.NewReader(tt.config))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLintConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLintConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintSARIF(t *testing.T) {
+	results := []LintResult{
+		{Rule: "reserved-name", Severity: LintWarning, Path: "TIMER.int", Line: 35, Message: "int is a reserved word"},
+	}
+	out, err := LintSARIF(results, "device.svd")
+	if err != nil {
+		t.Fatalf("LintSARIF() error = %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(out, &log); err != nil {
+		t.Fatalf("LintSARIF() is not JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Tool.Driver.Rules) != len(LintRules) {
+		t.Fatalf("LintSARIF() = %s", out)
+	}
+	want := []sarifResult{{
+		RuleID:  "reserved-name",
+		Level:   "warning",
+		Message: sarifMessage{"int is a reserved word"},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{"device.svd"}, Region: &sarifRegion{35}},
+			LogicalLocations: []sarifLogicalLocation{{"TIMER.int"}},
+		}},
+	}}
+	if !reflect.DeepEqual(log.Runs[0].Results, want) {
+		t.Errorf("LintSARIF() results = %+v, want %+v", log.Runs[0].Results, want)
+	}
+	if out, _ := LintJSON(nil); string(out) != "[]" {
+		t.Errorf("LintJSON(nil) = %s, want []", out)
+	}
+}
+
+func TestDevice_Lint(t *testing.T) {
+	dev := NewDevice("Cluster")
+	dev.Description = "Device with clusters"
+	dev.Peripherals.Peripheral = []Peripheral{{
+		Name:        "DMA",
+		Description: "Direct memory access",
+		BaseAddress: "0x40000000",
+		Registers: &Registers{Cluster: []Cluster{{
+			Name:          "CH",
+			Description:   "Channel",
+			AddressOffset: "0x0",
+			Register: []Register{
+				{Name: "CTRL", Description: "Channel control", AddressOffset: "0x0", ResetValue: "0x10",
+					Fields: &Fields{Field: []Field{{Name: "EN", Description: "Enable", BitRange: "[1:0]"}}}},
+				{Name: "DATA", Description: "Channel data", AddressOffset: "0x4", Size: "8",
+					ResetValue: "0x1FF", ResetMask: "0xFFFF",
+					Fields: &Fields{Field: []Field{{Name: "VALUE", Description: "Data value", BitRange: "[7:0]"}}}},
+			},
+		}}},
+	}}
+	got, err := dev.Lint(LintConfig{})
+	if err != nil {
+		t.Fatalf("Device.Lint() error = %v", err)
+	}
+	// DATA is 8 bits wide, its reset value beyond is not reported
+	want := []LintResult{
+		{Rule: "reset-mask-uncovered", Severity: LintWarning, Path: "DMA.CH.CTRL", Line: 31, Message: "reset mask bits 0xFFFFFFFC are not covered by fields"},
+		{Rule: "reset-outside-fields", Severity: LintWarning, Path: "DMA.CH.CTRL", Line: 31, Message: "reset value sets bits 0x00000010 outside fields"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Device.Lint() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,286 @@
+package svd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// MemoryMapEntry : an address block of a peripheral instance, or a gap
+type MemoryMapEntry struct {
+	// Peripheral instance owning the block, empty for a gap.
+	Peripheral string
+
+	// Group of the peripheral.
+	Group string
+
+	// First address of the block.
+	// For a peripheral without address block, its base address.
+	First uint64
+
+	// Number of bytes covered, 0 for a peripheral without address block.
+	Size uint64
+
+	// Usage of the block, empty for a gap.
+	Usage UsageType
+
+	// Problems found with the block.
+	Warnings []string
+}
+
+// Last : Get the last address of the entry
+func (e MemoryMapEntry) Last() uint64 {
+	if e.Size == 0 {
+		return e.First
+	}
+	return e.First + e.Size - 1
+}
+
+// IsGap : Tell whether the entry is an unmapped range
+func (e MemoryMapEntry) IsGap() bool {
+	return e.Peripheral == ""
+}
+
+// MemoryMap : the address space of a device, sorted by address
+type MemoryMap struct {
+	Device  string
+	Entries []MemoryMapEntry
+}
+
+// peripheralInstance : a peripheral instance with its absolute ranges
+type peripheralInstance struct {
+	name       string
+	group      string
+	base       uint64
+	ranges     []AddressRange
+	peripheral *Peripheral
+}
+
+// peripheralInstances : Get the peripheral instances sorted by base address
+// Peripheral arrays are expanded and derived peripherals without address
+// block use the blocks of their parent.
+func (dev *Device) peripheralInstances() (instances []peripheralInstance, err error) {
+	for i := range dev.Peripherals.Peripheral {
+		p := &dev.Peripherals.Peripheral[i]
+		ranges, err := dev.AddressRanges(p)
+		if err != nil {
+			return nil, err
+		}
+		base, _ := ParseNumber(p.BaseAddress)
+		group := p.GroupName
+		if group == "" {
+			if parent := dev.FindPeripheral(p.DerivedFrom); parent != nil {
+				group = parent.GroupName
+			}
+		}
+		indices := []string{""}
+		if p.Dim != 0 {
+			if indices, err = DimIndices(uint64(p.Dim), p.DimIndex); err != nil {
+				return nil, fmt.Errorf("peripheral %s: %v", p.Name, err)
+			}
+		}
+		for n, idx := range indices {
+			shift := uint64(n) * uint64(p.DimIncrement)
+			inst := peripheralInstance{name: dimName(p.Name, idx), group: group, base: base + shift, peripheral: p}
+			for _, r := range ranges {
+				inst.ranges = append(inst.ranges, AddressRange{First: r.First + shift, Last: r.Last + shift, Usage: r.Usage})
+			}
+			instances = append(instances, inst)
+		}
+	}
+	sort.SliceStable(instances, func(i, j int) bool { return instances[i].base < instances[j].base })
+	return
+}
+
+// MemoryMap : Build the memory map of the device
+// Unmapped ranges between blocks are listed as gaps. Peripherals without
+// address block and blocks reaching the base address of the following
+// peripherals are flagged, once for each peripheral.
+func (dev *Device) MemoryMap() (*MemoryMap, error) {
+	instances, err := dev.peripheralInstances()
+	if err != nil {
+		return nil, err
+	}
+	var entries []MemoryMapEntry
+	for i, inst := range instances {
+		if len(inst.ranges) == 0 {
+			entries = append(entries, MemoryMapEntry{
+				Peripheral: inst.name,
+				Group:      inst.group,
+				First:      inst.base,
+				Warnings:   []string{"peripheral has no address block"},
+			})
+			continue
+		}
+		for _, r := range inst.ranges {
+			e := MemoryMapEntry{
+				Peripheral: inst.name,
+				Group:      inst.group,
+				First:      r.First,
+				Size:       r.Last - r.First + 1,
+				Usage:      r.Usage,
+			}
+			// every peripheral starting above this one within the block
+			for _, next := range instances[i+1:] {
+				if next.base > r.Last {
+					break
+				}
+				if next.base > inst.base {
+					e.Warnings = append(e.Warnings, fmt.Sprintf("block extends past the base address 0x%08X of %s",
+						next.base, next.name))
+				}
+			}
+			entries = append(entries, e)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].First < entries[j].First })
+
+	m := MemoryMap{Device: dev.Name}
+	var end uint64 // first address not covered yet
+	for i, e := range entries {
+		if i > 0 && e.First > end {
+			m.Entries = append(m.Entries, MemoryMapEntry{First: end, Size: e.First - end})
+		}
+		m.Entries = append(m.Entries, e)
+		if last := e.Last() + 1; e.Size != 0 && last > end {
+			end = last
+		}
+	}
+	return &m, nil
+}
+
+// formatSize : Format a number of bytes with a binary unit
+func formatSize(n uint64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	u := 0
+	for n >= 1024 && n%1024 == 0 && u < len(units)-1 {
+		n /= 1024
+		u++
+	}
+	return fmt.Sprintf("%d %s", n, units[u])
+}
+
+// Text : Render the memory map as plain text
+func (m MemoryMap) Text() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s memory map\n", m.Device)
+	for _, e := range m.Entries {
+		name := e.Peripheral
+		if e.IsGap() {
+			name = "(gap)"
+		}
+		size := "-"
+		if e.Size != 0 {
+			size = formatSize(e.Size)
+		}
+		line := fmt.Sprintf("0x%08X-0x%08X %10s  %-20s %s", e.First, e.Last(), size, name, e.Usage)
+		fmt.Fprintln(&b, strings.TrimRight(line, " "))
+		for _, warn := range e.Warnings {
+			fmt.Fprintf(&b, "%23s warning: %s\n", "", warn)
+		}
+	}
+	return b.Bytes()
+}
+
+// CSV : Export the memory map as CSV
+// Columns are peripheral, group, start, end, size, usage and warnings.
+// Gaps have an empty peripheral.
+func (m MemoryMap) CSV() ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Write([]string{"peripheral", "group", "start", "end", "size", "usage", "warnings"})
+	for _, e := range m.Entries {
+		w.Write([]string{
+			e.Peripheral,
+			e.Group,
+			fmt.Sprintf("0x%08X", e.First),
+			fmt.Sprintf("0x%08X", e.Last()),
+			fmt.Sprintf("0x%X", e.Size),
+			string(e.Usage),
+			strings.Join(e.Warnings, "; "),
+		})
+	}
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
+
+// Geometry of the memory map diagram, in pixels.
+const (
+	memoryMapRow    = 22
+	memoryMapGapRow = 14
+	memoryMapLeft   = 190
+	memoryMapBox    = 260
+)
+
+// SVG : Draw the memory map as SVG
+// Blocks are drawn from the highest address at the top, one row each;
+// gaps are drawn as short dashed rows labelled with their size.
+func (m MemoryMap) SVG() []byte {
+	height := 8
+	for _, e := range m.Entries {
+		if e.IsGap() {
+			height += memoryMapGapRow
+		} else {
+			height += memoryMapRow
+		}
+	}
+	width := memoryMapLeft + memoryMapBox + 220
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(&b, "<title>%s memory map</title>\n", html.EscapeString(m.Device))
+	fills := map[UsageType]string{
+		UsageRegisters: "#cfe2f3",
+		UsageBuffer:    "#d9ead3",
+		UsageReserved:  "#d8d8d8",
+	}
+	y := 4
+	for i := len(m.Entries) - 1; i >= 0; i-- {
+		e := m.Entries[i]
+		if e.IsGap() {
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="#999" stroke-dasharray="4 3"/>`+"\n",
+				memoryMapLeft, y, memoryMapBox, memoryMapGapRow)
+			fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" font-size="9" fill="#777">%s unmapped</text>`+"\n",
+				memoryMapLeft+memoryMapBox/2, y+10, formatSize(e.Size))
+			y += memoryMapGapRow
+			continue
+		}
+		fill, ok := fills[e.Usage]
+		if !ok {
+			fill = "#ffffff"
+		}
+		stroke := "#000"
+		if len(e.Warnings) > 0 {
+			stroke = "#c00"
+		}
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="%s"/>`+"\n",
+			memoryMapLeft, y, memoryMapBox, memoryMapRow, fill, stroke)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">0x%08X-0x%08X</text>`+"\n",
+			memoryMapLeft-6, y+15, e.First, e.Last())
+		label := e.Peripheral
+		if e.Usage != "" && e.Usage != UsageRegisters {
+			label += " (" + string(e.Usage) + ")"
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n",
+			memoryMapLeft+memoryMapBox/2, y+15, html.EscapeString(label))
+		note := "no address block"
+		if e.Size != 0 {
+			note = formatSize(e.Size)
+		}
+		if len(e.Warnings) > 0 && e.Size != 0 {
+			note += " - overlaps next peripheral"
+		}
+		color := "#555"
+		if len(e.Warnings) > 0 {
+			color = "#c00"
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="%s">%s</text>`+"\n",
+			memoryMapLeft+memoryMapBox+6, y+15, color, note)
+		y += memoryMapRow
+	}
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
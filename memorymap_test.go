@@ -0,0 +1,141 @@
+package svd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDevice_MemoryMap(t *testing.T) {
+	dev := NewDevice("MapDevice")
+	dev.Peripherals.Peripheral = []Peripheral{
+		{
+			Name:         "TIMER[%s]",
+			BaseAddress:  "0x40001000",
+			Dim:          2,
+			DimIncrement: 0x400,
+			AddressBlock: []AddressBlock{{Offset: 0, Size: "0x100", Usage: UsageRegisters}},
+		},
+		{
+			Name:        "UART0",
+			BaseAddress: "0x40000000",
+			GroupName:   "UART",
+			AddressBlock: []AddressBlock{
+				{Offset: 0, Size: "0x100", Usage: UsageRegisters},
+				{Offset: 0x800, Size: "0x1000", Usage: UsageBuffer},
+			},
+		},
+		{Name: "UART1", BaseAddress: "0x40003000", DerivedFrom: "UART0"},
+		{Name: "DBG", BaseAddress: "0x50000000"},
+	}
+	m, err := dev.MemoryMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MemoryMapEntry{
+		{Peripheral: "UART0", Group: "UART", First: 0x40000000, Size: 0x100, Usage: UsageRegisters},
+		{First: 0x40000100, Size: 0x700},
+		{Peripheral: "UART0", Group: "UART", First: 0x40000800, Size: 0x1000, Usage: UsageBuffer,
+			Warnings: []string{
+				"block extends past the base address 0x40001000 of TIMER0",
+				"block extends past the base address 0x40001400 of TIMER1",
+			}},
+		{Peripheral: "TIMER0", First: 0x40001000, Size: 0x100, Usage: UsageRegisters},
+		{Peripheral: "TIMER1", First: 0x40001400, Size: 0x100, Usage: UsageRegisters},
+		{First: 0x40001800, Size: 0x1800},
+		{Peripheral: "UART1", Group: "UART", First: 0x40003000, Size: 0x100, Usage: UsageRegisters},
+		{First: 0x40003100, Size: 0x700},
+		{Peripheral: "UART1", Group: "UART", First: 0x40003800, Size: 0x1000, Usage: UsageBuffer},
+		{First: 0x40004800, Size: 0xFFFB800},
+		{Peripheral: "DBG", First: 0x50000000, Warnings: []string{"peripheral has no address block"}},
+	}
+	if !reflect.DeepEqual(m.Entries, want) {
+		t.Errorf("Device.MemoryMap() = %+v, want %+v", m.Entries, want)
+	}
+}
+
+func TestMemoryMap_renderers(t *testing.T) {
+	dev := NewDevice("MapDevice")
+	dev.Peripherals.Peripheral = []Peripheral{
+		{
+			Name:        "UART0",
+			BaseAddress: "0x40000000",
+			GroupName:   "UART",
+			AddressBlock: []AddressBlock{
+				{Offset: 0, Size: "0x100", Usage: UsageRegisters},
+				{Offset: 0x800, Size: "0x1000", Usage: UsageBuffer},
+			},
+		},
+		{Name: "TIMER", BaseAddress: "0x40001000", AddressBlock: []AddressBlock{{Offset: 0, Size: "0x100", Usage: UsageRegisters}}},
+		{Name: "DBG", BaseAddress: "0x50000000"},
+	}
+	m, err := dev.MemoryMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantText := `MapDevice memory map
+0x40000000-0x400000FF      256 B  UART0                registers
+0x40000100-0x400007FF     1792 B  (gap)
+0x40000800-0x400017FF      4 KiB  UART0                buffer
+                        warning: block extends past the base address 0x40001000 of TIMER
+0x40001000-0x400010FF      256 B  TIMER                registers
+0x40001800-0x4FFFFFFF 262138 KiB  (gap)
+0x50000000-0x50000000          -  DBG
+                        warning: peripheral has no address block
+`
+	if got := string(m.Text()); got != wantText {
+		t.Errorf("MemoryMap.Text() = %q, want %q", got, wantText)
+	}
+
+	wantCSV := `peripheral,group,start,end,size,usage,warnings
+UART0,UART,0x40000000,0x400000FF,0x100,registers,
+,,0x40000100,0x400007FF,0x700,,
+UART0,UART,0x40000800,0x400017FF,0x1000,buffer,block extends past the base address 0x40001000 of TIMER
+TIMER,,0x40001000,0x400010FF,0x100,registers,
+,,0x40001800,0x4FFFFFFF,0xFFFE800,,
+DBG,,0x50000000,0x50000000,0x0,,peripheral has no address block
+`
+	got, err := m.CSV()
+	if err != nil {
+		t.Fatalf("MemoryMap.CSV() error = %v", err)
+	}
+	if string(got) != wantCSV {
+		t.Errorf("MemoryMap.CSV() = %q, want %q", got, wantCSV)
+	}
+
+	svg := string(m.SVG())
+	for _, want := range []string{
+		"<title>MapDevice memory map</title>",
+		// highest address on top
+		`<text x="320" y="19" text-anchor="middle">DBG</text>`,
+		`<text x="456" y="19" fill="#c00">no address block</text>`,
+		`<text x="320" y="36" text-anchor="middle" font-size="9" fill="#777">262138 KiB unmapped</text>`,
+		`<rect x="190" y="62" width="260" height="22" fill="#d9ead3" stroke="#c00"/>`,
+		`<text x="456" y="77" fill="#c00">4 KiB - overlaps next peripheral</text>`,
+		`<text x="184" y="113" text-anchor="end">0x40000000-0x400000FF</text>`,
+		"</svg>\n",
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("MemoryMap.SVG() lacks %q", want)
+		}
+	}
+}
+
+func Test_formatSize(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want string
+	}{
+		{0x100, "256 B"},
+		{0x400, "1 KiB"},
+		{0x1800, "6 KiB"},
+		{0x100000, "1 MiB"},
+		{0x1001, "4097 B"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.n); got != tt.want {
+			t.Errorf("formatSize(%#x) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
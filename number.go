@@ -0,0 +1,89 @@
+package svd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseNumber : Parse an SVD scaledNonNegativeInteger
+// Numbers can be decimal, hexadecimal (0x...) or binary (0b... or #...)
+// and may be followed by a scale suffix (k, M, G or T). Scaled numbers
+// exceeding 64 bits are rejected.
+func ParseNumber(s string) (uint64, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return 0, fmt.Errorf("empty number")
+	}
+	var scale uint64 = 1
+	base := 10
+	switch {
+	case strings.HasPrefix(str, "0x") || strings.HasPrefix(str, "0X"):
+		str = str[2:]
+		base = 16
+	case strings.HasPrefix(str, "0b") || strings.HasPrefix(str, "0B"):
+		str = str[2:]
+		base = 2
+	case strings.HasPrefix(str, "#"):
+		str = str[1:]
+		base = 2
+	}
+	if len(str) > 1 {
+		switch str[len(str)-1] {
+		case 'k', 'K':
+			scale = 1 << 10
+		case 'm', 'M':
+			scale = 1 << 20
+		case 'g', 'G':
+			scale = 1 << 30
+		case 't', 'T':
+			scale = 1 << 40
+		}
+		if scale != 1 {
+			str = str[:len(str)-1]
+		}
+	}
+	n, err := strconv.ParseUint(str, base, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	if n > math.MaxUint64/scale {
+		return 0, fmt.Errorf("number %q overflows 64 bits", s)
+	}
+	return n * scale, nil
+}
+
+// FormatHex : Format a value as 0x... with enough digits for width bits
+func FormatHex(value uint64, width uint) string {
+	digits := int(width+3) / 4
+	if digits == 0 {
+		digits = 1
+	}
+	return fmt.Sprintf("0x%0*X", digits, value)
+}
+
+// parseDontCare : Parse a number which may contain 'do not care' bits
+// Binary numbers (0b... or #...) can use x for bits without defined value,
+// they are returned as 0 in value and set in dontCare.
+func parseDontCare(s string) (value, dontCare uint64, err error) {
+	str := strings.TrimSpace(s)
+	bin := strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(str, "#"), "0b"), "0B")
+	if bin == str || !strings.ContainsAny(bin, "xX") {
+		value, err = ParseNumber(s)
+		return
+	}
+	for _, c := range bin {
+		value, dontCare = value<<1, dontCare<<1
+		switch c {
+		case '0':
+		case '1':
+			value |= 1
+		case 'x', 'X':
+			dontCare |= 1
+		default:
+			return 0, 0, fmt.Errorf("invalid number %q", s)
+		}
+	}
+	return
+}
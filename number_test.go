@@ -0,0 +1,35 @@
+package svd
+
+import "testing"
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    uint64
+		wantErr bool
+	}{
+		{"decimal", "42", 42, false},
+		{"hexadecimal", " 0x1F ", 0x1F, false},
+		{"binary", "#101", 5, false},
+		{"binary prefix", "0b11", 3, false},
+		{"kilo", "4k", 4 << 10, false},
+		{"mega", "0x2M", 2 << 20, false},
+		{"largest scaled", "16777215T", 16777215 << 40, false},
+		{"scaled overflow", "16777216T", 0, true},
+		{"giga overflow", "0xFFFFFFFFFG", 0, true},
+		{"empty", "", 0, true},
+		{"invalid", "12z", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseNumber(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseNumber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseNumber() = 0x%X, want 0x%X", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package svd
 
+import "fmt"
+
 type UsageType string
 
 const (
@@ -425,9 +427,95 @@ type Register struct {
 	Fields *Fields `xml:"fields,omitempty"`
 }
 
+// Cluster describes a sequence of neighboring registers within a
+// peripheral.
+// A <cluster> specifies the addressOffset relative to the baseAddress
+// of the grouping element.
+// All <register> elements within a <cluster> specify their
+// addressOffset relative to the cluster base address
+// (<peripheral.baseAddress> + <cluster.addressOffset>).
+// Multiple <register> and <cluster> sections may occur in any order.
+// Since version 1.3 of the specification, the nesting of <cluster>
+// elements is supported.
+// Nested clusters express hierarchical structures of registers.
+type Cluster struct {
+	// Specify the cluster name from which to inherit data.
+	// Elements specified subsequently override inherited values.
+	// Usage:
+	// Always use the full qualifying path, which must start with the
+	// peripheral <name>, when deriving from another scope.
+	// (for example, in periperhal B, derive from peripheralA.clusterX).
+	// You can use the cluster <name> when both clusters are in the
+	// same scope.
+	DerivedFrom string `xml:"derivedFrom,attr,omitempty"`
+
+	// Define the number of elements in an array of clusters.
+	Dim string `xml:"dim,omitempty"`
+
+	// Specify the address increment, in Bytes, between two neighboring
+	// clusters of the cluster array.
+	DimIncrement string `xml:"dimIncrement,omitempty"`
+
+	// Specify the strings that substitue the placeholder %s within
+	// name and displayName.
+	DimIndex DimIndex `xml:"dimIndex,omitempty"`
+
+	// Specify the name of the C-type structure.
+	// If not defined, then the entry of the <name> element is used.
+	DimName DimName `xml:"dimName,omitempty"`
+
+	// Grouping element to create enumerations in the header file.
+	DimArrayIndex *DimArrayIndex `xml:"dimArrayIndex,omitempty"`
+
+	// String to identify the cluster.
+	// Cluster names are required to be unique within the scope of
+	// a peripheral.
+	// A list of cluster names can be build using the placeholder %s.
+	// Use the placeholder [%s] at the end of the identifier to
+	// generate arrays in the header file.
+	// The placeholder [%s] cannot be used together with <dimIndex>.
+	Name string `xml:"name"`
+
+	// String describing the details of the register cluster.
+	Description string `xml:"description,omitempty"`
+
+	// Specify the name of the original cluster if this cluster
+	// provides an alternative description.
+	AlternateCluster string `xml:"alternateCluster,omitempty"`
+
+	// Specify the struct type name created in the device header file.
+	// If not specified, then the name of the cluster is used.
+	HeaderStructName string `xml:"headerStructName,omitempty"`
+
+	// Cluster address relative to the baseAddress of the peripheral.
+	AddressOffset string `xml:"addressOffset"`
+
+	// Defines the default bit-width of any register contained in
+	// the cluster (implicit inheritance).
+	Size string `xml:"size,omitempty"`
+
+	// Defines the default access rights for all registers of the cluster.
+	Access AccessType `xml:"access,omitempty"`
+
+	// Defines the protection rights for all registers of the cluster.
+	Protection string `xml:"protection,omitempty"`
+
+	// Defines the default value for all registers of the cluster at RESET.
+	ResetValue string `xml:"resetValue,omitempty"`
+
+	// Identifies which register bits have a defined reset value.
+	ResetMask string `xml:"resetMask,omitempty"`
+
+	// Define a sequence of register within a cluster.
+	Register []Register `xml:"register,omitempty"`
+
+	// Element to describe nested clusters.
+	Cluster []Cluster `xml:"cluster,omitempty"`
+}
+
 type Registers struct {
 	// Define the sequence of register clusters.
-	// Cluster []Cluster `xml:"cluster,omitempty"`
+	Cluster []Cluster `xml:"cluster,omitempty"`
 
 	// Define the sequence of registers.
 	Register []Register `xml:"register"`
@@ -550,3 +638,65 @@ type Peripheral struct {
 	// Group to enclose register definitions.
 	Registers *Registers `xml:"registers,omitempty"`
 }
+
+// FindPeripheral : Get a peripheral of the device by name
+func (dev *Device) FindPeripheral(name string) *Peripheral {
+	if name == "" {
+		return nil
+	}
+	for i := range dev.Peripherals.Peripheral {
+		if dev.Peripherals.Peripheral[i].Name == name {
+			return &dev.Peripherals.Peripheral[i]
+		}
+	}
+	return nil
+}
+
+// AddressRange : a span of absolute addresses, both ends included
+type AddressRange struct {
+	First uint64
+	Last  uint64
+	Usage UsageType
+}
+
+// AddressRanges : Get the absolute address ranges of a peripheral
+// A derived peripheral without address block uses the blocks of
+// the peripheral it is derived from.
+func (dev *Device) AddressRanges(p *Peripheral) (ranges []AddressRange, err error) {
+	base, err := ParseNumber(p.BaseAddress)
+	if err != nil {
+		return nil, fmt.Errorf("peripheral %s baseAddress: %v", p.Name, err)
+	}
+	blocks := p.AddressBlock
+	if len(blocks) == 0 {
+		if parent := dev.FindPeripheral(p.DerivedFrom); parent != nil {
+			blocks = parent.AddressBlock
+		}
+	}
+	for _, b := range blocks {
+		size, err := ParseNumber(b.Size)
+		if err != nil {
+			return nil, fmt.Errorf("peripheral %s addressBlock size: %v", p.Name, err)
+		}
+		if size == 0 {
+			continue
+		}
+		first := base + uint64(b.Offset)
+		ranges = append(ranges, AddressRange{First: first, Last: first + size - 1, Usage: b.Usage})
+	}
+	return
+}
+
+// PeripheralAt : Get the peripheral whose address blocks contain an address
+func (dev *Device) PeripheralAt(address uint64) *Peripheral {
+	for i := range dev.Peripherals.Peripheral {
+		p := &dev.Peripherals.Peripheral[i]
+		ranges, _ := dev.AddressRanges(p)
+		for _, r := range ranges {
+			if address >= r.First && address <= r.Last {
+				return p
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package svd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// UVMAccess : Get the UVM access policy of a field
+// The policy combines the access, modifiedWriteValues and readAction of the
+// field. When the combination has no UVM equivalent, the closest policy
+// is returned with exact set to false.
+func (f ResolvedField) UVMAccess() (policy string, exact bool) {
+	rc := f.ReadAction == ReadActionClear
+	rs := f.ReadAction == ReadActionSet
+	switch f.Access {
+	case AccessReadOnly:
+		if rc {
+			return "RC", true
+		}
+		if rs {
+			return "RS", true
+		}
+		return "RO", f.ReadAction == ""
+	case AccessWriteOnly:
+		switch f.ModifiedWriteValues {
+		case ModifiedWriteValuesClear:
+			return "WOC", true
+		case ModifiedWriteValuesSet:
+			return "WOS", true
+		case "", ModifiedWriteValuesModify:
+			return "WO", true
+		}
+		return "WO", false
+	case AccessWriteOnce:
+		return "WO1", f.ModifiedWriteValues == "" && f.ReadAction == ""
+	case AccessReadWriteOnce:
+		return "W1", f.ModifiedWriteValues == "" && f.ReadAction == ""
+	}
+	// combinations of a write side effect with a read side effect
+	type key struct {
+		mwv    ModifiedWriteValues
+		action ReadAction
+	}
+	policies := map[key]string{
+		{"", ""}:                                        "RW",
+		{ModifiedWriteValuesModify, ""}:                 "RW",
+		{"", ReadActionClear}:                           "WRC",
+		{"", ReadActionSet}:                             "WRS",
+		{ModifiedWriteValuesOneToClear, ""}:             "W1C",
+		{ModifiedWriteValuesOneToClear, ReadActionSet}:  "W1CRS",
+		{ModifiedWriteValuesOneToSet, ""}:               "W1S",
+		{ModifiedWriteValuesOneToSet, ReadActionClear}:  "W1SRC",
+		{ModifiedWriteValuesOneToToggle, ""}:            "W1T",
+		{ModifiedWriteValuesZeroToClear, ""}:            "W0C",
+		{ModifiedWriteValuesZeroToClear, ReadActionSet}: "W0CRS",
+		{ModifiedWriteValuesZeroToSet, ""}:              "W0S",
+		{ModifiedWriteValuesZeroToSet, ReadActionClear}: "W0SRC",
+		{ModifiedWriteValuesZeroToToggle, ""}:           "W0T",
+		{ModifiedWriteValuesClear, ""}:                  "WC",
+		{ModifiedWriteValuesClear, ReadActionSet}:       "WCRS",
+		{ModifiedWriteValuesSet, ""}:                    "WS",
+		{ModifiedWriteValuesSet, ReadActionClear}:       "WSRC",
+	}
+	action := f.ReadAction
+	if action == ReadActionModifyExternal {
+		action = ReadActionModify
+	}
+	if p, ok := policies[key{f.ModifiedWriteValues, action}]; ok {
+		return p, true
+	}
+	// a read side effect without UVM policy, such as modify, is dropped
+	if p, ok := policies[key{f.ModifiedWriteValues, ""}]; ok {
+		return p, false
+	}
+	return "RW", false
+}
+
+// uvmVolatile : Tell whether the hardware may change the value of a field
+func (f ResolvedField) uvmVolatile() bool {
+	return f.Access == AccessReadOnly || f.ReadAction != "" ||
+		(f.ModifiedWriteValues != "" && f.ModifiedWriteValues != ModifiedWriteValuesModify)
+}
+
+// uvmRights : Get the rights of a register in an address map
+func uvmRights(access AccessType) string {
+	switch access {
+	case AccessReadOnly:
+		return "RO"
+	case AccessWriteOnly, AccessWriteOnce:
+		return "WO"
+	}
+	return "RW"
+}
+
+// uvmName : Get a SystemVerilog identifier for a name, keeping its case
+func uvmName(s string) string {
+	return strings.NewReplacer(".", "_", "$", "_").Replace(linkerSymbolRe.ReplaceAllString(s, "_"))
+}
+
+// uvmArrayName : Get the name of an array, without its %s placeholder,
+// or "" if the element is not an array of more than one element
+// "[%s]" and "%s" placeholders are both removed, e.g. REG[%s] and REG%s
+// are both the array REG.
+func uvmArrayName(name string, dim uint64) string {
+	if dim <= 1 || !strings.Contains(name, "%s") {
+		return ""
+	}
+	return uvmName(strings.Replace(strings.Replace(name, "[%s]", "", 1), "%s", "", 1))
+}
+
+// uvmRegister : a register, or a register array, of a block
+type uvmRegister struct {
+	ResolvedRegister
+
+	// Class of the register.
+	class string
+
+	// Array name, number of elements and address increment, if an array.
+	array     string
+	count     int
+	increment uint64
+}
+
+// uvmRegisters : Group the registers of a peripheral into registers and arrays
+func uvmRegisters(p ResolvedPeripheral, prefix string) (regs []uvmRegister) {
+	for i := 0; i < len(p.Registers); i++ {
+		r := p.Registers[i]
+		u := uvmRegister{ResolvedRegister: r, count: 1}
+		u.Name = uvmName(r.Name)
+		if r.Register != nil {
+			dim, _ := ParseNumber(r.Register.Dim)
+			u.array = uvmArrayName(r.Register.Name, dim)
+		}
+		name := u.Name
+		if u.array != "" {
+			name = u.array
+			for i+1 < len(p.Registers) && p.Registers[i+1].Register == r.Register {
+				i++
+				u.count++
+			}
+			if u.count > 1 {
+				u.increment = p.Registers[i].Offset - p.Registers[i-1].Offset
+			}
+		}
+		u.class = rtlName(prefix+"_"+name) + "_reg"
+		regs = append(regs, u)
+	}
+	return
+}
+
+// uvmRegisterClass : Write the uvm_reg class of a register
+func uvmRegisterClass(b *bytes.Buffer, r uvmRegister) {
+	fmt.Fprintf(b, "  class %s extends uvm_reg;\n", r.class)
+	fmt.Fprintf(b, "    `uvm_object_utils(%s)\n\n", r.class)
+	fields := r.bitFields()
+	for i := range fields {
+		fields[i].Name = uvmName(fields[i].Name)
+	}
+	if len(r.Fields) == 0 && r.array != "" {
+		fields[0].Name = r.array
+	}
+	for _, f := range fields {
+		fmt.Fprintf(b, "    rand uvm_reg_field %s;\n", f.Name)
+	}
+	fmt.Fprintf(b, "\n    function new(string name = \"%s\");\n", r.class)
+	fmt.Fprintf(b, "      super.new(name, %d, UVM_NO_COVERAGE);\n    endfunction\n\n", r.Size)
+	b.WriteString("    virtual function void build();\n")
+	for _, f := range fields {
+		policy, exact := f.UVMAccess()
+		if !exact {
+			fmt.Fprintf(b, "      // %s: access %s, modifiedWriteValues %s, readAction %s approximated as %s\n",
+				f.Name, f.Access, f.ModifiedWriteValues, f.ReadAction, policy)
+		}
+		w := f.Width()
+		mask := bitMask(w) << f.Lsb
+		hasReset := r.ResetMask&mask == mask
+		reset := r.ResetValue & mask >> f.Lsb
+		fmt.Fprintf(b, "      %s = uvm_reg_field::type_id::create(\"%s\");\n", f.Name, f.Name)
+		fmt.Fprintf(b, "      %s.configure(this, %d, %d, \"%s\", %d, %d'h%X, %d, %d, 0);\n",
+			f.Name, w, f.Lsb, policy, boolBit(f.uvmVolatile()), w, reset, boolBit(hasReset),
+			boolBit(f.Access != AccessReadOnly))
+	}
+	b.WriteString("    endfunction\n  endclass\n\n")
+}
+
+// boolBit : Get 1 for true, 0 for false
+func boolBit(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// UVMRegisterModel : Generate a UVM register model of the device
+// Every peripheral becomes a uvm_reg_block with its own address map, and
+// every register a uvm_reg with its uvm_reg_field. Register and peripheral
+// arrays of more than one element become arrays. The top block maps the
+// peripheral blocks at their base address.
+func (dev *Device) UVMRegisterModel() ([]byte, error) {
+	periphs, err := dev.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	pkg := rtlName(dev.Name)
+	endian := "UVM_LITTLE_ENDIAN"
+	if dev.Cpu.Endian == EndianBig {
+		endian = "UVM_BIG_ENDIAN"
+	}
+	width := dev.Width / 8
+	if width == 0 {
+		width = 4
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// %s register model, generated from its SVD description\n", dev.Name)
+	fmt.Fprintf(&b, "package %s_ral_pkg;\n  import uvm_pkg::*;\n  `include \"uvm_macros.svh\"\n\n", pkg)
+
+	// block classes, shared by the peripherals with the same registers
+	blocks := make(map[*Registers]string)
+	type instance struct {
+		name, class string
+		base        uint64
+		count       int
+		increment   uint64
+	}
+	var instances []instance
+	for i := 0; i < len(periphs); i++ {
+		p := periphs[i]
+		name := p.Name
+		array := uvmArrayName(p.Peripheral.Name, uint64(p.Peripheral.Dim))
+		if array != "" {
+			name = array
+		}
+		class, ok := blocks[p.Peripheral.Registers]
+		if !ok || p.Peripheral.Registers == nil {
+			class = rtlName(name) + "_block"
+			blocks[p.Peripheral.Registers] = class
+			regs := uvmRegisters(p, name)
+			for _, r := range regs {
+				uvmRegisterClass(&b, r)
+			}
+			fmt.Fprintf(&b, "  class %s extends uvm_reg_block;\n", class)
+			fmt.Fprintf(&b, "    `uvm_object_utils(%s)\n\n", class)
+			for _, r := range regs {
+				if r.array != "" {
+					fmt.Fprintf(&b, "    rand %s %s[%d];\n", r.class, r.array, r.count)
+				} else {
+					fmt.Fprintf(&b, "    rand %s %s;\n", r.class, r.Name)
+				}
+			}
+			fmt.Fprintf(&b, "\n    function new(string name = \"%s\");\n", class)
+			b.WriteString("      super.new(name, UVM_NO_COVERAGE);\n    endfunction\n\n")
+			b.WriteString("    virtual function void build();\n")
+			fmt.Fprintf(&b, "      default_map = create_map(\"default_map\", 0, %d, %s);\n", width, endian)
+			for _, r := range regs {
+				rights := uvmRights(r.Access)
+				if r.array == "" {
+					fmt.Fprintf(&b, "      %s = %s::type_id::create(\"%s\");\n", r.Name, r.class, r.Name)
+					fmt.Fprintf(&b, "      %s.configure(this);\n      %s.build();\n", r.Name, r.Name)
+					fmt.Fprintf(&b, "      default_map.add_reg(%s, 'h%X, \"%s\");\n", r.Name, r.Offset, rights)
+					continue
+				}
+				fmt.Fprintf(&b, "      foreach (%s[i]) begin\n", r.array)
+				fmt.Fprintf(&b, "        %s[i] = %s::type_id::create($sformatf(\"%s[%%0d]\", i));\n", r.array, r.class, r.array)
+				fmt.Fprintf(&b, "        %s[i].configure(this);\n        %s[i].build();\n", r.array, r.array)
+				fmt.Fprintf(&b, "        default_map.add_reg(%s[i], 'h%X + i * 'h%X, \"%s\");\n", r.array, r.Offset, r.increment, rights)
+				b.WriteString("      end\n")
+			}
+			b.WriteString("    endfunction\n  endclass\n\n")
+		}
+		inst := instance{name: uvmName(p.Name), class: class, base: p.BaseAddress, count: 1}
+		if array != "" {
+			inst.name = array
+			for i+1 < len(periphs) && periphs[i+1].Peripheral == p.Peripheral {
+				i++
+				inst.count++
+			}
+			if inst.count > 1 {
+				inst.increment = periphs[i].BaseAddress - periphs[i-1].BaseAddress
+			}
+		}
+		instances = append(instances, inst)
+	}
+
+	top := pkg + "_reg_model"
+	fmt.Fprintf(&b, "  class %s extends uvm_reg_block;\n", top)
+	fmt.Fprintf(&b, "    `uvm_object_utils(%s)\n\n", top)
+	for _, inst := range instances {
+		if inst.count > 1 {
+			fmt.Fprintf(&b, "    rand %s %s[%d];\n", inst.class, inst.name, inst.count)
+		} else {
+			fmt.Fprintf(&b, "    rand %s %s;\n", inst.class, inst.name)
+		}
+	}
+	fmt.Fprintf(&b, "\n    function new(string name = \"%s\");\n", top)
+	b.WriteString("      super.new(name, UVM_NO_COVERAGE);\n    endfunction\n\n")
+	b.WriteString("    virtual function void build();\n")
+	fmt.Fprintf(&b, "      default_map = create_map(\"default_map\", 0, %d, %s);\n", width, endian)
+	for _, inst := range instances {
+		if inst.count > 1 {
+			fmt.Fprintf(&b, "      foreach (%s[i]) begin\n", inst.name)
+			fmt.Fprintf(&b, "        %s[i] = %s::type_id::create($sformatf(\"%s[%%0d]\", i));\n", inst.name, inst.class, inst.name)
+			fmt.Fprintf(&b, "        %s[i].configure(this);\n        %s[i].build();\n", inst.name, inst.name)
+			fmt.Fprintf(&b, "        default_map.add_submap(%s[i].default_map, 'h%X + i * 'h%X);\n", inst.name, inst.base, inst.increment)
+			b.WriteString("      end\n")
+			continue
+		}
+		fmt.Fprintf(&b, "      %s = %s::type_id::create(\"%s\");\n", inst.name, inst.class, inst.name)
+		fmt.Fprintf(&b, "      %s.configure(this);\n      %s.build();\n", inst.name, inst.name)
+		fmt.Fprintf(&b, "      default_map.add_submap(%s.default_map, 'h%X);\n", inst.name, inst.base)
+	}
+	b.WriteString("      lock_model();\n    endfunction\n  endclass\n\nendpackage\n")
+	return b.Bytes(), nil
+}
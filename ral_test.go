@@ -0,0 +1,97 @@
+package svd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolvedField_UVMAccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		field      ResolvedField
+		wantPolicy string
+		wantExact  bool
+	}{
+		{"read-write", ResolvedField{Access: AccessReadWrite}, "RW", true},
+		{"read-only", ResolvedField{Access: AccessReadOnly}, "RO", true},
+		{"read to clear", ResolvedField{Access: AccessReadOnly, ReadAction: ReadActionClear}, "RC", true},
+		{"write one to clear", ResolvedField{Access: AccessReadWrite, ModifiedWriteValues: ModifiedWriteValuesOneToClear}, "W1C", true},
+		{"write one to set, read to clear", ResolvedField{Access: AccessReadWrite, ModifiedWriteValues: ModifiedWriteValuesOneToSet, ReadAction: ReadActionClear}, "W1SRC", true},
+		{"write-only clear", ResolvedField{Access: AccessWriteOnly, ModifiedWriteValues: ModifiedWriteValuesClear}, "WOC", true},
+		{"write once", ResolvedField{Access: AccessWriteOnce}, "WO1", true},
+		{"read write once", ResolvedField{Access: AccessReadWriteOnce}, "W1", true},
+		{"toggle and read to clear", ResolvedField{Access: AccessReadWrite, ModifiedWriteValues: ModifiedWriteValuesOneToToggle, ReadAction: ReadActionClear}, "W1T", false},
+		{"write-only toggle", ResolvedField{Access: AccessWriteOnly, ModifiedWriteValues: ModifiedWriteValuesOneToToggle}, "WO", false},
+		{"read modify", ResolvedField{Access: AccessReadWrite, ReadAction: ReadActionModifyExternal}, "RW", false},
+		{"write one to clear, read modify", ResolvedField{Access: AccessReadWrite, ModifiedWriteValues: ModifiedWriteValuesOneToClear, ReadAction: ReadActionModify}, "W1C", false},
+		{"read-only modify", ResolvedField{Access: AccessReadOnly, ReadAction: ReadActionModify}, "RO", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, exact := tt.field.UVMAccess()
+			if policy != tt.wantPolicy || exact != tt.wantExact {
+				t.Errorf("ResolvedField.UVMAccess() = %v, %v, want %v, %v", policy, exact, tt.wantPolicy, tt.wantExact)
+			}
+		})
+	}
+}
+
+func TestDevice_UVMRegisterModel(t *testing.T) {
+	dev := simTestDevice()
+	got, err := dev.UVMRegisterModel()
+	if err != nil {
+		t.Fatalf("Device.UVMRegisterModel() error = %v", err)
+	}
+	text := string(got)
+	for _, want := range []string{
+		"package simdevice_ral_pkg;",
+		"class uart0_sr_reg extends uvm_reg;",
+		`OVR.configure(this, 1, 1, "W1C", 1, 1'h0, 1, 1, 0);`,
+		`TXEMPTY.configure(this, 1, 0, "RO", 1, 1'h1, 1, 0, 0);`,
+		`default_map.add_reg(SR, 'h4, "RW");`,
+		"default_map.add_submap(UART0.default_map, 'h40000000);",
+		"endpackage",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Device.UVMRegisterModel() lacks %q", want)
+		}
+	}
+}
+
+func TestDevice_UVMRegisterModel_arrays(t *testing.T) {
+	dev := NewDevice("Arrays")
+	dev.Peripherals.Peripheral = []Peripheral{{
+		Name:         "TIM%s",
+		Dim:          2,
+		DimIncrement: 0x100,
+		BaseAddress:  "0x40000000",
+		Registers: &Registers{Register: []Register{
+			{Name: "CH%s", Dim: "2", DimIncrement: "4", DimIndex: "A,B", AddressOffset: "0x0"},
+			{Name: "ONE[%s]", Dim: "1", DimIncrement: "4", AddressOffset: "0x8",
+				Fields: &Fields{Field: []Field{{Name: "DATA.LO", BitRange: "[7:0]"}}}},
+		}},
+	}}
+	got, err := dev.UVMRegisterModel()
+	if err != nil {
+		t.Fatalf("Device.UVMRegisterModel() error = %v", err)
+	}
+	text := string(got)
+	for _, want := range []string{
+		"rand tim_ch_reg CH[2];",
+		"default_map.add_reg(CH[i], 'h0 + i * 'h4, \"RW\");",
+		"rand tim_one0_reg ONE0;",
+		"rand uvm_reg_field DATA_LO;",
+		"DATA_LO = uvm_reg_field::type_id::create(\"DATA_LO\");",
+		"rand tim_block TIM[2];",
+		"default_map.add_submap(TIM[i].default_map, 'h40000000 + i * 'h100);",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Device.UVMRegisterModel() lacks %q", want)
+		}
+	}
+	for _, unwanted := range []string{"CHA", "CHB", "DATA.LO", "TIM0", "TIM1"} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("Device.UVMRegisterModel() contains %q", unwanted)
+		}
+	}
+}
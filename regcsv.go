@@ -0,0 +1,520 @@
+package svd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// regCSVColumns : names accepted in the header of the register sheet
+var regCSVColumns = map[string][]string{
+	"peripheral":  {"peripheral", "block"},
+	"base":        {"base", "base address", "baseaddress"},
+	"register":    {"register", "reg"},
+	"offset":      {"offset", "address offset", "addressoffset"},
+	"size":        {"size", "register size"},
+	"field":       {"field", "bitfield"},
+	"bits":        {"bits", "bit range", "bitrange"},
+	"access":      {"access", "type"},
+	"reset":       {"reset", "reset value", "resetvalue"},
+	"description": {"description", "desc"},
+}
+
+// enumCSVColumns : names accepted in the header of the enum sheet
+var enumCSVColumns = map[string][]string{
+	"peripheral":  {"peripheral", "block"},
+	"register":    {"register", "reg"},
+	"field":       {"field", "bitfield"},
+	"name":        {"name", "enum", "value name"},
+	"value":       {"value"},
+	"description": {"description", "desc"},
+}
+
+// regCSVAccess : short access codes of the register sheet
+var regCSVAccess = map[string]struct {
+	access AccessType
+	mwv    ModifiedWriteValues
+	action ReadAction
+}{
+	"RW":  {AccessReadWrite, "", ""},
+	"RO":  {AccessReadOnly, "", ""},
+	"WO":  {AccessWriteOnly, "", ""},
+	"W1":  {AccessWriteOnce, "", ""},
+	"RW1": {AccessReadWriteOnce, "", ""},
+	"W1C": {AccessReadWrite, ModifiedWriteValuesOneToClear, ""},
+	"W1S": {AccessReadWrite, ModifiedWriteValuesOneToSet, ""},
+	"W1T": {AccessReadWrite, ModifiedWriteValuesOneToToggle, ""},
+	"W0C": {AccessReadWrite, ModifiedWriteValuesZeroToClear, ""},
+	"W0S": {AccessReadWrite, ModifiedWriteValuesZeroToSet, ""},
+	"W0T": {AccessReadWrite, ModifiedWriteValuesZeroToToggle, ""},
+	"WC":  {AccessReadWrite, ModifiedWriteValuesClear, ""},
+	"WS":  {AccessReadWrite, ModifiedWriteValuesSet, ""},
+	"RC":  {AccessReadOnly, "", ReadActionClear},
+	"RS":  {AccessReadOnly, "", ReadActionSet},
+	"WRC": {AccessReadWrite, "", ReadActionClear},
+	"WRS": {AccessReadWrite, "", ReadActionSet},
+}
+
+// formatCSVAccess : Format the access cell of the register sheet
+// A short code is used when one matches, otherwise the SVD access followed
+// by "; modifiedWriteValues=..." and "; readAction=..." as needed.
+func formatCSVAccess(access AccessType, mwv ModifiedWriteValues, action ReadAction) string {
+	if mwv == ModifiedWriteValuesModify {
+		mwv = ""
+	}
+	for code, a := range regCSVAccess {
+		if a.access == access && a.mwv == mwv && a.action == action {
+			return code
+		}
+	}
+	s := string(access)
+	if mwv != "" {
+		s += "; modifiedWriteValues=" + string(mwv)
+	}
+	if action != "" {
+		s += "; readAction=" + string(action)
+	}
+	return s
+}
+
+// parseCSVAccess : Parse the access cell of the register sheet
+func parseCSVAccess(s string) (access AccessType, mwv ModifiedWriteValues, action ReadAction, err error) {
+	parts := strings.Split(s, ";")
+	head := strings.TrimSpace(parts[0])
+	if a, ok := regCSVAccess[strings.ToUpper(head)]; ok {
+		access, mwv, action = a.access, a.mwv, a.action
+	} else {
+		switch access = AccessType(head); access {
+		case AccessReadOnly, AccessWriteOnly, AccessReadWrite, AccessWriteOnce, AccessReadWriteOnce:
+		default:
+			return "", "", "", fmt.Errorf("invalid access %q", head)
+		}
+	}
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return "", "", "", fmt.Errorf("invalid access %q", s)
+		}
+		switch value := strings.TrimSpace(kv[1]); strings.TrimSpace(kv[0]) {
+		case "modifiedWriteValues":
+			mwv = ModifiedWriteValues(value)
+		case "readAction":
+			action = ReadAction(value)
+		default:
+			return "", "", "", fmt.Errorf("invalid access %q", s)
+		}
+	}
+	return
+}
+
+// parseCSVBits : Parse a bit range, "[msb:lsb]", "msb:lsb" or a single bit
+func parseCSVBits(s string) (lsb, msb uint, err error) {
+	parts := strings.Split(strings.Trim(strings.TrimSpace(s), "[]"), ":")
+	if len(parts) > 2 {
+		return 0, 0, fmt.Errorf("invalid bits %q", s)
+	}
+	n, err := ParseNumber(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid bits %q", s)
+	}
+	msb, lsb = uint(n), uint(n)
+	if len(parts) == 2 {
+		if n, err = ParseNumber(parts[1]); err != nil {
+			return 0, 0, fmt.Errorf("invalid bits %q", s)
+		}
+		lsb = uint(n)
+	}
+	if msb < lsb {
+		return 0, 0, fmt.Errorf("bits %q: msb is below lsb", s)
+	}
+	return
+}
+
+// csvSheet : a CSV sheet read row by row, with columns named by its header
+type csvSheet struct {
+	rd   *csv.Reader
+	cols map[string]int
+	row  int
+}
+
+// newCSVSheet : Read the header of a sheet and check its required columns
+func newCSVSheet(r io.Reader, columns map[string][]string, required ...string) (*csvSheet, error) {
+	s := &csvSheet{rd: csv.NewReader(r), cols: make(map[string]int), row: 1}
+	s.rd.FieldsPerRecord = -1
+	s.rd.TrimLeadingSpace = true
+	header, err := s.rd.Read()
+	if err != nil {
+		return nil, fmt.Errorf("row 1: %v", err)
+	}
+	for i, name := range header {
+		for col, names := range columns {
+			for _, n := range names {
+				if strings.EqualFold(strings.TrimSpace(name), n) {
+					s.cols[col] = i
+				}
+			}
+		}
+	}
+	for _, col := range required {
+		if _, ok := s.cols[col]; !ok {
+			return nil, fmt.Errorf("row 1: missing column %s", col)
+		}
+	}
+	return s, nil
+}
+
+// next : Read the next row, io.EOF at the end
+// Blank rows are skipped; cells are read by column name.
+func (s *csvSheet) next() (get func(col string) string, err error) {
+	for {
+		rec, err := s.rd.Read()
+		if err != nil {
+			if err != io.EOF {
+				err = fmt.Errorf("row %d: %v", s.row+1, err)
+			}
+			return nil, err
+		}
+		s.row++
+		if strings.TrimSpace(strings.Join(rec, "")) == "" {
+			continue
+		}
+		return func(col string) string {
+			if i, ok := s.cols[col]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}, nil
+	}
+}
+
+// csvRegister : a register being aggregated from the register sheet
+type csvRegister struct {
+	reg *Register
+
+	// Bits used by the fields, reset value and mask given so far.
+	used, resetValue, resetMask uint64
+}
+
+// ReadRegisterCSV : Import a register map from CSV sheets
+// The register sheet has one row per field with the columns peripheral,
+// base, register, offset, size, field, bits, access, reset and
+// description, named by its header row in any order. Peripheral and
+// register columns are repeated on every row; base and offset are only
+// required on the first row of a peripheral or register. A row without
+// field describes the register itself, a row without register describes
+// the peripheral. Reset values are relative to the field; a register
+// without any reset cell gets an undefined reset value (resetMask 0).
+// The optional enum sheet has one row per enumerated value with the
+// columns peripheral, register, field, name, value and description; a
+// value "default" stands for all other values.
+// Errors give the row number in the sheet, the header being row 1.
+func ReadRegisterCSV(name string, fields, enums io.Reader) (*Device, error) {
+	dev := NewDevice(name)
+	sheet, err := newCSVSheet(fields, regCSVColumns, "peripheral", "register", "field")
+	if err != nil {
+		return nil, err
+	}
+	periphs := make(map[string]int)
+	regs := make(map[string]*csvRegister)
+	var order []*csvRegister
+	for {
+		get, err := sheet.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		fail := func(format string, a ...interface{}) error {
+			return fmt.Errorf("row %d: %s", sheet.row, fmt.Sprintf(format, a...))
+		}
+		pname := get("peripheral")
+		if pname == "" {
+			return nil, fail("missing peripheral")
+		}
+		var base uint64
+		if get("base") != "" {
+			if base, err = ParseNumber(get("base")); err != nil {
+				return nil, fail("base: %v", err)
+			}
+		}
+		pi, ok := periphs[pname]
+		if !ok {
+			if get("base") == "" {
+				return nil, fail("missing base address of peripheral %s", pname)
+			}
+			pi = len(dev.Peripherals.Peripheral)
+			periphs[pname] = pi
+			dev.Peripherals.Peripheral = append(dev.Peripherals.Peripheral, Peripheral{
+				Name:        pname,
+				BaseAddress: fmt.Sprintf("0x%08X", base),
+			})
+		} else if get("base") != "" && fmt.Sprintf("0x%08X", base) != dev.Peripherals.Peripheral[pi].BaseAddress {
+			return nil, fail("base address of peripheral %s differs from a previous row", pname)
+		}
+		p := &dev.Peripherals.Peripheral[pi]
+		rname := get("register")
+		if rname == "" {
+			if d := get("description"); d != "" {
+				p.Description = d
+			}
+			continue
+		}
+
+		var offset, size uint64 = 0, 32
+		if get("offset") != "" {
+			if offset, err = ParseNumber(get("offset")); err != nil {
+				return nil, fail("offset: %v", err)
+			}
+		}
+		if get("size") != "" {
+			if size, err = ParseNumber(get("size")); err != nil {
+				return nil, fail("size: %v", err)
+			}
+			if size == 0 || size > 64 {
+				return nil, fail("invalid size %d", size)
+			}
+		}
+		cr, ok := regs[pname+"."+rname]
+		if !ok {
+			if get("offset") == "" {
+				return nil, fail("missing offset of register %s", rname)
+			}
+			cr = &csvRegister{reg: &Register{
+				Name:          rname,
+				AddressOffset: fmt.Sprintf("0x%X", offset),
+				Size:          strconv.FormatUint(size, 10),
+			}}
+			regs[pname+"."+rname] = cr
+			order = append(order, cr)
+			if p.Registers == nil {
+				p.Registers = &Registers{}
+			}
+		} else {
+			if get("offset") != "" && fmt.Sprintf("0x%X", offset) != cr.reg.AddressOffset {
+				return nil, fail("offset of register %s differs from a previous row", rname)
+			}
+			if get("size") != "" && strconv.FormatUint(size, 10) != cr.reg.Size {
+				return nil, fail("size of register %s differs from a previous row", rname)
+			}
+			size, _ = ParseNumber(cr.reg.Size)
+		}
+		r := cr.reg
+
+		var access AccessType
+		var mwv ModifiedWriteValues
+		var action ReadAction
+		if get("access") != "" {
+			if access, mwv, action, err = parseCSVAccess(get("access")); err != nil {
+				return nil, fail("%v", err)
+			}
+		}
+		var reset uint64
+		if get("reset") != "" {
+			if reset, err = ParseNumber(get("reset")); err != nil {
+				return nil, fail("reset: %v", err)
+			}
+		}
+
+		fname := get("field")
+		if fname == "" {
+			if d := get("description"); d != "" {
+				r.Description = d
+			}
+			r.Access, r.ModifiedWriteValues, r.ReadAction = access, mwv, action
+			if get("reset") != "" {
+				if reset&^bitMask(uint(size)) != 0 {
+					return nil, fail("reset 0x%X does not fit in register %s", reset, rname)
+				}
+				cr.resetValue = cr.resetValue&cr.used | reset&^cr.used
+				cr.resetMask |= bitMask(uint(size))
+			}
+			continue
+		}
+		if get("bits") == "" {
+			return nil, fail("missing bits of field %s", fname)
+		}
+		lsb, msb, err := parseCSVBits(get("bits"))
+		if err != nil {
+			return nil, fail("%v", err)
+		}
+		if uint64(msb) >= size {
+			return nil, fail("field %s does not fit in register %s", fname, rname)
+		}
+		mask := bitMask(msb-lsb+1) << lsb
+		if cr.used&mask != 0 {
+			return nil, fail("field %s overlaps another field of register %s", fname, rname)
+		}
+		if r.Fields == nil {
+			r.Fields = &Fields{}
+		}
+		for _, f := range r.Fields.Field {
+			if f.Name == fname {
+				return nil, fail("field %s defined twice in register %s", fname, rname)
+			}
+		}
+		cr.used |= mask
+		f := Field{Name: fname, Description: get("description"), BitRange: fmt.Sprintf("[%d:%d]", msb, lsb)}
+		if access != "" {
+			f.Access = &access
+		}
+		if mwv != "" {
+			f.ModifiedWriteValues = &mwv
+		}
+		if action != "" {
+			f.ReadAction = &action
+		}
+		if get("reset") != "" {
+			if reset&^bitMask(msb-lsb+1) != 0 {
+				return nil, fail("reset 0x%X does not fit in field %s", reset, fname)
+			}
+			cr.resetValue = cr.resetValue&^mask | reset<<lsb
+			cr.resetMask |= mask
+		}
+		r.Fields.Field = append(r.Fields.Field, f)
+	}
+
+	// registers are added once complete, in the order of their first row
+	for _, cr := range order {
+		r := cr.reg
+		size, _ := ParseNumber(r.Size)
+		if cr.resetMask == 0 {
+			// no reset cell: the reset value is undefined, not the device default
+			r.ResetMask = FormatHex(0, uint(size))
+			continue
+		}
+		r.ResetValue = FormatHex(cr.resetValue, uint(size))
+		r.ResetMask = FormatHex(cr.resetMask, uint(size))
+	}
+	for i := range dev.Peripherals.Peripheral {
+		p := &dev.Peripherals.Peripheral[i]
+		var end uint64
+		for _, cr := range order {
+			if regs[p.Name+"."+cr.reg.Name] != cr {
+				continue
+			}
+			offset, _ := ParseNumber(cr.reg.AddressOffset)
+			size, _ := ParseNumber(cr.reg.Size)
+			if e := offset + (size+7)/8; e > end {
+				end = e
+			}
+			p.Registers.Register = append(p.Registers.Register, *cr.reg)
+		}
+		if end > 0 {
+			p.AddressBlock = []AddressBlock{{Offset: 0, Size: fmt.Sprintf("0x%X", end), Usage: UsageRegisters}}
+		}
+	}
+	if enums != nil {
+		if err := readEnumCSV(dev, enums); err != nil {
+			return nil, err
+		}
+	}
+	return dev, nil
+}
+
+// readEnumCSV : Add the enumerated values of the enum sheet to the fields
+func readEnumCSV(dev *Device, r io.Reader) error {
+	sheet, err := newCSVSheet(r, enumCSVColumns, "peripheral", "register", "field", "name", "value")
+	if err != nil {
+		return err
+	}
+	for {
+		get, err := sheet.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		path := get("peripheral") + "." + get("register") + "." + get("field")
+		var field *Field
+		if p := dev.FindPeripheral(get("peripheral")); p != nil && p.Registers != nil {
+			for i := range p.Registers.Register {
+				if r := &p.Registers.Register[i]; r.Name == get("register") && r.Fields != nil {
+					for j := range r.Fields.Field {
+						if r.Fields.Field[j].Name == get("field") {
+							field = &r.Fields.Field[j]
+						}
+					}
+				}
+			}
+		}
+		if field == nil {
+			return fmt.Errorf("row %d: field %s not found", sheet.row, path)
+		}
+		ev := EnumeratedValue{Name: get("name"), Description: get("description")}
+		if strings.EqualFold(get("value"), "default") {
+			ev.IsDefault = true
+		} else {
+			if _, _, err := parseDontCare(get("value")); err != nil {
+				return fmt.Errorf("row %d: value: %v", sheet.row, err)
+			}
+			ev.Value = get("value")
+		}
+		if field.EnumeratedValues == nil {
+			field.EnumeratedValues = &EnumeratedValues{}
+		}
+		field.EnumeratedValues.EnumeratedValue = append(field.EnumeratedValues.EnumeratedValue, ev)
+	}
+}
+
+// RegisterCSV : Export the register map as CSV sheets
+// The sheets are the ones read by ReadRegisterCSV. Derivations are
+// applied and arrays are expanded: every register is listed with the
+// effective access and reset of its fields.
+func (dev *Device) RegisterCSV() (fields, enums []byte, err error) {
+	periphs, err := dev.Resolve()
+	if err != nil {
+		return nil, nil, err
+	}
+	var fb, eb bytes.Buffer
+	fw, ew := csv.NewWriter(&fb), csv.NewWriter(&eb)
+	fw.Write([]string{"peripheral", "base", "register", "offset", "size", "field", "bits", "access", "reset", "description"})
+	ew.Write([]string{"peripheral", "register", "field", "name", "value", "description"})
+	for _, p := range periphs {
+		base := fmt.Sprintf("0x%08X", p.BaseAddress)
+		if p.Peripheral.Description != "" || len(p.Registers) == 0 {
+			fw.Write([]string{p.Name, base, "", "", "", "", "", "", "", p.Peripheral.Description})
+		}
+		for _, r := range p.Registers {
+			offset, size := fmt.Sprintf("0x%X", r.Offset), strconv.FormatUint(uint64(r.Size), 10)
+			if len(r.Fields) == 0 || r.Description != "" {
+				access, reset := "", ""
+				if len(r.Fields) == 0 {
+					access = formatCSVAccess(r.Access, r.ModifiedWriteValues, r.ReadAction)
+					if r.ResetMask == r.Mask() {
+						reset = FormatHex(r.ResetValue, r.Size)
+					}
+				}
+				fw.Write([]string{p.Name, base, r.Name, offset, size, "", "", access, reset, r.Description})
+			}
+			for _, f := range r.Fields {
+				reset := ""
+				if r.ResetMask&f.Mask() == f.Mask() {
+					reset = fmt.Sprintf("0x%X", f.Extract(r.ResetValue))
+				}
+				fw.Write([]string{p.Name, base, r.Name, offset, size, f.Name, fmt.Sprintf("[%d:%d]", f.Msb, f.Lsb),
+					formatCSVAccess(f.Access, f.ModifiedWriteValues, f.ReadAction), reset, f.Description})
+				if f.EnumeratedValues == nil {
+					continue
+				}
+				for _, ev := range f.EnumeratedValues.EnumeratedValue {
+					value := ev.Value
+					if ev.IsDefault {
+						value = "default"
+					}
+					ew.Write([]string{p.Name, r.Name, f.Name, ev.Name, value, ev.Description})
+				}
+			}
+		}
+	}
+	fw.Flush()
+	ew.Flush()
+	if err := fw.Error(); err != nil {
+		return nil, nil, err
+	}
+	return fb.Bytes(), eb.Bytes(), ew.Error()
+}
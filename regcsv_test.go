@@ -0,0 +1,130 @@
+package svd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRegisterCSV(t *testing.T) {
+	fields := `Peripheral,Base Address,Register,Offset,Size,Field,Bits,Access,Reset,Description
+TIMER,0x40010000,,,,,,,,General purpose timer
+
+TIMER,,CTRL,0x0,32,EN,[0:0],RW,1,Enable
+TIMER,,CTRL,,,MODE,2:1,rw,0x2,Mode
+TIMER,,SR,0x4,16,UIF,0,W1C,0,Update flag
+TIMER,,CNT,0x8,32,,,"read-only; readAction=clear",0x0,Counter
+`
+	enums := `peripheral,register,field,name,value,description
+TIMER,CTRL,MODE,UP,0,Count up
+TIMER,CTRL,MODE,OTHER,default,Reserved
+`
+	dev, err := ReadRegisterCSV("Test", strings.NewReader(fields), strings.NewReader(enums))
+	if err != nil {
+		t.Fatalf("ReadRegisterCSV() error = %v", err)
+	}
+	rw, w1c := AccessReadWrite, ModifiedWriteValuesOneToClear
+	want := []Peripheral{{
+		Name:         "TIMER",
+		Description:  "General purpose timer",
+		BaseAddress:  "0x40010000",
+		AddressBlock: []AddressBlock{{Offset: 0, Size: "0xC", Usage: UsageRegisters}},
+		Registers: &Registers{Register: []Register{{
+			Name: "CTRL", AddressOffset: "0x0", Size: "32", ResetValue: "0x00000005", ResetMask: "0x00000007",
+			Fields: &Fields{Field: []Field{
+				{Name: "EN", Description: "Enable", BitRange: "[0:0]", Access: &rw},
+				{Name: "MODE", Description: "Mode", BitRange: "[2:1]", Access: &rw, EnumeratedValues: &EnumeratedValues{
+					EnumeratedValue: []EnumeratedValue{
+						{Name: "UP", Description: "Count up", Value: "0"},
+						{Name: "OTHER", Description: "Reserved", IsDefault: true},
+					},
+				}},
+			}},
+		}, {
+			Name: "SR", AddressOffset: "0x4", Size: "16", ResetValue: "0x0000", ResetMask: "0x0001",
+			Fields: &Fields{Field: []Field{
+				{Name: "UIF", Description: "Update flag", BitRange: "[0:0]", Access: &rw, ModifiedWriteValues: &w1c},
+			}},
+		}, {
+			Name: "CNT", Description: "Counter", AddressOffset: "0x8", Size: "32",
+			Access: AccessReadOnly, ReadAction: ReadActionClear, ResetValue: "0x00000000", ResetMask: "0xFFFFFFFF",
+		}}},
+	}}
+	if !reflect.DeepEqual(dev.Peripherals.Peripheral, want) {
+		t.Errorf("ReadRegisterCSV() peripherals = %+v, want %+v", dev.Peripherals.Peripheral, want)
+	}
+}
+
+func TestReadRegisterCSV_errors(t *testing.T) {
+	const header = "peripheral,base,register,offset,field,bits,access,reset\n"
+	tests := []struct {
+		name string
+		csv  string
+		want string
+	}{
+		{"missing column", "peripheral,register\n", "row 1: missing column field"},
+		{"no base", header + "P,,R,0,F,0,RW,0\n", "row 2: missing base address of peripheral P"},
+		{"no offset", header + "P,0x0,R,,F,0,RW,0\n", "row 2: missing offset of register R"},
+		{"other base", header + "P,0x0,R,0,F,0,RW,0\nP,0x4,S,4,F,0,RW,0\n",
+			"row 3: base address of peripheral P differs from a previous row"},
+		{"bits", header + "P,0x0,R,0,F,1:2,RW,0\n", `row 2: bits "1:2": msb is below lsb`},
+		{"too wide", header + "P,0x0,R,0,F,32,RW,0\n", "row 2: field F does not fit in register R"},
+		{"overlap", header + "P,0x0,R,0,A,[3:0],RW,0\nP,,R,,B,[4:3],RW,0\n",
+			"row 3: field B overlaps another field of register R"},
+		{"reset", header + "P,0x0,R,0,F,[1:0],RW,4\n", "row 2: reset 0x4 does not fit in field F"},
+		{"access", header + "P,0x0,R,0,F,0,RX,0\n", `row 2: invalid access "RX"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadRegisterCSV("Test", strings.NewReader(tt.csv), nil)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("ReadRegisterCSV() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDevice_RegisterCSV_roundTrip(t *testing.T) {
+	dev := simTestDevice()
+	dev.Peripherals.Peripheral[0].Registers.Register[0].Fields.Field[0].EnumeratedValues = &EnumeratedValues{
+		EnumeratedValue: []EnumeratedValue{
+			{Name: "OFF", Description: "Disabled", Value: "0"},
+			{Name: "ON", Description: "Enabled", Value: "1"},
+		},
+	}
+	regs := &dev.Peripherals.Peripheral[0].Registers.Register
+	*regs = append(*regs, Register{Name: "BRD", Description: "Baud rate", AddressOffset: "0x14", ResetMask: "0x0"})
+	fields, enums, err := dev.RegisterCSV()
+	if err != nil {
+		t.Fatalf("Device.RegisterCSV() error = %v", err)
+	}
+	for _, line := range []string{
+		"UART0,0x40000000,SR,0x4,32,OVR,[1:1],W1C,0x0,",
+		"UART0,0x40000000,DR,0x8,32,,,WRC,0x00000000,",
+		"UART0,0x40000000,LOCK,0xC,32,,,RW1,0x00000000,",
+		"UART0,0x40000000,EVT,0x10,32,FLAG,[7:4],WRC,0x0,",
+		"UART0,0x40000000,BRD,0x14,32,,,RW,,Baud rate",
+	} {
+		if !strings.Contains(string(fields), line+"\n") {
+			t.Errorf("Device.RegisterCSV() lacks %q\n%s", line, fields)
+		}
+	}
+	if want := "UART0,CR,UARTEN,ON,1,Enabled\n"; !strings.HasSuffix(string(enums), want) {
+		t.Errorf("Device.RegisterCSV() enums = %q, want suffix %q", enums, want)
+	}
+
+	imported, err := ReadRegisterCSV(dev.Name, strings.NewReader(string(fields)), strings.NewReader(string(enums)))
+	if err != nil {
+		t.Fatalf("ReadRegisterCSV() error = %v", err)
+	}
+	if brd := imported.Peripherals.Peripheral[0].Registers.Register[5]; brd.ResetValue != "" || brd.ResetMask != "0x00000000" {
+		t.Errorf("ReadRegisterCSV() BRD reset = %q mask %q, want undefined", brd.ResetValue, brd.ResetMask)
+	}
+	fields2, enums2, err := imported.RegisterCSV()
+	if err != nil {
+		t.Fatalf("Device.RegisterCSV() error = %v", err)
+	}
+	if string(fields2) != string(fields) || string(enums2) != string(enums) {
+		t.Errorf("round trip = %s%s, want %s%s", fields2, enums2, fields, enums)
+	}
+}
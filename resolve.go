@@ -0,0 +1,818 @@
+package svd
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ResolvedField : a field with its bit position and inherited properties
+type ResolvedField struct {
+	// Field name, with the dim placeholder substituted.
+	Name string
+
+	// String describing the details of the field.
+	Description string
+
+	// Position of the least significant bit.
+	Lsb uint
+
+	// Position of the most significant bit.
+	Msb uint
+
+	// Access rights, inherited from the register if not specified.
+	Access AccessType
+
+	// Manipulation of written data, inherited from the register.
+	ModifiedWriteValues ModifiedWriteValues
+
+	// Side effect of a read, inherited from the register.
+	ReadAction ReadAction
+
+	// Enumerated values after derivation, nil if none.
+	EnumeratedValues *EnumeratedValues
+
+	// Field description after derivation.
+	Field *Field
+}
+
+// Width : Get the number of bits of the field
+func (f ResolvedField) Width() uint {
+	return f.Msb - f.Lsb + 1
+}
+
+// Mask : Get the bits of the register covered by the field
+func (f ResolvedField) Mask() uint64 {
+	return bitMask(f.Width()) << f.Lsb
+}
+
+// Extract : Get the value of the field from a register value
+func (f ResolvedField) Extract(reg uint64) uint64 {
+	return (reg >> f.Lsb) & bitMask(f.Width())
+}
+
+// Insert : Set the value of the field into a register value
+func (f ResolvedField) Insert(reg, value uint64) uint64 {
+	return reg&^f.Mask() | (value<<f.Lsb)&f.Mask()
+}
+
+// Enum : Get the enumerated value matching a field value, nil if none
+func (f ResolvedField) Enum(value uint64) *EnumeratedValue {
+	if f.EnumeratedValues == nil {
+		return nil
+	}
+	var def *EnumeratedValue
+	for i := range f.EnumeratedValues.EnumeratedValue {
+		ev := &f.EnumeratedValues.EnumeratedValue[i]
+		if ev.IsDefault {
+			def = ev
+			continue
+		}
+		if enumMatches(ev.Value, value) {
+			return ev
+		}
+	}
+	return def
+}
+
+// enumMatches : Compare a value with an enumerated value supporting 'x' bits
+func enumMatches(pattern string, value uint64) bool {
+	n, dontCare, err := parseDontCare(pattern)
+	return err == nil && n == value&^dontCare
+}
+
+// ResolvedRegister : a register with its address and inherited properties
+type ResolvedRegister struct {
+	// Name of the peripheral instance.
+	Peripheral string
+
+	// Register name, with the dim placeholder substituted.
+	Name string
+
+	// String describing the details of the register.
+	Description string
+
+	// Address offset relative to the peripheral base address.
+	Offset uint64
+
+	// Absolute address of the register.
+	Address uint64
+
+	// Bit-width of the register.
+	Size uint
+
+	// Access rights of the register.
+	Access AccessType
+
+	// Value of the register at RESET.
+	ResetValue uint64
+
+	// Register bits with a defined reset value.
+	ResetMask uint64
+
+	// Manipulation of written data.
+	ModifiedWriteValues ModifiedWriteValues
+
+	// Side effect of a read.
+	ReadAction ReadAction
+
+	// Bit-fields of the register, sorted as described.
+	Fields []ResolvedField
+
+	// Register description after derivation.
+	Register *Register
+}
+
+// Path : Get the "peripheral.register" name of the register
+func (r ResolvedRegister) Path() string {
+	return r.Peripheral + "." + r.Name
+}
+
+// Mask : Get the bits of the register
+func (r ResolvedRegister) Mask() uint64 {
+	return bitMask(r.Size)
+}
+
+// Field : Get a field of the register by name, nil if not found
+func (r *ResolvedRegister) Field(name string) *ResolvedField {
+	for i := range r.Fields {
+		if r.Fields[i].Name == name {
+			return &r.Fields[i]
+		}
+	}
+	return nil
+}
+
+// bitFields : Get the fields of the register
+// A register without field is seen as a single field spanning all its bits.
+func (r ResolvedRegister) bitFields() []ResolvedField {
+	if len(r.Fields) > 0 {
+		return r.Fields
+	}
+	return []ResolvedField{{
+		Name:                r.Name,
+		Description:         r.Description,
+		Msb:                 r.Size - 1,
+		Access:              r.Access,
+		ModifiedWriteValues: r.ModifiedWriteValues,
+		ReadAction:          r.ReadAction,
+	}}
+}
+
+// ResolvedPeripheral : a peripheral instance with its registers resolved
+type ResolvedPeripheral struct {
+	// Peripheral instance name, with the dim placeholder substituted.
+	Name string
+
+	// Absolute base address of the instance.
+	BaseAddress uint64
+
+	// Registers of the peripheral, sorted as described.
+	Registers []ResolvedRegister
+
+	// Peripheral description after derivation.
+	Peripheral *Peripheral
+}
+
+// Register : Get a register of the peripheral by name, nil if not found
+func (p *ResolvedPeripheral) Register(name string) *ResolvedRegister {
+	for i := range p.Registers {
+		if p.Registers[i].Name == name {
+			return &p.Registers[i]
+		}
+	}
+	return nil
+}
+
+// bitMask : Get a mask of the given number of bits
+func bitMask(width uint) uint64 {
+	if width >= 64 {
+		return ^uint64(0)
+	}
+	return (1 << width) - 1
+}
+
+// inherit : Copy the empty fields of dst from base
+// dst and base must be pointers to the same struct type.
+func inherit(dst, base interface{}, skip ...string) {
+	d := reflect.ValueOf(dst).Elem()
+	b := reflect.ValueOf(base).Elem()
+	for i := 0; i < d.NumField(); i++ {
+		name := d.Type().Field(i).Name
+		if name == "DerivedFrom" || name == "XMLName" {
+			continue
+		}
+		skipped := false
+		for _, s := range skip {
+			skipped = skipped || s == name
+		}
+		if !skipped && d.Field(i).IsZero() {
+			d.Field(i).Set(b.Field(i))
+		}
+	}
+}
+
+var dimRangeRe = regexp.MustCompile(`^([0-9]+|[A-Z])-([0-9]+|[A-Z])$`)
+
+// DimIndices : Get the substitution strings of a dim list
+// By default, the index is a decimal value starting with 0.
+// <dimIndex> can be a range ("0-3", "A-D") or a comma separated list.
+func DimIndices(dim uint64, index DimIndex) ([]string, error) {
+	idx := strings.TrimSpace(string(index))
+	var list []string
+	switch {
+	case idx == "":
+		for i := uint64(0); i < dim; i++ {
+			list = append(list, strconv.FormatUint(i, 10))
+		}
+	case dimRangeRe.MatchString(idx):
+		m := dimRangeRe.FindStringSubmatch(idx)
+		if a, err := strconv.Atoi(m[1]); err == nil {
+			b, err := strconv.Atoi(m[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid dimIndex %q", idx)
+			}
+			for i := a; i <= b; i++ {
+				list = append(list, strconv.Itoa(i))
+			}
+		} else {
+			for c := m[1][0]; c <= m[2][0]; c++ {
+				list = append(list, string(c))
+			}
+		}
+	default:
+		for _, s := range strings.Split(idx, ",") {
+			list = append(list, strings.TrimSpace(s))
+		}
+	}
+	if uint64(len(list)) != dim {
+		return nil, fmt.Errorf("dimIndex %q gives %d indices, dim is %d", idx, len(list), dim)
+	}
+	return list, nil
+}
+
+// dimName : Substitute the %s or [%s] placeholder of a name
+func dimName(name, index string) string {
+	return strings.Replace(strings.Replace(name, "[%s]", index, 1), "%s", index, 1)
+}
+
+// parseDim : Get the dim, increment and indices of an element, nil if not an array
+func parseDim(dim, increment string, index DimIndex) (indices []string, inc uint64, err error) {
+	if strings.TrimSpace(dim) == "" {
+		return nil, 0, nil
+	}
+	n, err := ParseNumber(dim)
+	if err != nil {
+		return nil, 0, fmt.Errorf("dim: %v", err)
+	}
+	if strings.TrimSpace(increment) != "" {
+		if inc, err = ParseNumber(increment); err != nil {
+			return nil, 0, fmt.Errorf("dimIncrement: %v", err)
+		}
+	}
+	indices, err = DimIndices(n, index)
+	return
+}
+
+// Bits : Get the least and most significant bits of the field
+func (f Field) Bits() (lsb, msb uint, err error) {
+	parse := func(s string) uint {
+		n, e := ParseNumber(s)
+		if e != nil && err == nil {
+			err = fmt.Errorf("field %s: %v", f.Name, e)
+		}
+		return uint(n)
+	}
+	switch {
+	case f.BitRange != "":
+		r := strings.TrimSpace(f.BitRange)
+		parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(r, "["), "]"), ":")
+		if len(parts) != 2 {
+			return 0, 0, fmt.Errorf("field %s: invalid bitRange %q", f.Name, f.BitRange)
+		}
+		msb, lsb = parse(parts[0]), parse(parts[1])
+	case f.Lsb != "" || f.Msb != "":
+		lsb, msb = parse(f.Lsb), parse(f.Msb)
+	default:
+		lsb = parse(f.BitOffset)
+		width := uint(1)
+		if f.BitWidth != "" {
+			width = parse(f.BitWidth)
+		}
+		msb = lsb + width - 1
+	}
+	if err == nil && msb < lsb {
+		err = fmt.Errorf("field %s: msb %d is below lsb %d", f.Name, msb, lsb)
+	}
+	return
+}
+
+// derivedPeripheral : Get a peripheral with its derivation applied
+func (dev *Device) derivedPeripheral(p *Peripheral, depth int) (*Peripheral, error) {
+	if p.DerivedFrom == "" {
+		return p, nil
+	}
+	if depth > 16 {
+		return nil, fmt.Errorf("peripheral %s: derivation loop", p.Name)
+	}
+	base := dev.FindPeripheral(p.DerivedFrom)
+	if base == nil {
+		return nil, fmt.Errorf("peripheral %s: derivedFrom %s not found", p.Name, p.DerivedFrom)
+	}
+	base, err := dev.derivedPeripheral(base, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	d := *p
+	inherit(&d, base, "Interrupt", "Dim", "DimIncrement", "DimIndex")
+	return &d, nil
+}
+
+// findRegister : Get a register by name in a peripheral or by "peripheral.register"
+func (dev *Device) findRegister(p *Peripheral, path string) *Register {
+	scope := p
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		scope = dev.FindPeripheral(path[:i])
+		path = path[i+1:]
+	}
+	if scope == nil {
+		return nil
+	}
+	scope, err := dev.derivedPeripheral(scope, 0)
+	if err != nil || scope.Registers == nil {
+		return nil
+	}
+	for i := range scope.Registers.Register {
+		if scope.Registers.Register[i].Name == path {
+			return &scope.Registers.Register[i]
+		}
+	}
+	return nil
+}
+
+// derivedRegister : Get a register with its derivation applied
+func (dev *Device) derivedRegister(p *Peripheral, r *Register, depth int) (*Register, error) {
+	if r.DerivedFrom == "" {
+		return r, nil
+	}
+	if depth > 16 {
+		return nil, fmt.Errorf("register %s: derivation loop", r.Name)
+	}
+	base := dev.findRegister(p, r.DerivedFrom)
+	if base == nil {
+		return nil, fmt.Errorf("register %s: derivedFrom %s not found", r.Name, r.DerivedFrom)
+	}
+	base, err := dev.derivedRegister(p, base, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	d := *r
+	inherit(&d, base)
+	return &d, nil
+}
+
+// derivedField : Get a field with its derivation applied
+func (dev *Device) derivedField(p *Peripheral, r *Register, f *Field, depth int) (*Field, error) {
+	if f.DerivedFrom == "" {
+		return f, nil
+	}
+	if depth > 16 {
+		return nil, fmt.Errorf("field %s: derivation loop", f.Name)
+	}
+	scope, name := r, f.DerivedFrom
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		scope = dev.findRegister(p, name[:i])
+		name = name[i+1:]
+	}
+	var base *Field
+	if scope != nil && scope.Fields != nil {
+		for i := range scope.Fields.Field {
+			if scope.Fields.Field[i].Name == name {
+				base = &scope.Fields.Field[i]
+			}
+		}
+	}
+	if base == nil {
+		return nil, fmt.Errorf("field %s: derivedFrom %s not found", f.Name, f.DerivedFrom)
+	}
+	base, err := dev.derivedField(p, scope, base, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	d := *f
+	inherit(&d, base)
+	return &d, nil
+}
+
+// findEnumeratedValues : Get an enumeratedValues section by (qualified) name
+// The section is searched in the register first, then in the peripheral,
+// then in the whole device.
+func (dev *Device) findEnumeratedValues(p *Peripheral, r *Register, path string) *EnumeratedValues {
+	parts := strings.Split(path, ".")
+	name := parts[len(parts)-1]
+	qualifiers := parts[:len(parts)-1]
+	search := func(p *Peripheral, r *Register) *EnumeratedValues {
+		if r == nil || r.Fields == nil {
+			return nil
+		}
+		for i := range r.Fields.Field {
+			f := &r.Fields.Field[i]
+			ev := f.EnumeratedValues
+			if ev == nil || ev.Name != name || ev.DerivedFrom != "" {
+				continue
+			}
+			q := []string{p.Name, r.Name, f.Name}
+			if len(qualifiers) <= 3 && reflect.DeepEqual(qualifiers, q[3-len(qualifiers):]) {
+				return ev
+			}
+		}
+		return nil
+	}
+	if ev := search(p, r); ev != nil {
+		return ev
+	}
+	peripherals := []*Peripheral{p}
+	for i := range dev.Peripherals.Peripheral {
+		peripherals = append(peripherals, &dev.Peripherals.Peripheral[i])
+	}
+	for _, sp := range peripherals {
+		if sp.Registers == nil {
+			continue
+		}
+		for i := range sp.Registers.Register {
+			if ev := search(sp, &sp.Registers.Register[i]); ev != nil {
+				return ev
+			}
+		}
+	}
+	return nil
+}
+
+// defaults : register properties inherited from the upper levels
+type defaults struct {
+	size       uint
+	access     AccessType
+	resetValue uint64
+	resetMask  uint64
+	hasMask    bool
+}
+
+// defaults : Get the register properties set at device level
+func (dev *Device) defaults() (top defaults, err error) {
+	top = defaults{size: dev.Size, access: dev.Access}
+	if dev.ResetValue != "" {
+		if top.resetValue, err = ParseNumber(dev.ResetValue); err != nil {
+			return top, fmt.Errorf("device resetValue: %v", err)
+		}
+	}
+	if dev.ResetMask != "" {
+		if top.resetMask, err = ParseNumber(dev.ResetMask); err != nil {
+			return top, fmt.Errorf("device resetMask: %v", err)
+		}
+		top.hasMask = true
+	}
+	if top.size == 0 {
+		top.size = 32
+	}
+	if top.access == "" {
+		top.access = AccessReadWrite
+	}
+	return top, nil
+}
+
+// peripheral : Get the register properties overridden by a peripheral
+func (def defaults) peripheral(p *Peripheral) defaults {
+	if p.Size != 0 {
+		def.size = p.Size
+	}
+	if p.Access != "" {
+		def.access = p.Access
+	}
+	if p.ResetValue != 0 {
+		def.resetValue = uint64(p.ResetValue)
+	}
+	if p.ResetMask != 0 {
+		def.resetMask = uint64(p.ResetMask)
+		def.hasMask = true
+	}
+	return def
+}
+
+// cluster : Get the register properties overridden by a cluster
+func (def defaults) cluster(c *Cluster) (defaults, error) {
+	if c.Size != "" {
+		n, err := ParseNumber(c.Size)
+		if err != nil {
+			return def, fmt.Errorf("size: %v", err)
+		}
+		def.size = uint(n)
+	}
+	if c.Access != "" {
+		def.access = c.Access
+	}
+	var err error
+	if c.ResetValue != "" {
+		if def.resetValue, err = ParseNumber(c.ResetValue); err != nil {
+			return def, fmt.Errorf("resetValue: %v", err)
+		}
+	}
+	if c.ResetMask != "" {
+		if def.resetMask, err = ParseNumber(c.ResetMask); err != nil {
+			return def, fmt.Errorf("resetMask: %v", err)
+		}
+		def.hasMask = true
+	}
+	return def, nil
+}
+
+// Resolve : Get every peripheral instance with its registers resolved
+// Derivations are applied, arrays are expanded and properties are
+// inherited from the upper levels.
+func (dev *Device) Resolve() (periphs []ResolvedPeripheral, err error) {
+	top, err := dev.defaults()
+	if err != nil {
+		return nil, err
+	}
+	for i := range dev.Peripherals.Peripheral {
+		src := &dev.Peripherals.Peripheral[i]
+		p, err := dev.derivedPeripheral(src, 0)
+		if err != nil {
+			return nil, err
+		}
+		base, err := ParseNumber(p.BaseAddress)
+		if err != nil {
+			return nil, fmt.Errorf("peripheral %s baseAddress: %v", p.Name, err)
+		}
+		regs, err := dev.resolveRegisters(p, top.peripheral(p))
+		if err != nil {
+			return nil, err
+		}
+		indices := []string{""}
+		inc := uint64(p.DimIncrement)
+		if p.Dim != 0 {
+			if indices, err = DimIndices(uint64(p.Dim), p.DimIndex); err != nil {
+				return nil, fmt.Errorf("peripheral %s: %v", p.Name, err)
+			}
+		}
+		for n, idx := range indices {
+			rp := ResolvedPeripheral{
+				Name:        dimName(p.Name, idx),
+				BaseAddress: base + uint64(n)*inc,
+				Peripheral:  p,
+			}
+			for _, r := range regs {
+				r.Peripheral = rp.Name
+				r.Address = rp.BaseAddress + r.Offset
+				rp.Registers = append(rp.Registers, r)
+			}
+			periphs = append(periphs, rp)
+		}
+	}
+	return
+}
+
+// resolveRegisters : Get the registers of a peripheral relative to its base
+func (dev *Device) resolveRegisters(p *Peripheral, def defaults) (regs []ResolvedRegister, err error) {
+	if p.Registers == nil {
+		return
+	}
+	return dev.resolveScope(p, p.Registers.Register, p.Registers.Cluster, "", 0, def)
+}
+
+// resolveScope : Get the registers of a peripheral or cluster scope
+// Registers of a cluster are named after the cluster, e.g. CH0_CTRL,
+// and placed at the offset of the cluster.
+func (dev *Device) resolveScope(p *Peripheral, registers []Register, clusters []Cluster, prefix string, offset uint64, def defaults) (regs []ResolvedRegister, err error) {
+	for i := range registers {
+		r, err := dev.derivedRegister(p, &registers[i], 0)
+		if err != nil {
+			return nil, fmt.Errorf("peripheral %s: %v", p.Name, err)
+		}
+		if prefix != "" {
+			d := *r
+			d.Name = prefix + r.Name
+			r = &d
+		}
+		rr, err := dev.resolveRegister(p, r, def)
+		if err != nil {
+			return nil, fmt.Errorf("peripheral %s register %s: %v", p.Name, r.Name, err)
+		}
+		for _, reg := range rr {
+			reg.Offset += offset
+			regs = append(regs, reg)
+		}
+	}
+	for i := range clusters {
+		c, err := derivedCluster(clusters, &clusters[i], 0)
+		if err != nil {
+			return nil, fmt.Errorf("peripheral %s: %v", p.Name, err)
+		}
+		rr, err := dev.resolveCluster(p, c, prefix, offset, def)
+		if err != nil {
+			return nil, err
+		}
+		regs = append(regs, rr...)
+	}
+	return
+}
+
+// derivedCluster : Get a cluster with its derivation from a sibling applied
+func derivedCluster(siblings []Cluster, c *Cluster, depth int) (*Cluster, error) {
+	if c.DerivedFrom == "" {
+		return c, nil
+	}
+	if depth > 16 {
+		return nil, fmt.Errorf("cluster %s: derivation loop", c.Name)
+	}
+	var base *Cluster
+	for i := range siblings {
+		if siblings[i].Name == c.DerivedFrom {
+			base = &siblings[i]
+		}
+	}
+	if base == nil {
+		return nil, fmt.Errorf("cluster %s: derivedFrom %s not found", c.Name, c.DerivedFrom)
+	}
+	base, err := derivedCluster(siblings, base, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	d := *c
+	inherit(&d, base, "Dim", "DimIncrement", "DimIndex")
+	return &d, nil
+}
+
+// resolveCluster : Get the registers of a cluster (or of the expanded cluster array)
+func (dev *Device) resolveCluster(p *Peripheral, c *Cluster, prefix string, offset uint64, def defaults) (regs []ResolvedRegister, err error) {
+	path := prefix + c.Name
+	fail := func(format string, a ...interface{}) ([]ResolvedRegister, error) {
+		return nil, fmt.Errorf("peripheral %s cluster %s: %s", p.Name, path, fmt.Sprintf(format, a...))
+	}
+	base, err := ParseNumber(c.AddressOffset)
+	if err != nil {
+		return fail("addressOffset: %v", err)
+	}
+	if def, err = def.cluster(c); err != nil {
+		return fail("%v", err)
+	}
+	indices, inc, err := parseDim(c.Dim, c.DimIncrement, c.DimIndex)
+	if err != nil {
+		return fail("%v", err)
+	}
+	if indices == nil {
+		indices = []string{""}
+	}
+	for n, idx := range indices {
+		rr, err := dev.resolveScope(p, c.Register, c.Cluster, prefix+dimName(c.Name, idx)+"_",
+			offset+base+uint64(n)*inc, def)
+		if err != nil {
+			return nil, err
+		}
+		regs = append(regs, rr...)
+	}
+	return
+}
+
+// resolveRegister : Get the register (or the expanded register array)
+func (dev *Device) resolveRegister(p *Peripheral, r *Register, def defaults) (regs []ResolvedRegister, err error) {
+	offset, err := ParseNumber(r.AddressOffset)
+	if err != nil {
+		return nil, fmt.Errorf("addressOffset: %v", err)
+	}
+	reg := ResolvedRegister{
+		Description:         r.Description,
+		Size:                def.size,
+		Access:              def.access,
+		ResetValue:          def.resetValue,
+		ModifiedWriteValues: r.ModifiedWriteValues,
+		ReadAction:          r.ReadAction,
+		Register:            r,
+	}
+	if r.Size != "" {
+		n, err := ParseNumber(r.Size)
+		if err != nil {
+			return nil, fmt.Errorf("size: %v", err)
+		}
+		reg.Size = uint(n)
+	}
+	if r.Access != "" {
+		reg.Access = r.Access
+	}
+	var dontCare uint64
+	if r.ResetValue != "" {
+		if reg.ResetValue, dontCare, err = parseDontCare(r.ResetValue); err != nil {
+			return nil, fmt.Errorf("resetValue: %v", err)
+		}
+	}
+	reg.ResetMask = bitMask(reg.Size)
+	if def.hasMask {
+		reg.ResetMask = def.resetMask & bitMask(reg.Size)
+	}
+	if r.ResetMask != "" {
+		if reg.ResetMask, err = ParseNumber(r.ResetMask); err != nil {
+			return nil, fmt.Errorf("resetMask: %v", err)
+		}
+	}
+	reg.ResetMask &^= dontCare
+	if r.Fields != nil {
+		for i := range r.Fields.Field {
+			f, err := dev.derivedField(p, r, &r.Fields.Field[i], 0)
+			if err != nil {
+				return nil, err
+			}
+			fields, err := dev.resolveField(p, r, f, reg)
+			if err != nil {
+				return nil, err
+			}
+			reg.Fields = append(reg.Fields, fields...)
+		}
+	}
+	indices, inc, err := parseDim(r.Dim, r.DimIncrement, r.DimIndex)
+	if err != nil {
+		return nil, err
+	}
+	if indices == nil {
+		reg.Name = r.Name
+		reg.Offset = offset
+		return []ResolvedRegister{reg}, nil
+	}
+	for n, idx := range indices {
+		reg.Name = dimName(r.Name, idx)
+		reg.Description = dimName(r.Description, idx)
+		reg.Offset = offset + uint64(n)*inc
+		regs = append(regs, reg)
+	}
+	return
+}
+
+// resolveField : Get the field (or the expanded field array) of a register
+func (dev *Device) resolveField(p *Peripheral, r *Register, f *Field, reg ResolvedRegister) (fields []ResolvedField, err error) {
+	lsb, msb, err := f.Bits()
+	if err != nil {
+		return nil, err
+	}
+	field := ResolvedField{
+		Description:         f.Description,
+		Access:              reg.Access,
+		ModifiedWriteValues: reg.ModifiedWriteValues,
+		ReadAction:          reg.ReadAction,
+		EnumeratedValues:    f.EnumeratedValues,
+		Field:               f,
+	}
+	if f.Access != nil && *f.Access != "" {
+		field.Access = *f.Access
+	}
+	if f.ModifiedWriteValues != nil {
+		field.ModifiedWriteValues = *f.ModifiedWriteValues
+	}
+	if f.ReadAction != nil {
+		field.ReadAction = *f.ReadAction
+	}
+	if ev := f.EnumeratedValues; ev != nil && ev.DerivedFrom != "" {
+		base := dev.findEnumeratedValues(p, r, ev.DerivedFrom)
+		if base == nil {
+			return nil, fmt.Errorf("field %s: enumeratedValues derivedFrom %s not found", f.Name, ev.DerivedFrom)
+		}
+		d := *ev
+		inherit(&d, base)
+		field.EnumeratedValues = &d
+	}
+	indices, inc, err := parseDim(f.Dim, f.DimIncrement, f.DimIndex)
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %v", f.Name, err)
+	}
+	if indices == nil {
+		field.Name, field.Lsb, field.Msb = f.Name, lsb, msb
+		return []ResolvedField{field}, nil
+	}
+	for n, idx := range indices {
+		field.Name = dimName(f.Name, idx)
+		field.Description = dimName(f.Description, idx)
+		field.Lsb = lsb + uint(uint64(n)*inc)
+		field.Msb = msb + uint(uint64(n)*inc)
+		fields = append(fields, field)
+	}
+	return
+}
+
+// ResolveRegister : Get a register by its "peripheral.register" path
+func (dev *Device) ResolveRegister(path string) (*ResolvedRegister, error) {
+	i := strings.Index(path, ".")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid register path %q (expected peripheral.register)", path)
+	}
+	periphs, err := dev.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	for n := range periphs {
+		if periphs[n].Name == path[:i] {
+			if r := periphs[n].Register(path[i+1:]); r != nil {
+				return r, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("register %s not found", path)
+}
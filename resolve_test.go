@@ -0,0 +1,62 @@
+package svd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDevice_Resolve_clusters(t *testing.T) {
+	dev := NewDevice("ClusterDevice")
+	dev.Peripherals.Peripheral = []Peripheral{{
+		Name:        "DMA",
+		BaseAddress: "0x40020000",
+		Registers: &Registers{
+			Register: []Register{{Name: "CTRL", AddressOffset: "0x0"}},
+			Cluster: []Cluster{
+				{Name: "CH%s", Dim: "2", DimIncrement: "0x20", AddressOffset: "0x100",
+					Size: "16", Access: AccessReadOnly, ResetValue: "0x5",
+					Register: []Register{
+						{Name: "CFG", AddressOffset: "0x0"},
+						{Name: "CNT", AddressOffset: "0x4", Access: AccessReadWrite},
+					},
+					Cluster: []Cluster{{Name: "SUB", AddressOffset: "0x10",
+						Register: []Register{{Name: "DATA", AddressOffset: "0x2"}}}},
+				},
+				{Name: "AUX", DerivedFrom: "CH%s", AddressOffset: "0x200",
+					Register: []Register{{Name: "STAT", AddressOffset: "0x8"}}},
+			},
+		},
+	}}
+	periphs, err := dev.Resolve()
+	if err != nil {
+		t.Fatalf("Device.Resolve() error = %v", err)
+	}
+	type reg struct {
+		Name       string
+		Address    uint64
+		Size       uint
+		Access     AccessType
+		ResetValue uint64
+	}
+	want := []reg{
+		{"CTRL", 0x40020000, 32, AccessReadWrite, 0},
+		{"CH0_CFG", 0x40020100, 16, AccessReadOnly, 5},
+		{"CH0_CNT", 0x40020104, 16, AccessReadWrite, 5},
+		{"CH0_SUB_DATA", 0x40020112, 16, AccessReadOnly, 5},
+		{"CH1_CFG", 0x40020120, 16, AccessReadOnly, 5},
+		{"CH1_CNT", 0x40020124, 16, AccessReadWrite, 5},
+		{"CH1_SUB_DATA", 0x40020132, 16, AccessReadOnly, 5},
+		{"AUX_STAT", 0x40020208, 16, AccessReadOnly, 5},
+		{"AUX_SUB_DATA", 0x40020212, 16, AccessReadOnly, 5},
+	}
+	var got []reg
+	for _, r := range periphs[0].Registers {
+		got = append(got, reg{r.Name, r.Address, r.Size, r.Access, r.ResetValue})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Device.Resolve() registers = %v, want %v", got, want)
+	}
+	if name := periphs[0].Registers[1].Register.Name; name != "CH0_CFG" {
+		t.Errorf("Device.Resolve() cluster register name = %q, want CH0_CFG", name)
+	}
+}
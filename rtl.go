@@ -0,0 +1,387 @@
+package svd
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RTLBus : bus interface of a generated register block
+type RTLBus string
+
+const (
+	RTLBusAPB     RTLBus = "apb"
+	RTLBusAXILite RTLBus = "axi-lite"
+)
+
+// rtlField : a field of a generated register block
+type rtlField struct {
+	ResolvedField
+
+	// Signal prefix, "<register>_<field>".
+	signal string
+
+	// Position of the field in the 32-bit bus word.
+	shift uint
+}
+
+func (f rtlField) readable() bool {
+	return f.Access != AccessWriteOnly && f.Access != AccessWriteOnce
+}
+
+func (f rtlField) writable() bool {
+	return f.Access != AccessReadOnly
+}
+
+// stored : Tell whether the field is a flip-flop of the block
+// Read-only fields are driven by the hardware, unless a read clears or
+// sets them.
+func (f rtlField) stored() bool {
+	return f.writable() || f.ReadAction == ReadActionClear || f.ReadAction == ReadActionSet
+}
+
+// volatile : Tell whether the hardware can update a stored field
+func (f rtlField) volatile() bool {
+	if !f.stored() {
+		return false
+	}
+	return !f.writable() || f.ReadAction != "" ||
+		(f.ModifiedWriteValues != "" && f.ModifiedWriteValues != ModifiedWriteValuesModify)
+}
+
+// rtlRange : Format a bit range of a vector
+func rtlRange(msb, lsb uint) string {
+	if msb == lsb {
+		return fmt.Sprintf("[%d]", lsb)
+	}
+	return fmt.Sprintf("[%d:%d]", msb, lsb)
+}
+
+// rtlVector : Format the packed dimension of a signal of a given width
+func rtlVector(width uint) string {
+	if width == 1 {
+		return ""
+	}
+	return fmt.Sprintf("[%d:0]", width-1)
+}
+
+// rtlWriteValue : Get the expression of a field after a bus write
+// The data written to the field is d, its current value q.
+func rtlWriteValue(mwv ModifiedWriteValues, q, d string, width uint) string {
+	switch mwv {
+	case ModifiedWriteValuesOneToClear:
+		return q + " & ~" + d
+	case ModifiedWriteValuesOneToSet:
+		return q + " | " + d
+	case ModifiedWriteValuesOneToToggle:
+		return q + " ^ " + d
+	case ModifiedWriteValuesZeroToClear:
+		return q + " & " + d
+	case ModifiedWriteValuesZeroToSet:
+		return q + " | ~" + d
+	case ModifiedWriteValuesZeroToToggle:
+		return q + " ^ ~" + d
+	case ModifiedWriteValuesClear:
+		return fmt.Sprintf("%d'h0", width)
+	case ModifiedWriteValuesSet:
+		return fmt.Sprintf("{%d{1'b1}}", width)
+	}
+	return d
+}
+
+// rtlName : Make a lowercase SystemVerilog identifier
+func rtlName(s string) string {
+	return strings.ToLower(strings.NewReplacer(".", "_", "$", "_").Replace(linkerSymbolRe.ReplaceAllString(s, "_")))
+}
+
+// RegisterBlockRTL : Generate a SystemVerilog register block for a peripheral
+// The module decodes a 32-bit APB or AXI-Lite slave port. Every field
+// gets its storage according to its access, modifiedWriteValues and
+// readAction, with its reset value where defined (0 otherwise):
+//   - stored fields drive an output port <reg>_<field>_o,
+//   - read-only fields without read side effect are read from an input
+//     port <reg>_<field>_i,
+//   - stored fields the hardware may update (status flags, fields with
+//     write or read side effects) get a <reg>_<field>_we_i strobe and a
+//     <reg>_<field>_d_i value, taking precedence over bus accesses.
+//
+// Every register also drives <reg>_wstb_o and <reg>_rstb_o strobes for one
+// cycle when written or read through the bus.
+func (dev *Device) RegisterBlockRTL(peripheral string, bus RTLBus) ([]byte, error) {
+	if bus != RTLBusAPB && bus != RTLBusAXILite {
+		return nil, fmt.Errorf("unknown bus %q", bus)
+	}
+	periphs, err := dev.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	var p *ResolvedPeripheral
+	for i := range periphs {
+		if periphs[i].Name == peripheral || periphs[i].Peripheral.Name == peripheral {
+			p = &periphs[i]
+			break
+		}
+	}
+	if p == nil {
+		return nil, fmt.Errorf("no peripheral %s", peripheral)
+	}
+
+	// registers grouped by 32-bit word
+	words := make(map[uint64][]ResolvedRegister)
+	fields := make(map[string][]rtlField)
+	var end uint64
+	for _, r := range p.Registers {
+		if r.Size > 32 {
+			return nil, fmt.Errorf("register %s is wider than the 32-bit bus", r.Path())
+		}
+		shift := uint(r.Offset%4) * 8
+		if shift+r.Size > 32 {
+			return nil, fmt.Errorf("register %s crosses a 32-bit word boundary", r.Path())
+		}
+		words[r.Offset/4] = append(words[r.Offset/4], r)
+		for _, f := range r.bitFields() {
+			signal := rtlName(r.Name)
+			if len(r.Fields) > 0 {
+				signal += "_" + rtlName(f.Name)
+			}
+			fields[r.Name] = append(fields[r.Name], rtlField{ResolvedField: f, signal: signal, shift: shift})
+		}
+		if r.Offset+4 > end {
+			end = r.Offset + 4
+		}
+	}
+	var offsets []uint64
+	for w := range words {
+		offsets = append(offsets, w)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	// at least one word index bit, so that decodes hold a valid range
+	addrWidth := 3
+	for uint64(1)<<uint(addrWidth) < end {
+		addrWidth++
+	}
+
+	var b bytes.Buffer
+	module := rtlName(p.Name) + "_regs"
+	fmt.Fprintf(&b, "// %s register block, generated from the SVD description of %s\n", p.Name, dev.Name)
+	fmt.Fprintf(&b, "module %s #(\n  parameter int ADDR_WIDTH = %d\n) (\n", module, addrWidth)
+	b.WriteString("  input  logic                  clk,\n  input  logic                  rst_n,\n")
+	if bus == RTLBusAPB {
+		b.WriteString(`  // APB slave
+  input  logic [ADDR_WIDTH-1:0] paddr,
+  input  logic                  psel,
+  input  logic                  penable,
+  input  logic                  pwrite,
+  input  logic [31:0]           pwdata,
+  input  logic [3:0]            pstrb,
+  output logic [31:0]           prdata,
+  output logic                  pready,
+  output logic                  pslverr`)
+	} else {
+		b.WriteString(`  // AXI-Lite slave
+  input  logic [ADDR_WIDTH-1:0] s_axi_awaddr,
+  input  logic                  s_axi_awvalid,
+  output logic                  s_axi_awready,
+  input  logic [31:0]           s_axi_wdata,
+  input  logic [3:0]            s_axi_wstrb,
+  input  logic                  s_axi_wvalid,
+  output logic                  s_axi_wready,
+  output logic [1:0]            s_axi_bresp,
+  output logic                  s_axi_bvalid,
+  input  logic                  s_axi_bready,
+  input  logic [ADDR_WIDTH-1:0] s_axi_araddr,
+  input  logic                  s_axi_arvalid,
+  output logic                  s_axi_arready,
+  output logic [31:0]           s_axi_rdata,
+  output logic [1:0]            s_axi_rresp,
+  output logic                  s_axi_rvalid,
+  input  logic                  s_axi_rready`)
+	}
+	for _, r := range p.Registers {
+		reg := rtlName(r.Name)
+		fmt.Fprintf(&b, ",\n  // %s\n", r.Name)
+		fmt.Fprintf(&b, "  output logic                  %s_wstb_o,\n", reg)
+		fmt.Fprintf(&b, "  output logic                  %s_rstb_o", reg)
+		for _, f := range fields[r.Name] {
+			vec := rtlVector(f.Width())
+			if f.stored() {
+				fmt.Fprintf(&b, ",\n  output logic %-16s %s_o", vec, f.signal)
+			} else {
+				fmt.Fprintf(&b, ",\n  input  logic %-16s %s_i", vec, f.signal)
+			}
+			if f.volatile() {
+				fmt.Fprintf(&b, ",\n  input  logic %-16s %s_we_i", "", f.signal)
+				fmt.Fprintf(&b, ",\n  input  logic %-16s %s_d_i", vec, f.signal)
+			}
+		}
+	}
+	b.WriteString("\n);\n\n")
+
+	b.WriteString(`  // register access from the bus
+  logic                  wr_en, rd_en, wr_hit, rd_hit;
+  logic [ADDR_WIDTH-1:0] wr_addr, rd_addr;
+  logic [31:0]           wr_data, wr_mask, rd_data;
+  logic [3:0]            wr_strb;
+
+  assign wr_mask = {{8{wr_strb[3]}}, {8{wr_strb[2]}}, {8{wr_strb[1]}}, {8{wr_strb[0]}}};
+
+`)
+	if bus == RTLBusAPB {
+		b.WriteString(`  assign wr_en   = psel & penable & pwrite;
+  assign rd_en   = psel & penable & ~pwrite;
+  assign wr_addr = paddr;
+  assign rd_addr = paddr;
+  assign wr_data = pwdata;
+  assign wr_strb = pstrb;
+  assign prdata  = rd_data;
+  assign pready  = 1'b1;
+  assign pslverr = (wr_en & ~wr_hit) | (rd_en & ~rd_hit);
+
+`)
+	} else {
+		b.WriteString(`  // a write is accepted when both its address and data are valid
+  assign s_axi_awready = s_axi_awvalid & s_axi_wvalid & ~s_axi_bvalid;
+  assign s_axi_wready  = s_axi_awready;
+  assign wr_en   = s_axi_awready;
+  assign wr_addr = s_axi_awaddr;
+  assign wr_data = s_axi_wdata;
+  assign wr_strb = s_axi_wstrb;
+
+  always_ff @(posedge clk or negedge rst_n) begin
+    if (!rst_n) begin
+      s_axi_bvalid <= 1'b0;
+      s_axi_bresp  <= 2'b00;
+    end else if (wr_en) begin
+      s_axi_bvalid <= 1'b1;
+      s_axi_bresp  <= wr_hit ? 2'b00 : 2'b10;
+    end else if (s_axi_bready) begin
+      s_axi_bvalid <= 1'b0;
+    end
+  end
+
+  assign s_axi_arready = ~s_axi_rvalid;
+  assign rd_en   = s_axi_arvalid & s_axi_arready;
+  assign rd_addr = s_axi_araddr;
+
+  always_ff @(posedge clk or negedge rst_n) begin
+    if (!rst_n) begin
+      s_axi_rvalid <= 1'b0;
+      s_axi_rdata  <= 32'h0;
+      s_axi_rresp  <= 2'b00;
+    end else if (rd_en) begin
+      s_axi_rvalid <= 1'b1;
+      s_axi_rdata  <= rd_data;
+      s_axi_rresp  <= rd_hit ? 2'b00 : 2'b10;
+    end else if (s_axi_rready) begin
+      s_axi_rvalid <= 1'b0;
+    end
+  end
+
+`)
+	}
+
+	// address decoding
+	var wrHits, rdHits []string
+	for _, r := range p.Registers {
+		reg := rtlName(r.Name)
+		word := fmt.Sprintf("%d'h%X", addrWidth-2, r.Offset/4)
+		fmt.Fprintf(&b, "  // %s at offset 0x%X\n", r.Name, r.Offset)
+		fmt.Fprintf(&b, "  assign %s_wstb_o = wr_en & (wr_addr[ADDR_WIDTH-1:2] == %s);\n", reg, word)
+		fmt.Fprintf(&b, "  assign %s_rstb_o = rd_en & (rd_addr[ADDR_WIDTH-1:2] == %s);\n\n", reg, word)
+		writable, readable := false, false
+		for _, f := range fields[r.Name] {
+			writable = writable || f.writable()
+			readable = readable || f.readable()
+		}
+		if writable {
+			wrHits = append(wrHits, reg+"_wstb_o")
+		}
+		if readable {
+			rdHits = append(rdHits, reg+"_rstb_o")
+		}
+	}
+	if len(wrHits) == 0 {
+		wrHits = []string{"1'b0"}
+	}
+	if len(rdHits) == 0 {
+		rdHits = []string{"1'b0"}
+	}
+	fmt.Fprintf(&b, "  assign wr_hit = %s;\n", strings.Join(wrHits, " | "))
+	fmt.Fprintf(&b, "  assign rd_hit = %s;\n\n", strings.Join(rdHits, " | "))
+
+	// field storage
+	for _, r := range p.Registers {
+		reg := rtlName(r.Name)
+		for _, f := range fields[r.Name] {
+			if !f.stored() {
+				continue
+			}
+			w := f.Width()
+			bits := rtlRange(f.shift+f.Msb, f.shift+f.Lsb)
+			reset := (r.ResetValue & r.ResetMask) >> f.Lsb & bitMask(w)
+			fmt.Fprintf(&b, "  // %s.%s %s %s\n", r.Name, f.Name, rtlRange(f.Msb, f.Lsb), f.AccessLabel())
+			fmt.Fprintf(&b, "  logic %s_q;\n", strings.TrimLeft(rtlVector(w)+" "+f.signal, " "))
+			once := f.Access == AccessWriteOnce || f.Access == AccessReadWriteOnce
+			if once {
+				fmt.Fprintf(&b, "  logic %s_written;\n", f.signal)
+			}
+			b.WriteString("  always_ff @(posedge clk or negedge rst_n) begin\n")
+			fmt.Fprintf(&b, "    if (!rst_n) begin\n      %s_q <= %d'h%X;\n", f.signal, w, reset)
+			if once {
+				fmt.Fprintf(&b, "      %s_written <= 1'b0;\n", f.signal)
+			}
+			b.WriteString("    end")
+			if f.volatile() {
+				fmt.Fprintf(&b, " else if (%s_we_i) begin\n      %s_q <= %s_d_i;\n    end", f.signal, f.signal, f.signal)
+			}
+			b.WriteString(" else begin\n")
+			switch f.ReadAction {
+			case ReadActionClear:
+				fmt.Fprintf(&b, "      if (%s_rstb_o) %s_q <= %d'h0;\n", reg, f.signal, w)
+			case ReadActionSet:
+				fmt.Fprintf(&b, "      if (%s_rstb_o) %s_q <= {%d{1'b1}};\n", reg, f.signal, w)
+			}
+			if f.writable() {
+				cond := reg + "_wstb_o"
+				if once {
+					cond += " & ~" + f.signal + "_written"
+				}
+				value := rtlWriteValue(f.ModifiedWriteValues, f.signal+"_q", "wr_data"+bits, w)
+				fmt.Fprintf(&b, "      if (%s) begin\n", cond)
+				fmt.Fprintf(&b, "        %s_q <= (%s_q & ~wr_mask%s) | ((%s) & wr_mask%s);\n",
+					f.signal, f.signal, bits, value, bits)
+				if once {
+					fmt.Fprintf(&b, "        %s_written <= 1'b1;\n", f.signal)
+				}
+				b.WriteString("      end\n")
+			}
+			b.WriteString("    end\n  end\n")
+			fmt.Fprintf(&b, "  assign %s_o = %s_q;\n\n", f.signal, f.signal)
+		}
+	}
+
+	// read data
+	b.WriteString("  always_comb begin\n    rd_data = 32'h0;\n    case (rd_addr[ADDR_WIDTH-1:2])\n")
+	for _, word := range offsets {
+		var lines []string
+		for _, r := range words[word] {
+			for _, f := range fields[r.Name] {
+				if !f.readable() {
+					continue
+				}
+				src := f.signal + "_q"
+				if !f.stored() {
+					src = f.signal + "_i"
+				}
+				lines = append(lines, fmt.Sprintf("        rd_data%s = %s;\n", rtlRange(f.shift+f.Msb, f.shift+f.Lsb), src))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "      %d'h%X: begin\n%s      end\n", addrWidth-2, word, strings.Join(lines, ""))
+	}
+	b.WriteString("      default: rd_data = 32'h0;\n    endcase\n  end\n\nendmodule\n")
+	return b.Bytes(), nil
+}
@@ -0,0 +1,115 @@
+package svd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	beginRe     = regexp.MustCompile(`\bbegin\b`)
+	endRe       = regexp.MustCompile(`\bend\b`)
+	addrWidthRe = regexp.MustCompile(`parameter int ADDR_WIDTH = (\d+)`)
+	rangeRe     = regexp.MustCompile(`\[(ADDR_WIDTH-1|\d+):(\d+)\]`)
+	literalRe   = regexp.MustCompile(`\b(\d+)'[hb]`)
+)
+
+// checkRTLRanges : Check that the part selects and sized literals of a module are valid
+func checkRTLRanges(t *testing.T, name, text string) {
+	t.Helper()
+	m := addrWidthRe.FindStringSubmatch(text)
+	if m == nil {
+		t.Fatalf("%s has no ADDR_WIDTH parameter", name)
+	}
+	addrWidth, _ := strconv.Atoi(m[1])
+	for _, r := range rangeRe.FindAllStringSubmatch(text, -1) {
+		msb := addrWidth - 1
+		if r[1] != "ADDR_WIDTH-1" {
+			msb, _ = strconv.Atoi(r[1])
+		}
+		if lsb, _ := strconv.Atoi(r[2]); msb < lsb {
+			t.Errorf("%s has the reversed range %s with ADDR_WIDTH = %d", name, r[0], addrWidth)
+		}
+	}
+	for _, l := range literalRe.FindAllStringSubmatch(text, -1) {
+		if l[1] == "0" {
+			t.Errorf("%s has the zero-width literal %s", name, l[0])
+		}
+	}
+}
+
+func Test_rtlField_storage(t *testing.T) {
+	tests := []struct {
+		name         string
+		field        ResolvedField
+		wantStored   bool
+		wantVolatile bool
+	}{
+		{"read-write", ResolvedField{Access: AccessReadWrite}, true, false},
+		{"read-only status", ResolvedField{Access: AccessReadOnly}, false, false},
+		{"read to clear", ResolvedField{Access: AccessReadOnly, ReadAction: ReadActionClear}, true, true},
+		{"write one to clear", ResolvedField{Access: AccessReadWrite, ModifiedWriteValues: ModifiedWriteValuesOneToClear}, true, true},
+		{"write-only", ResolvedField{Access: AccessWriteOnly}, true, false},
+		{"read modify", ResolvedField{Access: AccessReadOnly, ReadAction: ReadActionModify}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := rtlField{ResolvedField: tt.field}
+			if got := f.stored(); got != tt.wantStored {
+				t.Errorf("rtlField.stored() = %v, want %v", got, tt.wantStored)
+			}
+			if got := f.volatile(); got != tt.wantVolatile {
+				t.Errorf("rtlField.volatile() = %v, want %v", got, tt.wantVolatile)
+			}
+		})
+	}
+}
+
+func TestDevice_RegisterBlockRTL(t *testing.T) {
+	dev := simTestDevice()
+	for _, bus := range []RTLBus{RTLBusAPB, RTLBusAXILite} {
+		got, err := dev.RegisterBlockRTL(dev.Peripherals.Peripheral[0].Name, bus)
+		if err != nil {
+			t.Fatalf("Device.RegisterBlockRTL(%s) error = %v", bus, err)
+		}
+		text := string(got)
+		for _, want := range []string{"module uart0_regs", "output logic                  cr_uarten_o",
+			"input  logic                  sr_txempty_i", "input  logic                  sr_ovr_we_i",
+			"sr_ovr_q <= (sr_ovr_q & ~wr_mask[1]) | ((sr_ovr_q & ~wr_data[1]) & wr_mask[1]);",
+			"lock_written <= 1'b1;", "endmodule\n"} {
+			if !strings.Contains(text, want) {
+				t.Errorf("Device.RegisterBlockRTL(%s) lacks %q", bus, want)
+			}
+		}
+		if b, e := len(beginRe.FindAllString(text, -1)), len(endRe.FindAllString(text, -1)); b != e {
+			t.Errorf("Device.RegisterBlockRTL(%s) has %d begin for %d end", bus, b, e)
+		}
+		checkRTLRanges(t, "Device.RegisterBlockRTL("+string(bus)+")", text)
+	}
+
+	// a single word still needs a valid word index
+	single := NewDevice("Single")
+	single.Peripherals.Peripheral = []Peripheral{{
+		Name:        "GPIO",
+		BaseAddress: "0x40000000",
+		Registers:   &Registers{Register: []Register{{Name: "OUT", AddressOffset: "0x0"}}},
+	}}
+	for _, bus := range []RTLBus{RTLBusAPB, RTLBusAXILite} {
+		got, err := single.RegisterBlockRTL("GPIO", bus)
+		if err != nil {
+			t.Fatalf("Device.RegisterBlockRTL(%s) single register error = %v", bus, err)
+		}
+		text := string(got)
+		name := "Device.RegisterBlockRTL(" + string(bus) + ") single register"
+		for _, want := range []string{"parameter int ADDR_WIDTH = 3", "assign out_wstb_o = wr_en & (wr_addr[ADDR_WIDTH-1:2] == 1'h0);", "      1'h0: begin\n"} {
+			if !strings.Contains(text, want) {
+				t.Errorf("%s lacks %q", name, want)
+			}
+		}
+		checkRTLRanges(t, name, text)
+	}
+	if _, err := dev.RegisterBlockRTL("NONE", RTLBusAPB); err == nil {
+		t.Errorf("Device.RegisterBlockRTL(NONE) error = nil, want an error")
+	}
+}
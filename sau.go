@@ -0,0 +1,214 @@
+package svd
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SAU regions are defined with a granularity of 32 bytes.
+const sauGranule = 32
+
+// SecurityAttribution : security state of an address as seen by the SAU
+type SecurityAttribution string
+
+const (
+	// the address is Secure.
+	SecuritySecure SecurityAttribution = "secure"
+	// the address is Non-secure.
+	SecurityNonSecure SecurityAttribution = "non-secure"
+	// the address is Non-secure Callable (Secure gateway entry points).
+	SecurityNonSecureCallable SecurityAttribution = "non-secure-callable"
+)
+
+// IsEnabled : Report whether the region is enabled (default true)
+func (r Region) IsEnabled() bool {
+	return r.Enabled == nil || *r.Enabled
+}
+
+// Bounds : Get the first and last byte address covered by the region
+func (r Region) Bounds() (first, last uint64, err error) {
+	if first, err = ParseNumber(r.Base); err != nil {
+		return 0, 0, fmt.Errorf("region %s base: %v", r.Name, err)
+	}
+	if last, err = ParseNumber(r.Limit); err != nil {
+		return 0, 0, fmt.Errorf("region %s limit: %v", r.Name, err)
+	}
+	return first, last | (sauGranule - 1), nil
+}
+
+// IsEnabled : Report whether the SAU is enabled (default true)
+func (cfg SauRegionsConfigType) IsEnabled() bool {
+	return cfg.Enabled == nil || *cfg.Enabled
+}
+
+// ValidateSau : Check the SAU description of the processor
+// Regions must be 32-byte aligned, must not overlap, must fit in
+// <sauNumRegions> and <protectionWhenDisabled> must be "s" or "n".
+func (cpu Cpu) ValidateSau() (errs []error) {
+	cfg := cpu.SauRegionsConfig
+	if cfg == nil {
+		return
+	}
+	if cpu.SauNumRegions == 0 {
+		errs = append(errs, fmt.Errorf("sauRegionsConfig is defined but sauNumRegions is 0"))
+	} else if uint(len(cfg.Region)) > cpu.SauNumRegions {
+		errs = append(errs, fmt.Errorf("%d SAU regions defined but sauNumRegions is %d", len(cfg.Region), cpu.SauNumRegions))
+	}
+	switch cfg.ProtectionWhenDisabled {
+	case "", ProtectionSecure, ProtectionNonSecure:
+	default:
+		errs = append(errs, fmt.Errorf("invalid protectionWhenDisabled %q (expected \"s\" or \"n\")", cfg.ProtectionWhenDisabled))
+	}
+	type span struct {
+		name        string
+		first, last uint64
+	}
+	var spans []span
+	for i, r := range cfg.Region {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		switch r.Access {
+		case RegionAccessNonSecure, RegionAccessSecureCallable:
+		default:
+			errs = append(errs, fmt.Errorf("region %s: invalid access %q (expected \"n\" or \"c\")", name, r.Access))
+		}
+		first, last, err := r.Bounds()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if first%sauGranule != 0 {
+			errs = append(errs, fmt.Errorf("region %s: base 0x%08X is not 32-byte aligned", name, first))
+		}
+		if limit, _ := ParseNumber(r.Limit); limit%sauGranule != 0 && limit%sauGranule != sauGranule-1 {
+			errs = append(errs, fmt.Errorf("region %s: limit 0x%08X is not on a 32-byte boundary", name, limit))
+		}
+		if last < first {
+			errs = append(errs, fmt.Errorf("region %s: limit is below base", name))
+			continue
+		}
+		if r.IsEnabled() {
+			spans = append(spans, span{name, first, last})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].first < spans[j].first })
+	for i := 1; i < len(spans); i++ {
+		if spans[i].first <= spans[i-1].last {
+			errs = append(errs, fmt.Errorf("region %s overlaps region %s", spans[i].name, spans[i-1].name))
+		}
+	}
+	return
+}
+
+// AddressSecurity : result of a security query on an address
+type AddressSecurity struct {
+	// Queried address.
+	Address uint64
+
+	// Security state given by the SAU configuration.
+	Attribution SecurityAttribution
+
+	// SAU region containing the address, nil if none.
+	Region *Region
+
+	// Peripheral containing the address, nil if none.
+	Peripheral *Peripheral
+
+	// Set when the peripheral <protection> disagrees with the SAU.
+	Mismatch bool
+}
+
+// Security : Tell whether an address is Secure, Non-secure or Non-secure Callable
+// Without SAU description, every address is Secure (reset state of the SAU).
+func (dev *Device) Security(address uint64) (sec AddressSecurity) {
+	sec.Address = address
+	sec.Attribution = SecuritySecure
+	if cfg := dev.Cpu.SauRegionsConfig; cfg != nil {
+		if !cfg.IsEnabled() {
+			if cfg.ProtectionWhenDisabled == ProtectionNonSecure {
+				sec.Attribution = SecurityNonSecure
+			}
+		} else {
+			for i := range cfg.Region {
+				r := &cfg.Region[i]
+				first, last, err := r.Bounds()
+				if err != nil || !r.IsEnabled() || address < first || address > last {
+					continue
+				}
+				sec.Region = r
+				if r.Access == RegionAccessSecureCallable {
+					sec.Attribution = SecurityNonSecureCallable
+				} else {
+					sec.Attribution = SecurityNonSecure
+				}
+				break
+			}
+		}
+	}
+	sec.Peripheral = dev.PeripheralAt(address)
+	if sec.Peripheral != nil {
+		switch dev.effectivePeripheralProtection(sec.Peripheral) {
+		case ProtectionSecure:
+			sec.Mismatch = sec.Attribution != SecuritySecure
+		case ProtectionNonSecure:
+			sec.Mismatch = sec.Attribution != SecurityNonSecure
+		}
+	}
+	return
+}
+
+// effectivePeripheralProtection : protection of a peripheral after inheritance
+func (dev *Device) effectivePeripheralProtection(p *Peripheral) ProtectionType {
+	if p.Protection != "" {
+		return p.Protection
+	}
+	if base := dev.FindPeripheral(p.DerivedFrom); base != nil && base != p && base.Protection != "" {
+		return base.Protection
+	}
+	return dev.Protection
+}
+
+// ValidateSau : Check the SAU description against the device peripherals
+// In addition to the processor checks, every peripheral with an explicit
+// <protection> must be located in memory of the same security state, from
+// the first to the last byte of each of its address ranges.
+func (dev *Device) ValidateSau() (errs []error) {
+	errs = dev.Cpu.ValidateSau()
+	cfg := dev.Cpu.SauRegionsConfig
+	if cfg == nil {
+		return
+	}
+	// the attribution can only change at the bounds of a region
+	var bounds []uint64
+	for _, region := range cfg.Region {
+		if first, last, err := region.Bounds(); err == nil && region.IsEnabled() {
+			bounds = append(bounds, first, last+1)
+		}
+	}
+	for i := range dev.Peripherals.Peripheral {
+		p := &dev.Peripherals.Peripheral[i]
+		ranges, err := dev.AddressRanges(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, r := range ranges {
+			addresses := []uint64{r.First, r.Last}
+			for _, b := range bounds {
+				if b > r.First && b < r.Last {
+					addresses = append(addresses, b)
+				}
+			}
+			for _, address := range addresses {
+				if sec := dev.Security(address); sec.Mismatch && sec.Peripheral == p {
+					errs = append(errs, fmt.Errorf("peripheral %s has protection %q but 0x%08X is %s", p.Name,
+						dev.effectivePeripheralProtection(p), address, sec.Attribution))
+					break
+				}
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,131 @@
+package svd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func sauTestDevice() *Device {
+	dev := NewDevice("SauDevice")
+	dev.Cpu.Select(CpuNameCM33)
+	dev.Cpu.SauNumRegions = 2
+	dev.Cpu.SauRegionsConfig = &SauRegionsConfigType{
+		ProtectionWhenDisabled: ProtectionSecure,
+		Region: []Region{
+			{Name: "NS", Base: "0x20000000", Limit: "0x2000FFE0", Access: RegionAccessNonSecure},
+			{Name: "NSC", Base: "0x10000000", Limit: "0x1000001F", Access: RegionAccessSecureCallable},
+		},
+	}
+	dev.Peripherals.Peripheral = []Peripheral{
+		{
+			Name:         "UART0",
+			BaseAddress:  "0x20001000",
+			Protection:   ProtectionSecure,
+			AddressBlock: []AddressBlock{{Offset: 0, Size: "0x100", Usage: UsageRegisters}},
+		},
+		{
+			Name:         "UART1",
+			BaseAddress:  "0x20002000",
+			Protection:   ProtectionNonSecure,
+			AddressBlock: []AddressBlock{{Offset: 0, Size: "0x100", Usage: UsageRegisters}},
+		},
+	}
+	return dev
+}
+
+func TestCpu_ValidateSau(t *testing.T) {
+	tests := []struct {
+		name    string
+		regions []Region
+		num     uint
+		want    []string
+	}{
+		{
+			name: "valid",
+			regions: []Region{
+				{Name: "A", Base: "0x1000", Limit: "0x1FE0", Access: RegionAccessNonSecure},
+				{Name: "B", Base: "0x2000", Limit: "0x201F", Access: RegionAccessSecureCallable},
+			},
+			num: 2,
+		},
+		{
+			name: "misaligned and overlapping",
+			regions: []Region{
+				{Name: "A", Base: "0x1004", Limit: "0x2010", Access: RegionAccessNonSecure},
+				{Name: "B", Base: "0x2000", Limit: "0x201F", Access: "x"},
+			},
+			num: 1,
+			want: []string{
+				"2 SAU regions defined but sauNumRegions is 1",
+				"region A: base 0x00001004 is not 32-byte aligned",
+				"region A: limit 0x00002010 is not on a 32-byte boundary",
+				"region B: invalid access \"x\" (expected \"n\" or \"c\")",
+				"region B overlaps region A",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpu := Cpu{SauNumRegions: tt.num, SauRegionsConfig: &SauRegionsConfigType{Region: tt.regions}}
+			var got []string
+			for _, err := range cpu.ValidateSau() {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cpu.ValidateSau() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDevice_Security(t *testing.T) {
+	dev := sauTestDevice()
+	tests := []struct {
+		name         string
+		address      uint64
+		want         SecurityAttribution
+		wantPeriph   string
+		wantMismatch bool
+	}{
+		{name: "secure by default", address: 0x00000000, want: SecuritySecure},
+		{name: "non-secure callable", address: 0x10000010, want: SecurityNonSecureCallable},
+		{name: "end of limit block", address: 0x2000FFFF, want: SecurityNonSecure},
+		{name: "after limit block", address: 0x20010000, want: SecuritySecure},
+		{name: "secure peripheral in non-secure region", address: 0x20001004, want: SecurityNonSecure, wantPeriph: "UART0", wantMismatch: true},
+		{name: "non-secure peripheral", address: 0x20002000, want: SecurityNonSecure, wantPeriph: "UART1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dev.Security(tt.address)
+			if got.Attribution != tt.want {
+				t.Errorf("Device.Security() attribution = %v, want %v", got.Attribution, tt.want)
+			}
+			periph := ""
+			if got.Peripheral != nil {
+				periph = got.Peripheral.Name
+			}
+			if periph != tt.wantPeriph || got.Mismatch != tt.wantMismatch {
+				t.Errorf("Device.Security() peripheral = %q mismatch = %v, want %q %v", periph, got.Mismatch, tt.wantPeriph, tt.wantMismatch)
+			}
+		})
+	}
+
+	// a range straddling the end of a region is checked on both sides
+	dev.Peripherals.Peripheral = append(dev.Peripherals.Peripheral, Peripheral{
+		Name:         "UART2",
+		BaseAddress:  "0x2000FF80",
+		Protection:   ProtectionNonSecure,
+		AddressBlock: []AddressBlock{{Offset: 0, Size: "0x100", Usage: UsageRegisters}},
+	})
+	var got []string
+	for _, err := range dev.ValidateSau() {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		`peripheral UART0 has protection "s" but 0x20001000 is non-secure`,
+		`peripheral UART2 has protection "n" but 0x2001007F is secure`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Device.ValidateSau() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,659 @@
+package svd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema versions known by ValidateSchema.
+const (
+	SchemaVersion11 = "1.1"
+	SchemaVersion12 = "1.2"
+	SchemaVersion13 = "1.3"
+)
+
+// SchemaViolation : a place where a document does not conform to CMSIS-SVD.xsd
+type SchemaViolation struct {
+	// Line of the offending element in the document, starting at 1.
+	Line int
+
+	// Slash separated path of the element, e.g. /device/peripherals/peripheral.
+	Path string
+
+	Message string
+}
+
+// Error : Describe the violation
+func (v SchemaViolation) Error() string {
+	return fmt.Sprintf("line %d: %s: %s", v.Line, v.Path, v.Message)
+}
+
+// schemaParticle : an element, or a choice between sequences, of a content model
+type schemaParticle struct {
+	name string
+	typ  string
+
+	// Allowed occurrences, -1 for unbounded.
+	min, max int
+
+	// First schema version defining the element, empty for 1.1.
+	since string
+
+	// Alternative sequences of a choice, nil for an element.
+	choice [][]schemaParticle
+}
+
+// schemaType : the allowed content of an element
+type schemaType struct {
+	// Child elements in order, nil for a simple type.
+	content []schemaParticle
+
+	// Simple type of the attributes.
+	attrs map[string]string
+
+	// Any content is accepted, as for vendorExtensions.
+	any bool
+}
+
+func schemaOpt(name, typ string) schemaParticle {
+	return schemaParticle{name: name, typ: typ, min: 0, max: 1}
+}
+func schemaReq(name, typ string) schemaParticle {
+	return schemaParticle{name: name, typ: typ, min: 1, max: 1}
+}
+
+func schemaChoice(min, max int, branches ...[]schemaParticle) schemaParticle {
+	return schemaParticle{min: min, max: max, choice: branches}
+}
+
+// since13 : Get the particle tagged as introduced by schema version 1.3
+func (p schemaParticle) since13() schemaParticle {
+	p.since = SchemaVersion13
+	return p
+}
+
+// since12 : Get the particle tagged as introduced by schema version 1.2
+func (p schemaParticle) since12() schemaParticle {
+	p.since = SchemaVersion12
+	return p
+}
+
+// schemaDimGroup : the optional dimElementGroup
+var schemaDimGroup = schemaChoice(0, 1, []schemaParticle{
+	schemaReq("dim", "scaledNonNegativeInteger"),
+	schemaReq("dimIncrement", "scaledNonNegativeInteger"),
+	schemaOpt("dimIndex", "dimIndexType"),
+	schemaOpt("dimName", "identifierType"),
+	schemaOpt("dimArrayIndex", "dimArrayIndex").since13(),
+})
+
+// schemaRegisterProperties : the registerPropertiesGroup
+var schemaRegisterProperties = []schemaParticle{
+	schemaOpt("size", "scaledNonNegativeInteger"),
+	schemaOpt("access", "accessType"),
+	schemaOpt("protection", "protectionStringType").since13(),
+	schemaOpt("resetValue", "scaledNonNegativeInteger"),
+	schemaOpt("resetMask", "scaledNonNegativeInteger"),
+}
+
+// schemaSequence : Concatenate particles and particle groups
+func schemaSequence(parts ...interface{}) []schemaParticle {
+	var seq []schemaParticle
+	for _, part := range parts {
+		switch p := part.(type) {
+		case schemaParticle:
+			seq = append(seq, p)
+		case []schemaParticle:
+			seq = append(seq, p...)
+		}
+	}
+	return seq
+}
+
+// svdSchema : the complex types of CMSIS-SVD.xsd, by element type
+// The tables are transcribed from the 1.3 schema; elements introduced
+// after version 1.1 carry the version defining them, which gives the 1.1
+// and 1.2 schemas back.
+var svdSchema = map[string]schemaType{
+	"device": {attrs: map[string]string{"schemaVersion": "versionType"}, content: schemaSequence(
+		schemaOpt("vendor", "stringType"),
+		schemaOpt("vendorID", "identifierType"),
+		schemaReq("name", "identifierType"),
+		schemaOpt("series", "stringType"),
+		schemaReq("version", "stringType"),
+		schemaReq("description", "stringType"),
+		schemaOpt("licenseText", "stringType"),
+		schemaOpt("cpu", "cpu"),
+		schemaOpt("headerSystemFilename", "identifierType"),
+		schemaOpt("headerDefinitionsPrefix", "identifierType"),
+		schemaReq("addressUnitBits", "scaledNonNegativeInteger"),
+		schemaReq("width", "scaledNonNegativeInteger"),
+		schemaRegisterProperties,
+		schemaReq("peripherals", "peripherals"),
+		schemaOpt("vendorExtensions", "vendorExtensions"),
+	)},
+	"cpu": {content: []schemaParticle{
+		schemaReq("name", "cpuNameType"),
+		schemaReq("revision", "revisionType"),
+		schemaReq("endian", "endianType"),
+		schemaReq("mpuPresent", "boolean"),
+		schemaReq("fpuPresent", "boolean"),
+		schemaOpt("fpuDP", "boolean").since12(),
+		schemaOpt("dspPresent", "boolean").since13(),
+		schemaOpt("icachePresent", "boolean").since12(),
+		schemaOpt("dcachePresent", "boolean").since12(),
+		schemaOpt("itcmPresent", "boolean").since12(),
+		schemaOpt("dtcmPresent", "boolean").since12(),
+		schemaOpt("vtorPresent", "boolean").since12(),
+		schemaReq("nvicPrioBits", "scaledNonNegativeInteger"),
+		schemaReq("vendorSystickConfig", "boolean"),
+		schemaOpt("deviceNumInterrupts", "scaledNonNegativeInteger").since13(),
+		schemaOpt("sauNumRegions", "scaledNonNegativeInteger").since13(),
+		schemaOpt("sauRegionsConfig", "sauRegionsConfig").since13(),
+	}},
+	"sauRegionsConfig": {
+		attrs:   map[string]string{"enabled": "boolean", "protectionWhenDisabled": "protectionStringType"},
+		content: []schemaParticle{{name: "region", typ: "sauRegion", min: 0, max: -1}},
+	},
+	"sauRegion": {
+		attrs: map[string]string{"enabled": "boolean", "name": "stringType"},
+		content: []schemaParticle{
+			schemaReq("base", "scaledNonNegativeInteger"),
+			schemaReq("limit", "scaledNonNegativeInteger"),
+			schemaReq("access", "sauAccessType"),
+		},
+	},
+	"peripherals": {content: []schemaParticle{{name: "peripheral", typ: "peripheral", min: 1, max: -1}}},
+	"peripheral": {attrs: map[string]string{"derivedFrom": "derivedFromType"}, content: schemaSequence(
+		schemaDimGroup,
+		schemaReq("name", "dimableIdentifierType"),
+		schemaOpt("version", "stringType"),
+		schemaOpt("description", "stringType"),
+		schemaOpt("alternatePeripheral", "dimableIdentifierType"),
+		schemaOpt("groupName", "stringType"),
+		schemaOpt("prependToName", "identifierType"),
+		schemaOpt("appendToName", "identifierType"),
+		schemaOpt("headerStructName", "dimableIdentifierType"),
+		schemaOpt("disableCondition", "stringType"),
+		schemaReq("baseAddress", "scaledNonNegativeInteger"),
+		schemaRegisterProperties,
+		schemaParticle{name: "addressBlock", typ: "addressBlock", min: 0, max: -1},
+		schemaParticle{name: "interrupt", typ: "interrupt", min: 0, max: -1},
+		schemaOpt("registers", "registers"),
+		schemaOpt("vendorExtensions", "vendorExtensions"),
+	)},
+	"addressBlock": {content: []schemaParticle{
+		schemaReq("offset", "scaledNonNegativeInteger"),
+		schemaReq("size", "scaledNonNegativeInteger"),
+		schemaReq("usage", "addressBlockUsageType"),
+		schemaOpt("protection", "protectionStringType").since13(),
+	}},
+	"interrupt": {content: []schemaParticle{
+		schemaReq("name", "stringType"),
+		schemaOpt("description", "stringType"),
+		schemaReq("value", "integer"),
+	}},
+	"registers": {content: []schemaParticle{schemaChoice(1, -1,
+		[]schemaParticle{schemaReq("cluster", "cluster")},
+		[]schemaParticle{schemaReq("register", "register")},
+	)}},
+	"cluster": {attrs: map[string]string{"derivedFrom": "derivedFromType"}, content: schemaSequence(
+		schemaDimGroup,
+		schemaReq("name", "dimableIdentifierType"),
+		schemaOpt("description", "stringType"),
+		schemaOpt("alternateCluster", "dimableIdentifierType"),
+		schemaOpt("headerStructName", "identifierType"),
+		schemaReq("addressOffset", "scaledNonNegativeInteger"),
+		schemaRegisterProperties,
+		schemaChoice(1, -1,
+			[]schemaParticle{schemaReq("register", "register")},
+			[]schemaParticle{schemaReq("cluster", "cluster")},
+		),
+		schemaOpt("vendorExtensions", "vendorExtensions"),
+	)},
+	"register": {attrs: map[string]string{"derivedFrom": "derivedFromType"}, content: schemaSequence(
+		schemaDimGroup,
+		schemaReq("name", "dimableIdentifierType"),
+		schemaOpt("displayName", "stringType"),
+		schemaOpt("description", "stringType"),
+		schemaChoice(0, 1,
+			[]schemaParticle{schemaReq("alternateGroup", "identifierType")},
+			[]schemaParticle{schemaReq("alternateRegister", "dimableIdentifierType")},
+		),
+		schemaReq("addressOffset", "scaledNonNegativeInteger"),
+		schemaRegisterProperties,
+		schemaOpt("dataType", "dataTypeType").since12(),
+		schemaOpt("modifiedWriteValues", "modifiedWriteValuesType"),
+		schemaOpt("writeConstraint", "writeConstraint"),
+		schemaOpt("readAction", "readActionType"),
+		schemaOpt("fields", "fields"),
+		schemaOpt("vendorExtensions", "vendorExtensions"),
+	)},
+	"fields": {content: []schemaParticle{{name: "field", typ: "field", min: 1, max: -1}}},
+	"field": {attrs: map[string]string{"derivedFrom": "derivedFromType"}, content: schemaSequence(
+		schemaDimGroup,
+		schemaReq("name", "dimableIdentifierType"),
+		schemaOpt("description", "stringType"),
+		schemaChoice(1, 1,
+			[]schemaParticle{schemaReq("bitOffset", "scaledNonNegativeInteger"), schemaOpt("bitWidth", "scaledNonNegativeInteger")},
+			[]schemaParticle{schemaReq("lsb", "scaledNonNegativeInteger"), schemaReq("msb", "scaledNonNegativeInteger")},
+			[]schemaParticle{schemaReq("bitRange", "bitRangeType")},
+		),
+		schemaOpt("access", "accessType"),
+		schemaOpt("modifiedWriteValues", "modifiedWriteValuesType"),
+		schemaOpt("writeConstraint", "writeConstraint"),
+		schemaOpt("readAction", "readActionType"),
+		schemaParticle{name: "enumeratedValues", typ: "enumeratedValues", min: 0, max: 2},
+	)},
+	"writeConstraint": {content: []schemaParticle{schemaChoice(1, 1,
+		[]schemaParticle{schemaReq("writeAsRead", "boolean")},
+		[]schemaParticle{schemaReq("useEnumeratedValues", "boolean")},
+		[]schemaParticle{schemaReq("range", "range")},
+	)}},
+	"range": {content: []schemaParticle{
+		schemaReq("minimum", "scaledNonNegativeInteger"),
+		schemaReq("maximum", "scaledNonNegativeInteger"),
+	}},
+	// enumeratedValue is optional so that a derived section may omit it.
+	"enumeratedValues": {attrs: map[string]string{"derivedFrom": "derivedFromType"}, content: []schemaParticle{
+		schemaOpt("name", "identifierType"),
+		schemaOpt("headerEnumName", "identifierType").since13(),
+		schemaOpt("usage", "enumUsageType"),
+		{name: "enumeratedValue", typ: "enumeratedValue", min: 0, max: -1},
+		schemaOpt("vendorExtensions", "vendorExtensions"),
+	}},
+	"enumeratedValue": {content: []schemaParticle{
+		schemaReq("name", "stringType"),
+		schemaOpt("description", "stringType"),
+		schemaChoice(1, 1,
+			[]schemaParticle{schemaReq("value", "enumeratedValueDataType")},
+			[]schemaParticle{schemaReq("isDefault", "boolean")},
+		),
+	}},
+	"dimArrayIndex": {content: []schemaParticle{
+		schemaOpt("headerEnumName", "identifierType"),
+		{name: "enumeratedValue", typ: "enumeratedValue", min: 1, max: -1},
+	}},
+	"vendorExtensions": {any: true},
+}
+
+// schemaEnum : Get a pattern matching one of the values
+func schemaEnum(values ...string) *regexp.Regexp {
+	for i, v := range values {
+		values[i] = regexp.QuoteMeta(v)
+	}
+	return regexp.MustCompile(`^(` + strings.Join(values, "|") + `)$`)
+}
+
+// svdSimpleTypes : the simple types of CMSIS-SVD.xsd
+// Types without pattern, such as descriptions, accept any text.
+var svdSimpleTypes = map[string]*regexp.Regexp{
+	"stringType":               regexp.MustCompile(`^[\x{0}-\x{FF}]*$`),
+	"identifierType":           regexp.MustCompile(`^[_A-Za-z][_A-Za-z0-9]*$`),
+	"dimableIdentifierType":    regexp.MustCompile(`^(((%s)|(%s)[_A-Za-z][_A-Za-z0-9]*)|([_A-Za-z][_A-Za-z0-9]*(\[%s\])?)|([_A-Za-z][_A-Za-z0-9]*(%s)?[_A-Za-z0-9]*))$`),
+	"derivedFromType":          regexp.MustCompile(`^[_A-Za-z0-9%\[\]]+(\.[_A-Za-z0-9%\[\]]+)*$`),
+	"scaledNonNegativeInteger": regexp.MustCompile(`^[+]?(0x|0X|#)?[0-9a-fA-F]+[kmgtKMGT]?$`),
+	"enumeratedValueDataType":  regexp.MustCompile(`^[+]?(((0x|0X)[0-9a-fA-F]+)|([0-9]+)|((#|0b)[01xX]+))$`),
+	"dimIndexType":             regexp.MustCompile(`^([0-9]+\-[0-9]+|[A-Z]-[A-Z]|[_0-9a-zA-Z]+(,\s*[_0-9a-zA-Z]+)+)$`),
+	"bitRangeType":             regexp.MustCompile(`^\[([0-4])?[0-9]:([0-4])?[0-9]\]$`),
+	"revisionType":             regexp.MustCompile(`^r[0-9]*p[0-9]*$`),
+	"versionType":              regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`),
+	"boolean":                  schemaEnum("true", "false", "1", "0"),
+	"integer":                  regexp.MustCompile(`^[+-]?[0-9]+$`),
+	"accessType": schemaEnum(string(AccessReadOnly), string(AccessWriteOnly), string(AccessReadWrite),
+		string(AccessWriteOnce), string(AccessReadWriteOnce)),
+	"modifiedWriteValuesType": schemaEnum("oneToClear", "oneToSet", "oneToToggle", "zeroToClear",
+		"zeroToSet", "zeroToToggle", "clear", "set", "modify"),
+	"readActionType":        schemaEnum("clear", "set", "modify", "modifyExternal"),
+	"enumUsageType":         schemaEnum("read", "write", "read-write"),
+	"protectionStringType":  schemaEnum("s", "n", "p"),
+	"sauAccessType":         schemaEnum("c", "n"),
+	"endianType":            schemaEnum("little", "big", "selectable", "other"),
+	"addressBlockUsageType": schemaEnum("registers", "buffer", "reserved"),
+	"cpuNameType": schemaEnum("CM0", "CM0PLUS", "CM0+", "CM1", "SC000", "CM23", "CM3", "CM33", "CM35P",
+		"CM55", "SC300", "CM4", "CM7", "CA5", "CA7", "CA8", "CA9", "CA15", "CA17", "CA53", "CA57", "CA72", "other"),
+	"dataTypeType": schemaEnum("uint8_t", "uint16_t", "uint32_t", "uint64_t", "int8_t", "int16_t", "int32_t",
+		"int64_t", "uint8_t *", "uint16_t *", "uint32_t *", "uint64_t *", "int8_t *", "int16_t *", "int32_t *",
+		"int64_t *"),
+}
+
+// svdValuesSince : the values of simple types introduced after schema version 1.1
+// Versions are only distinguished down to the minor version, so CM35P and
+// CM55, added by 1.3 patch releases, are accepted by every 1.3.x.
+var svdValuesSince = map[string]map[string]string{
+	"cpuNameType": {
+		string(CpuNameCM23):  SchemaVersion13,
+		string(CpuNameCM33):  SchemaVersion13,
+		string(CpuNameCM35P): SchemaVersion13,
+		string(CpuNameCM55):  SchemaVersion13,
+	},
+}
+
+// schemaVersionOf : Get the known schema version a version string falls in
+func schemaVersionOf(version string) (string, bool) {
+	for _, v := range []string{SchemaVersion11, SchemaVersion12, SchemaVersion13} {
+		if version == v || strings.HasPrefix(version, v+".") {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// schemaChild : a child element met while validating
+type schemaChild struct {
+	name string
+	line int
+}
+
+// schemaFrame : an open element being validated
+type schemaFrame struct {
+	path     string
+	typ      string
+	line     int
+	text     strings.Builder
+	children []schemaChild
+}
+
+// schemaValidator : the state of a validation
+type schemaValidator struct {
+	version    string
+	violations []SchemaViolation
+}
+
+func (v *schemaValidator) report(line int, path, format string, a ...interface{}) {
+	v.violations = append(v.violations, SchemaViolation{Line: line, Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+// defined : Tell whether a particle exists in the validated version
+func (v *schemaValidator) defined(p schemaParticle) bool {
+	return p.since == "" || p.since <= v.version
+}
+
+// firsts : Get the element names a sequence can start with
+func (v *schemaValidator) firsts(seq []schemaParticle) (names []string) {
+	for _, p := range seq {
+		if p.choice == nil {
+			if v.defined(p) {
+				names = append(names, p.name)
+			}
+			if p.min > 0 && v.defined(p) {
+				return
+			}
+			continue
+		}
+		required := p.min > 0
+		for _, b := range p.choice {
+			names = append(names, v.firsts(b)...)
+		}
+		if required {
+			return
+		}
+	}
+	return
+}
+
+// particleOf : Get the particle of an element name, searching choices
+func particleOf(seq []schemaParticle, name string) *schemaParticle {
+	for i := range seq {
+		if seq[i].choice == nil && seq[i].name == name {
+			return &seq[i]
+		}
+		for _, b := range seq[i].choice {
+			if p := particleOf(b, name); p != nil {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// match : Match the children from i against a sequence, reporting the first mismatch
+// The CMSIS-SVD content models are deterministic, so a greedy match is
+// enough. ok is false once a violation has been reported.
+func (v *schemaValidator) match(f *schemaFrame, seq []schemaParticle, i int) (next int, ok bool) {
+	kids := f.children
+	for _, p := range seq {
+		count := 0
+		if p.choice == nil {
+			if !v.defined(p) {
+				continue
+			}
+			for i < len(kids) && (p.max < 0 || count < p.max) && kids[i].name == p.name {
+				i++
+				count++
+			}
+			if count < p.min {
+				v.missing(f, i, []string{p.name})
+				return i, false
+			}
+			continue
+		}
+		for i < len(kids) && (p.max < 0 || count < p.max) {
+			var branch []schemaParticle
+			for _, b := range p.choice {
+				for _, name := range v.firsts(b) {
+					if name == kids[i].name {
+						branch = b
+					}
+				}
+			}
+			if branch == nil {
+				break
+			}
+			n, ok := v.match(f, branch, i)
+			if !ok {
+				return n, false
+			}
+			if n == i {
+				break
+			}
+			i = n
+			count++
+		}
+		if count < p.min {
+			v.missing(f, i, v.firsts([]schemaParticle{p}))
+			return i, false
+		}
+	}
+	return i, true
+}
+
+// missing : Report the expected elements not found at position i
+func (v *schemaValidator) missing(f *schemaFrame, i int, expected []string) {
+	want := "<" + strings.Join(expected, "> or <") + ">"
+	if i < len(f.children) {
+		v.unexpected(f, f.children[i], "expected "+want)
+		return
+	}
+	v.report(f.line, f.path, "missing element %s", want)
+}
+
+// unexpected : Report an element not allowed where it is
+func (v *schemaValidator) unexpected(f *schemaFrame, kid schemaChild, detail string) {
+	if p := particleOf(svdSchema[f.typ].content, kid.name); p != nil && !v.defined(*p) {
+		v.report(kid.line, f.path+"/"+kid.name, "element is not defined before schema version %s", p.since)
+		return
+	}
+	v.report(kid.line, f.path+"/"+kid.name, "unexpected element, %s", detail)
+}
+
+// close : Validate the content of an element once complete
+func (v *schemaValidator) close(f *schemaFrame) {
+	t, complex := svdSchema[f.typ]
+	if !complex {
+		value := strings.TrimSpace(f.text.String())
+		if re := svdSimpleTypes[f.typ]; re != nil && !re.MatchString(value) {
+			v.report(f.line, f.path, "invalid %s %q", f.typ, value)
+		} else if since := svdValuesSince[f.typ][value]; since > v.version {
+			v.report(f.line, f.path, "%s %q is not defined before schema version %s", f.typ, value, since)
+		}
+		return
+	}
+	if t.any {
+		return
+	}
+	if strings.TrimSpace(f.text.String()) != "" {
+		v.report(f.line, f.path, "unexpected text")
+	}
+	i, ok := v.match(f, t.content, 0)
+	if ok && i < len(f.children) {
+		v.unexpected(f, f.children[i], "not allowed here")
+	}
+}
+
+// schemaInstanceAttrs : the XML Schema instance attributes locating the schema
+// They are matched by local name: the prefix of a document written from
+// a decoded device may be bound to nothing, as xs:noNamespaceSchemaLocation
+// with an empty xmlns:xs.
+var schemaInstanceAttrs = map[string]bool{
+	"noNamespaceSchemaLocation": true,
+	"schemaLocation":            true,
+}
+
+// ValidateSchema : Check a document against the CMSIS-SVD schema
+// The schema is the one of the given version (1.1, 1.2 or 1.3.x), or of
+// the schemaVersion attribute of the document if version is empty. The
+// element structure, element order, occurrences, attributes and value
+// patterns of CMSIS-SVD.xsd are checked; elements and processor names
+// introduced by a later version are reported as such. err is only set
+// when the document is not well-formed XML.
+//
+// This is not a full XSD validation: the schema is an approximation of
+// the latest CMSIS-SVD.xsd, built into the package, where the elements
+// and enumerated values added since 1.1 are tagged with the minor version
+// introducing them. Differences between 1.3 patch releases are not
+// modelled, and neither are identity constraints nor the per-version
+// changes of the value patterns.
+func ValidateSchema(r io.Reader, version string) (violations []SchemaViolation, err error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var newlines []int
+	for i, c := range data {
+		if c == '\n' {
+			newlines = append(newlines, i)
+		}
+	}
+	lineOf := func(offset int64) int {
+		return sort.SearchInts(newlines, int(offset)) + 1
+	}
+
+	v := &schemaValidator{}
+	if version != "" {
+		known, ok := schemaVersionOf(version)
+		if !ok {
+			return nil, fmt.Errorf("unknown schema version %q", version)
+		}
+		v.version = known
+	}
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*schemaFrame
+	skip := 0
+	for {
+		offset := d.InputOffset()
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineOf(d.InputOffset()), err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			line := lineOf(offset)
+			if skip > 0 {
+				skip++
+				continue
+			}
+			name := t.Name.Local
+			if len(stack) == 0 {
+				if name != "device" {
+					v.report(line, "/"+name, "root element must be <device>")
+					skip = 1
+					continue
+				}
+				if v.version == "" {
+					for _, a := range t.Attr {
+						if a.Name.Local == "schemaVersion" && a.Name.Space == "" {
+							v.version, _ = schemaVersionOf(strings.TrimSpace(a.Value))
+						}
+					}
+					if v.version == "" {
+						v.version = SchemaVersion13
+						v.report(line, "/device", "unknown schemaVersion, validated as %s", SchemaVersion13)
+					}
+				}
+				stack = append(stack, &schemaFrame{path: "/device", typ: "device", line: line})
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, schemaChild{name, line})
+				if svdSchema[parent.typ].any {
+					skip = 1
+					continue
+				}
+				p := particleOf(svdSchema[parent.typ].content, name)
+				if p == nil {
+					// reported when the parent closes
+					skip = 1
+					continue
+				}
+				stack = append(stack, &schemaFrame{path: parent.path + "/" + name, typ: p.typ, line: line})
+			}
+			f := stack[len(stack)-1]
+			attrs := svdSchema[f.typ].attrs
+			for _, a := range t.Attr {
+				if a.Name.Space != "" || a.Name.Local == "xmlns" || schemaInstanceAttrs[a.Name.Local] {
+					continue
+				}
+				typ, ok := attrs[a.Name.Local]
+				if !ok {
+					v.report(line, f.path, "unexpected attribute %s", a.Name.Local)
+					continue
+				}
+				if re := svdSimpleTypes[typ]; !re.MatchString(strings.TrimSpace(a.Value)) {
+					v.report(line, f.path, "invalid %s %q for attribute %s", typ, a.Value, a.Name.Local)
+				}
+			}
+			if f.typ == "device" {
+				found := false
+				for _, a := range t.Attr {
+					found = found || a.Name.Local == "schemaVersion" && a.Name.Space == ""
+				}
+				if !found {
+					v.report(line, f.path, "missing attribute schemaVersion")
+				}
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			v.close(f)
+		case xml.CharData:
+			if skip == 0 && len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	sort.SliceStable(v.violations, func(i, j int) bool { return v.violations[i].Line < v.violations[j].Line })
+	return v.violations, nil
+}
+
+// ValidateSchema : Check the SVD output of the device against its schema version
+// The version is taken from SchemaVersion; see ValidateSchema.
+func (dev Device) ValidateSchema() ([]SchemaViolation, error) {
+	data, err := dev.SVD()
+	if err != nil {
+		return nil, err
+	}
+	return ValidateSchema(bytes.NewReader(data), "")
+}
@@ -0,0 +1,202 @@
+package svd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const schemaTestDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<device schemaVersion="%s">
+  <name>SchemaDevice</name>
+  <version>1.0</version>
+  <description>Schema test device</description>
+  <cpu>
+    <name>CM4</name>
+    <revision>r0p1</revision>
+    <endian>little</endian>
+    <mpuPresent>true</mpuPresent>
+    <fpuPresent>true</fpuPresent>
+    <fpuDP>false</fpuDP>
+    <nvicPrioBits>4</nvicPrioBits>
+    <vendorSystickConfig>false</vendorSystickConfig>
+  </cpu>
+  <addressUnitBits>8</addressUnitBits>
+  <width>32</width>
+  <peripherals>
+    <peripheral>
+      <name>TIMER</name>
+      <baseAddress>0x40000000</baseAddress>
+      <registers>
+        <register>
+          <name>CTRL</name>
+          <description>Control</description>
+          <addressOffset>0x0</addressOffset>
+          <fields>
+            <field>
+              <name>EN</name>
+              <bitRange>[0:0]</bitRange>
+              <enumeratedValues>
+                <enumeratedValue>
+                  <name>on</name>
+                  <value>1</value>
+                </enumeratedValue>
+              </enumeratedValues>
+            </field>
+          </fields>
+        </register>
+      </registers>
+    </peripheral>
+  </peripherals>
+</device>
+`
+
+func TestValidateSchema(t *testing.T) {
+	valid := strings.Replace(schemaTestDocument, "%s", "1.3", 1)
+	tests := []struct {
+		name    string
+		doc     string
+		version string
+		want    []SchemaViolation
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			doc:  valid,
+		},
+		{
+			name: "version of the document",
+			doc:  strings.Replace(schemaTestDocument, "%s", "1.1", 1),
+			want: []SchemaViolation{
+				{Line: 12, Path: "/device/cpu/fpuDP", Message: "element is not defined before schema version 1.2"},
+			},
+		},
+		{
+			name:    "version given",
+			doc:     valid,
+			version: "1.2",
+		},
+		{
+			name: "out of order",
+			doc:  strings.Replace(valid, "<version>1.0</version>\n  <description>Schema test device</description>", "<description>Schema test device</description>\n  <version>1.0</version>", 1),
+			want: []SchemaViolation{
+				{Line: 4, Path: "/device/description", Message: "unexpected element, expected <version>"},
+			},
+		},
+		{
+			name: "missing element",
+			doc:  strings.Replace(valid, "          <addressOffset>0x0</addressOffset>\n", "", 1),
+			want: []SchemaViolation{
+				{Line: 26, Path: "/device/peripherals/peripheral/registers/register/fields", Message: "unexpected element, expected <addressOffset>"},
+			},
+		},
+		{
+			name: "invalid values",
+			doc:  strings.Replace(strings.Replace(valid, "[0:0]", "0:0", 1), "<value>1</value>", "<value>one</value>", 1),
+			want: []SchemaViolation{
+				{Line: 30, Path: "/device/peripherals/peripheral/registers/register/fields/field/bitRange", Message: `invalid bitRangeType "0:0"`},
+				{Line: 34, Path: "/device/peripherals/peripheral/registers/register/fields/field/enumeratedValues/enumeratedValue/value", Message: `invalid enumeratedValueDataType "one"`},
+			},
+		},
+		{
+			name: "unknown element and attribute",
+			doc:  strings.Replace(valid, "<register>\n          <name>CTRL</name>", "<register reset=\"0\">\n          <name>CTRL</name>\n          <color>red</color>", 1),
+			want: []SchemaViolation{
+				{Line: 23, Path: "/device/peripherals/peripheral/registers/register", Message: "unexpected attribute reset"},
+				{Line: 25, Path: "/device/peripherals/peripheral/registers/register/color", Message: "unexpected element, expected <addressOffset>"},
+			},
+		},
+		{
+			name:    "processor name of a later version",
+			doc:     strings.Replace(valid, "<name>CM4</name>", "<name>CM33</name>", 1),
+			version: "1.2",
+			want: []SchemaViolation{
+				{Line: 7, Path: "/device/cpu/name", Message: `cpuNameType "CM33" is not defined before schema version 1.3`},
+			},
+		},
+		{
+			name:    "unknown version",
+			doc:     valid,
+			version: "2.0",
+			wantErr: true,
+		},
+		{
+			name:    "not well-formed",
+			doc:     "<device>\n</devise>",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateSchema(strings.NewReader(tt.doc), tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSchema() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateSchema() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDevice_ValidateSchema(t *testing.T) {
+	dev := sauTestDevice()
+	dev.Version = "1.0"
+	dev.Description = "SAU test device"
+	dev.Cpu.Revision = "r0p0"
+	dev.Cpu.Endian = EndianLittle
+	dev.Cpu.NvicPrioBits = "3"
+	got, err := dev.ValidateSchema()
+	if err != nil || len(got) != 0 {
+		t.Errorf("Device.ValidateSchema() = %v, %v, want no violation", got, err)
+	}
+	dev.Cpu.NvicPrioBits = ""
+	got, err = dev.ValidateSchema()
+	want := []SchemaViolation{
+		{Line: 12, Path: "/device/cpu/nvicPrioBits", Message: `invalid scaledNonNegativeInteger ""`},
+	}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Device.ValidateSchema() = %v, %v, want %v", got, err, want)
+	}
+
+	// SVD refuses to write 1.3 elements as 1.1, validate the document relabeled
+	dev.Cpu.NvicPrioBits = "3"
+	data, err := dev.SVD()
+	if err != nil {
+		t.Fatalf("Device.SVD() error = %v", err)
+	}
+	data = bytes.Replace(data, []byte(`schemaVersion="`+dev.SchemaVersion+`"`), []byte(`schemaVersion="1.1"`), 1)
+	got, err = ValidateSchema(bytes.NewReader(data), "")
+	want = []SchemaViolation{
+		{Line: 7, Path: "/device/cpu/name", Message: `cpuNameType "CM33" is not defined before schema version 1.3`},
+		{Line: 14, Path: "/device/cpu/sauNumRegions", Message: "element is not defined before schema version 1.3"},
+		{Line: 38, Path: "/device/peripherals/peripheral/protection", Message: "element is not defined before schema version 1.3"},
+		{Line: 48, Path: "/device/peripherals/peripheral/protection", Message: "element is not defined before schema version 1.3"},
+	}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateSchema() as 1.1 = %v, %v, want %v", got, err, want)
+	}
+}
+
+func TestDevice_ValidateSchema_decoded(t *testing.T) {
+	data, err := ioutil.ReadFile("exemple.svd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dev Device
+	if err := xml.Unmarshal(data, &dev); err != nil {
+		t.Fatal(err)
+	}
+	flat, err := dev.Flatten()
+	if err != nil {
+		t.Fatalf("Device.Flatten() error = %v", err)
+	}
+	got, err := flat.ValidateSchema()
+	if err != nil || len(got) != 0 {
+		t.Errorf("Device.ValidateSchema() = %v, %v, want no violation", got, err)
+	}
+}
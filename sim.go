@@ -0,0 +1,217 @@
+package svd
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SimRegister : a simulated register
+type SimRegister struct {
+	ResolvedRegister
+
+	// Current content of the register.
+	Value uint64
+
+	// Called before a read access samples Value.
+	// Use it to model status bits updated by the hardware.
+	OnRead func(r *SimRegister)
+
+	// Called once a write access has been applied to Value,
+	// with the value written by the bus.
+	// Use it to model commands triggered by the software.
+	OnWrite func(r *SimRegister, written uint64)
+
+	// Bits of writeOnce fields already written since reset.
+	once uint64
+}
+
+// Simulator : an in-memory register file honouring the SVD access semantics
+// Reads and writes go through the effective <access>,
+// <modifiedWriteValues> and <readAction> of each field.
+type Simulator struct {
+	// Byte order used for accesses narrower than a register.
+	Endian EndianType
+
+	// Simulated registers sorted by address.
+	Registers []*SimRegister
+
+	byName map[string]*SimRegister
+}
+
+// NewSimulator : Create a Simulator of the device registers at RESET
+func NewSimulator(dev *Device) (*Simulator, error) {
+	periphs, err := dev.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	sim := Simulator{
+		Endian: dev.Cpu.Endian,
+		byName: make(map[string]*SimRegister),
+	}
+	for _, p := range periphs {
+		for _, r := range p.Registers {
+			reg := &SimRegister{ResolvedRegister: r}
+			sim.Registers = append(sim.Registers, reg)
+			sim.byName[r.Path()] = reg
+		}
+	}
+	sort.SliceStable(sim.Registers, func(i, j int) bool {
+		return sim.Registers[i].Address < sim.Registers[j].Address
+	})
+	sim.Reset()
+	return &sim, nil
+}
+
+// Reset : Set every register to its reset value
+func (sim *Simulator) Reset() {
+	for _, r := range sim.Registers {
+		r.Value = r.ResetValue & r.ResetMask
+		r.once = 0
+	}
+}
+
+// Register : Get a register by its "peripheral.register" name, nil if not found
+func (sim *Simulator) Register(path string) *SimRegister {
+	return sim.byName[path]
+}
+
+// registersAt : Get the registers covering an access, with the bit shift of
+// the access inside them
+// Alternate registers sharing an address are all returned.
+func (sim *Simulator) registersAt(address uint64, width uint) (regs []*SimRegister, shifts []uint) {
+	bytes := uint64(width / 8)
+	for _, r := range sim.Registers {
+		size := uint64(r.Size / 8)
+		if address < r.Address || address+bytes > r.Address+size {
+			continue
+		}
+		offset := address - r.Address
+		if sim.Endian == EndianBig {
+			offset = size - bytes - offset
+		}
+		regs = append(regs, r)
+		shifts = append(shifts, uint(offset*8))
+	}
+	return
+}
+
+// Read : Read width bits at an address
+func (sim *Simulator) Read(address uint64, width uint) (uint64, error) {
+	regs, shifts := sim.registersAt(address, width)
+	for i, r := range regs {
+		if r.Access == AccessWriteOnly || r.Access == AccessWriteOnce {
+			continue
+		}
+		return r.read(bitMask(width)<<shifts[i]) >> shifts[i], nil
+	}
+	if len(regs) > 0 {
+		return 0, nil
+	}
+	return 0, fmt.Errorf("no register at 0x%08X", address)
+}
+
+// Write : Write width bits at an address
+func (sim *Simulator) Write(address uint64, width uint, value uint64) error {
+	regs, shifts := sim.registersAt(address, width)
+	if len(regs) == 0 {
+		return fmt.Errorf("no register at 0x%08X", address)
+	}
+	for i, r := range regs {
+		r.write(value<<shifts[i], bitMask(width)<<shifts[i])
+	}
+	return nil
+}
+
+// read : Read the bits of mask, applying the read side effects
+func (r *SimRegister) read(mask uint64) uint64 {
+	if r.OnRead != nil {
+		r.OnRead(r)
+	}
+	value := r.Value
+	for _, f := range r.bitFields() {
+		if f.Mask()&mask == 0 {
+			continue
+		}
+		if f.Access == AccessWriteOnly || f.Access == AccessWriteOnce {
+			value &^= f.Mask()
+		}
+		switch f.ReadAction {
+		case ReadActionClear:
+			r.Value &^= f.Mask()
+		case ReadActionSet:
+			r.Value |= f.Mask()
+		}
+	}
+	return value & mask
+}
+
+// write : Write the bits of mask, applying the modified write values
+func (r *SimRegister) write(value, mask uint64) {
+	written := value&mask | r.Value&^mask
+	for _, f := range r.bitFields() {
+		if f.Mask()&mask == 0 || f.Access == AccessReadOnly {
+			continue
+		}
+		if f.Access == AccessWriteOnce || f.Access == AccessReadWriteOnce {
+			if r.once&f.Mask() != 0 {
+				continue
+			}
+			r.once |= f.Mask()
+		}
+		r.Value = f.writeBits(r.Value, written, mask)
+	}
+	if r.OnWrite != nil {
+		r.OnWrite(r, value&mask)
+	}
+}
+
+// modifiedWrite : Get the value of the field once w is written over old
+func (f ResolvedField) modifiedWrite(old, w uint64) uint64 {
+	all := bitMask(f.Width())
+	switch f.ModifiedWriteValues {
+	case ModifiedWriteValuesOneToClear:
+		return old &^ w
+	case ModifiedWriteValuesOneToSet:
+		return old | w
+	case ModifiedWriteValuesOneToToggle:
+		return old ^ w
+	case ModifiedWriteValuesZeroToClear:
+		return old & w
+	case ModifiedWriteValuesZeroToSet:
+		return old | (^w & all)
+	case ModifiedWriteValuesZeroToToggle:
+		return old ^ (^w & all)
+	case ModifiedWriteValuesClear:
+		return 0
+	case ModifiedWriteValuesSet:
+		return all
+	}
+	return w
+}
+
+// writeBits : Get the register value once the field is written
+// Only the bits of the field within the access mask take the modified value.
+func (f ResolvedField) writeBits(current, written, mask uint64) uint64 {
+	bits := f.Mask() & mask
+	return current&^bits | f.Insert(current, f.modifiedWrite(f.Extract(current), f.Extract(written)))&bits
+}
+
+// FieldValue : Get the value of a field without side effect
+func (r *SimRegister) FieldValue(name string) (uint64, error) {
+	f := r.Field(name)
+	if f == nil {
+		return 0, fmt.Errorf("register %s has no field %s", r.Path(), name)
+	}
+	return f.Extract(r.Value), nil
+}
+
+// SetFieldValue : Set the value of a field from the hardware side
+// Access rights and write semantics are not applied.
+func (r *SimRegister) SetFieldValue(name string, value uint64) error {
+	f := r.Field(name)
+	if f == nil {
+		return fmt.Errorf("register %s has no field %s", r.Path(), name)
+	}
+	r.Value = f.Insert(r.Value, value)
+	return nil
+}
@@ -0,0 +1,165 @@
+package svd
+
+import (
+	"testing"
+)
+
+func simTestDevice() *Device {
+	ro := AccessReadOnly
+	w1c := ModifiedWriteValuesOneToClear
+	rc := ReadActionClear
+	dev := NewDevice("SimDevice")
+	dev.Peripherals.Peripheral = []Peripheral{
+		{
+			Name:        "UART0",
+			BaseAddress: "0x40000000",
+			Registers: &Registers{Register: []Register{
+				{
+					Name:          "CR",
+					AddressOffset: "0x0",
+					ResetValue:    "0x00000300",
+					Fields: &Fields{Field: []Field{
+						{Name: "UARTEN", BitRange: "[0:0]"},
+						{Name: "TXE", BitRange: "[8:8]"},
+						{Name: "RXE", BitRange: "[9:9]"},
+					}},
+				},
+				{
+					Name:          "SR",
+					AddressOffset: "0x4",
+					ResetValue:    "0x00000001",
+					Fields: &Fields{Field: []Field{
+						{Name: "TXEMPTY", BitRange: "[0:0]", Access: &ro},
+						{Name: "OVR", BitRange: "[1:1]", ModifiedWriteValues: &w1c},
+					}},
+				},
+				{
+					Name:          "DR",
+					AddressOffset: "0x8",
+					ReadAction:    ReadActionClear,
+				},
+				{
+					Name:          "LOCK",
+					AddressOffset: "0xC",
+					Access:        AccessReadWriteOnce,
+				},
+				{
+					Name:          "EVT",
+					AddressOffset: "0x10",
+					Fields: &Fields{Field: []Field{
+						{Name: "FLAG", BitOffset: "4", BitWidth: "4", ReadAction: &rc},
+					}},
+				},
+			}},
+		},
+	}
+	return dev
+}
+
+func TestSimulator(t *testing.T) {
+	sim, err := NewSimulator(simTestDevice())
+	if err != nil {
+		t.Fatalf("NewSimulator() error = %v", err)
+	}
+	sr := sim.Register("UART0.SR")
+	steps := []struct {
+		name    string
+		hook    func()
+		write   bool
+		address uint64
+		width   uint
+		value   uint64
+		want    uint64
+	}{
+		{name: "reset value", address: 0x40000000, width: 32, want: 0x300},
+		{name: "write control", write: true, address: 0x40000000, width: 32, value: 0x1},
+		{name: "read control", address: 0x40000000, width: 32, want: 0x1},
+		{name: "byte write", write: true, address: 0x40000001, width: 8, value: 0x3},
+		{name: "byte read", address: 0x40000001, width: 8, want: 0x3},
+		{name: "overrun set by hardware", hook: func() { sr.SetFieldValue("OVR", 1) }},
+		{name: "status overrun", address: 0x40000004, width: 32, want: 0x3},
+		{name: "write one to clear", write: true, address: 0x40000004, width: 32, value: 0x2},
+		{name: "overrun cleared, read only bit kept", address: 0x40000004, width: 32, want: 0x1},
+		{name: "data write", write: true, address: 0x40000008, width: 32, value: 0x55},
+		{name: "data read", address: 0x40000008, width: 32, want: 0x55},
+		{name: "data cleared on read", address: 0x40000008, width: 32, want: 0},
+		{name: "lock first write", write: true, address: 0x4000000C, width: 32, value: 0xA5},
+		{name: "lock second write", write: true, address: 0x4000000C, width: 32, value: 0x5A},
+		{name: "lock read", address: 0x4000000C, width: 32, want: 0xA5},
+		{name: "event write", write: true, address: 0x40000010, width: 32, value: 0xFF},
+		{name: "event read", address: 0x40000010, width: 32, want: 0xF0},
+		{name: "event cleared", address: 0x40000010, width: 32, want: 0},
+	}
+	for _, s := range steps {
+		if s.hook != nil {
+			s.hook()
+			continue
+		}
+		if s.write {
+			if err := sim.Write(s.address, s.width, s.value); err != nil {
+				t.Errorf("%s: Simulator.Write() error = %v", s.name, err)
+			}
+			continue
+		}
+		got, err := sim.Read(s.address, s.width)
+		if err != nil {
+			t.Errorf("%s: Simulator.Read() error = %v", s.name, err)
+		}
+		if got != s.want {
+			t.Errorf("%s: Simulator.Read() = 0x%X, want 0x%X", s.name, got, s.want)
+		}
+	}
+	sr.OnRead = func(r *SimRegister) {
+		r.SetFieldValue("OVR", 1)
+	}
+	if got, _ := sim.Read(0x40000004, 32); got != 0x3 {
+		t.Errorf("status set by OnRead hook = 0x%X, want 0x3", got)
+	}
+	sr.OnRead = nil
+	if _, err := sim.Read(0x40000100, 32); err == nil {
+		t.Errorf("Simulator.Read() of unmapped address succeeded")
+	}
+	sim.Reset()
+	if got, _ := sim.Register("UART0.CR").FieldValue("RXE"); got != 1 {
+		t.Errorf("after Reset(), CR.RXE = %d, want 1", got)
+	}
+	if err := sim.Write(0x4000000C, 32, 0x5A); err != nil || sim.Register("UART0.LOCK").Value != 0x5A {
+		t.Errorf("after Reset(), LOCK write was not applied")
+	}
+}
+
+func TestSimulator_narrowWrite(t *testing.T) {
+	dev := simTestDevice()
+	regs := &dev.Peripherals.Peripheral[0].Registers.Register
+	*regs = append(*regs,
+		Register{Name: "IFR", AddressOffset: "0x14", ModifiedWriteValues: ModifiedWriteValuesOneToClear},
+		Register{Name: "TGL", AddressOffset: "0x18", ModifiedWriteValues: ModifiedWriteValuesOneToToggle},
+	)
+	sim, err := NewSimulator(dev)
+	if err != nil {
+		t.Fatalf("NewSimulator() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		register string
+		address  uint64
+		value    uint64
+		want     uint64
+	}{
+		{"write one to clear, byte of zeros", "UART0.IFR", 0x40000014, 0x00, 0xFF00FF00},
+		{"write one to clear, byte of ones", "UART0.IFR", 0x40000015, 0x0F, 0xFF00F000},
+		{"write one to toggle, byte", "UART0.TGL", 0x40000018, 0x01, 0xFF00FF01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := sim.Register(tt.register)
+			r.Value = 0xFF00FF00
+			if err := sim.Write(tt.address, 8, tt.value); err != nil {
+				t.Fatalf("Simulator.Write() error = %v", err)
+			}
+			if r.Value != tt.want {
+				t.Errorf("after Simulator.Write(), %s = 0x%08X, want 0x%08X", r.Name, r.Value, tt.want)
+			}
+		})
+	}
+}
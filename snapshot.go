@@ -0,0 +1,226 @@
+package svd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MemoryRegion : a range of memory dumped from the target
+type MemoryRegion struct {
+	// Address of the first byte.
+	Base uint64
+
+	// Dumped content.
+	Data []byte
+}
+
+// word : Get the value at an address, false if not covered by the region
+func (m MemoryRegion) word(address uint64, size uint, endian EndianType) (uint64, bool) {
+	n := uint64(size / 8)
+	if address < m.Base || address+n > m.Base+uint64(len(m.Data)) {
+		return 0, false
+	}
+	data := m.Data[address-m.Base : address-m.Base+n]
+	var value uint64
+	for i := uint64(0); i < n; i++ {
+		b := data[i]
+		if endian == EndianBig {
+			b = data[n-1-i]
+		}
+		value |= uint64(b) << (8 * i)
+	}
+	return value, true
+}
+
+// SnapshotField : a field decoded from a memory snapshot
+type SnapshotField struct {
+	Name  string `json:"name"`
+	Lsb   uint   `json:"lsb"`
+	Msb   uint   `json:"msb"`
+	Value uint64 `json:"value"`
+
+	// Name of the enumerated value matching Value, if any.
+	Enum string `json:"enum,omitempty"`
+
+	// Set when the value differs from the defined reset value.
+	Modified bool `json:"modified,omitempty"`
+}
+
+// SnapshotRegister : a register decoded from a memory snapshot
+type SnapshotRegister struct {
+	// "peripheral.register" name.
+	Register string `json:"register"`
+
+	Address    uint64 `json:"address"`
+	Size       uint   `json:"size"`
+	Value      uint64 `json:"value"`
+	ResetValue uint64 `json:"resetValue"`
+	ResetMask  uint64 `json:"resetMask"`
+
+	// Set when a bit with a defined reset value differs from it.
+	Modified bool `json:"modified,omitempty"`
+
+	// Set when the register is not decoded, with the reason.
+	Skipped string `json:"skipped,omitempty"`
+
+	Fields []SnapshotField `json:"fields,omitempty"`
+}
+
+// SnapshotReport : the state of the peripherals found in a memory snapshot
+type SnapshotReport struct {
+	Device    string             `json:"device"`
+	Registers []SnapshotRegister `json:"registers"`
+}
+
+// DecodeSnapshot : Decode the registers covered by memory regions
+// Values are read in the byte order of <cpu><endian>.
+// Registers with a <readAction> are reported as skipped: reading them
+// has side effects, so the dumped value does not reflect their state.
+// Write-only registers are skipped too and write-only fields are left
+// out, as the value read back from them is meaningless.
+func (dev *Device) DecodeSnapshot(regions []MemoryRegion) (*SnapshotReport, error) {
+	periphs, err := dev.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	rep := SnapshotReport{Device: dev.Name}
+	for _, p := range periphs {
+		for _, r := range p.Registers {
+			var value uint64
+			covered := false
+			for _, m := range regions {
+				if value, covered = m.word(r.Address, r.Size, dev.Cpu.Endian); covered {
+					break
+				}
+			}
+			if !covered {
+				continue
+			}
+			sr := SnapshotRegister{
+				Register:   r.Path(),
+				Address:    r.Address,
+				Size:       r.Size,
+				Value:      value,
+				ResetValue: r.ResetValue & r.ResetMask,
+				ResetMask:  r.ResetMask,
+			}
+			for _, f := range r.Fields {
+				if f.ReadAction != "" {
+					sr.Skipped = fmt.Sprintf("field %s has readAction %s", f.Name, f.ReadAction)
+				}
+			}
+			if r.ReadAction != "" {
+				sr.Skipped = fmt.Sprintf("readAction %s", r.ReadAction)
+			}
+			if r.Access == AccessWriteOnly || r.Access == AccessWriteOnce {
+				sr.Skipped = fmt.Sprintf("access %s", r.Access)
+			}
+			if sr.Skipped != "" {
+				sr.Value = 0
+				rep.Registers = append(rep.Registers, sr)
+				continue
+			}
+			readable := r.Mask()
+			for _, f := range r.Fields {
+				if f.Access == AccessWriteOnly || f.Access == AccessWriteOnce {
+					readable &^= f.Mask()
+				}
+			}
+			sr.Modified = (value^r.ResetValue)&r.ResetMask&readable != 0
+			for _, f := range r.Fields {
+				if f.Access == AccessWriteOnly || f.Access == AccessWriteOnce {
+					continue
+				}
+				sf := SnapshotField{
+					Name:     f.Name,
+					Lsb:      f.Lsb,
+					Msb:      f.Msb,
+					Value:    f.Extract(value),
+					Modified: (value^r.ResetValue)&r.ResetMask&f.Mask() != 0,
+				}
+				if e := f.Enum(sf.Value); e != nil {
+					sf.Enum = e.Name
+				}
+				sr.Fields = append(sr.Fields, sf)
+			}
+			rep.Registers = append(rep.Registers, sr)
+		}
+	}
+	return &rep, nil
+}
+
+// bits : Format the bit range of a field
+func (f SnapshotField) bits() string {
+	if f.Lsb == f.Msb {
+		return fmt.Sprintf("[%d]", f.Lsb)
+	}
+	return fmt.Sprintf("[%d:%d]", f.Msb, f.Lsb)
+}
+
+// Text : Generate the report as plain text
+func (rep SnapshotReport) Text() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s peripheral state\n", rep.Device)
+	for _, r := range rep.Registers {
+		if r.Skipped != "" {
+			fmt.Fprintf(&b, "0x%08X %-24s skipped (%s)\n", r.Address, r.Register, r.Skipped)
+			continue
+		}
+		mark := ""
+		if r.Modified {
+			mark = fmt.Sprintf(" (reset %s)", FormatHex(r.ResetValue, r.Size))
+		}
+		fmt.Fprintf(&b, "0x%08X %-24s %s%s\n", r.Address, r.Register, FormatHex(r.Value, r.Size), mark)
+		for _, f := range r.Fields {
+			fmt.Fprintf(&b, "    %-8s %-16s 0x%X", f.bits(), f.Name, f.Value)
+			if f.Enum != "" {
+				fmt.Fprintf(&b, " %s", f.Enum)
+			}
+			if f.Modified {
+				b.WriteString(" *")
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.Bytes()
+}
+
+// Markdown : Generate the report as a Markdown document
+func (rep SnapshotReport) Markdown() []byte {
+	var b bytes.Buffer
+	esc := strings.NewReplacer("|", "\\|", "\n", " ")
+	fmt.Fprintf(&b, "# %s peripheral state\n\n", rep.Device)
+	b.WriteString("| Address | Register | Value | Reset | Fields |\n")
+	b.WriteString("|---|---|---|---|---|\n")
+	for _, r := range rep.Registers {
+		if r.Skipped != "" {
+			fmt.Fprintf(&b, "| `0x%08X` | %s | *skipped* | | %s |\n", r.Address, r.Register, esc.Replace(r.Skipped))
+			continue
+		}
+		value := "`" + FormatHex(r.Value, r.Size) + "`"
+		if r.Modified {
+			value = "**" + value + "**"
+		}
+		var fields []string
+		for _, f := range r.Fields {
+			s := fmt.Sprintf("%s%s=0x%X", f.Name, f.bits(), f.Value)
+			if f.Enum != "" {
+				s += " (" + f.Enum + ")"
+			}
+			if f.Modified {
+				s = "**" + s + "**"
+			}
+			fields = append(fields, esc.Replace(s))
+		}
+		fmt.Fprintf(&b, "| `0x%08X` | %s | %s | `%s` | %s |\n", r.Address, r.Register, value,
+			FormatHex(r.ResetValue, r.Size), strings.Join(fields, "<br>"))
+	}
+	return b.Bytes()
+}
+
+// JSON : Generate the report as JSON
+func (rep SnapshotReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(rep, "", "  ")
+}
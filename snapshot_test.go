@@ -0,0 +1,147 @@
+package svd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func snapshotTestDevice() *Device {
+	wo := AccessWriteOnly
+	ro := AccessReadOnly
+	dev := NewDevice("SnapDevice")
+	dev.Cpu.Endian = EndianLittle
+	dev.Peripherals.Peripheral = []Peripheral{
+		{
+			Name:        "TIM",
+			BaseAddress: "0x40000000",
+			Registers: &Registers{Register: []Register{
+				{
+					Name:          "CTRL",
+					AddressOffset: "0x0",
+					Fields: &Fields{Field: []Field{
+						{Name: "EN", BitRange: "[0:0]"},
+						{Name: "MODE", BitRange: "[2:1]", EnumeratedValues: &EnumeratedValues{EnumeratedValue: []EnumeratedValue{
+							{Name: "slow", Value: "0"},
+							{Name: "fast", Value: "1"},
+						}}},
+					}},
+				},
+				{Name: "KEY", AddressOffset: "0x4", Access: AccessWriteOnly},
+				{
+					Name:          "CMD",
+					AddressOffset: "0x8",
+					Fields: &Fields{Field: []Field{
+						{Name: "START", BitRange: "[0:0]", Access: &wo},
+						{Name: "BUSY", BitRange: "[1:1]", Access: &ro},
+					}},
+				},
+				{Name: "DATA", AddressOffset: "0xC", ReadAction: ReadActionClear},
+				{Name: "IDLE", AddressOffset: "0x20"},
+			}},
+		},
+	}
+	return dev
+}
+
+func snapshotTestReport(t *testing.T) *SnapshotReport {
+	regions := []MemoryRegion{{Base: 0x40000000, Data: []byte{
+		0x03, 0x00, 0x00, 0x00,
+		0xEF, 0xBE, 0xAD, 0xDE,
+		0x01, 0x00, 0x00, 0x00,
+		0x55, 0x00, 0x00, 0x00,
+	}}}
+	rep, err := snapshotTestDevice().DecodeSnapshot(regions)
+	if err != nil {
+		t.Fatalf("Device.DecodeSnapshot() error = %v", err)
+	}
+	return rep
+}
+
+func TestDevice_DecodeSnapshot(t *testing.T) {
+	want := &SnapshotReport{
+		Device: "SnapDevice",
+		Registers: []SnapshotRegister{
+			{
+				Register: "TIM.CTRL", Address: 0x40000000, Size: 32, Value: 3, ResetMask: 0xFFFFFFFF, Modified: true,
+				Fields: []SnapshotField{
+					{Name: "EN", Lsb: 0, Msb: 0, Value: 1, Modified: true},
+					{Name: "MODE", Lsb: 1, Msb: 2, Value: 1, Enum: "fast", Modified: true},
+				},
+			},
+			{Register: "TIM.KEY", Address: 0x40000004, Size: 32, ResetMask: 0xFFFFFFFF, Skipped: "access write-only"},
+			{
+				Register: "TIM.CMD", Address: 0x40000008, Size: 32, Value: 1, ResetMask: 0xFFFFFFFF,
+				Fields: []SnapshotField{
+					{Name: "BUSY", Lsb: 1, Msb: 1, Value: 0},
+				},
+			},
+			{Register: "TIM.DATA", Address: 0x4000000C, Size: 32, ResetMask: 0xFFFFFFFF, Skipped: "readAction clear"},
+		},
+	}
+	if got := snapshotTestReport(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("Device.DecodeSnapshot() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSnapshotReport_renderers(t *testing.T) {
+	rep := snapshotTestReport(t)
+	tests := []struct {
+		name   string
+		render func() ([]byte, error)
+		want   string
+	}{
+		{
+			name:   "Text",
+			render: func() ([]byte, error) { return rep.Text(), nil },
+			want: `SnapDevice peripheral state
+0x40000000 TIM.CTRL                 0x00000003 (reset 0x00000000)
+    [0]      EN               0x1 *
+    [2:1]    MODE             0x1 fast *
+0x40000004 TIM.KEY                  skipped (access write-only)
+0x40000008 TIM.CMD                  0x00000001
+    [1]      BUSY             0x0
+0x4000000C TIM.DATA                 skipped (readAction clear)
+`,
+		},
+		{
+			name:   "Markdown",
+			render: func() ([]byte, error) { return rep.Markdown(), nil },
+			want: "# SnapDevice peripheral state\n\n" +
+				"| Address | Register | Value | Reset | Fields |\n" +
+				"|---|---|---|---|---|\n" +
+				"| `0x40000000` | TIM.CTRL | **`0x00000003`** | `0x00000000` | **EN[0]=0x1**<br>**MODE[2:1]=0x1 (fast)** |\n" +
+				"| `0x40000004` | TIM.KEY | *skipped* | | access write-only |\n" +
+				"| `0x40000008` | TIM.CMD | `0x00000001` | `0x00000000` | BUSY[1]=0x0 |\n" +
+				"| `0x4000000C` | TIM.DATA | *skipped* | | readAction clear |\n",
+		},
+		{
+			name:   "JSON",
+			render: (&SnapshotReport{Device: "D", Registers: rep.Registers[1:2]}).JSON,
+			want: `{
+  "device": "D",
+  "registers": [
+    {
+      "register": "TIM.KEY",
+      "address": 1073741828,
+      "size": 32,
+      "value": 0,
+      "resetValue": 0,
+      "resetMask": 4294967295,
+      "skipped": "access write-only"
+    }
+  ]
+}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.render()
+			if err != nil {
+				t.Fatalf("%s() error = %v", tt.name, err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("%s() = %s, want %s", tt.name, got, tt.want)
+			}
+		})
+	}
+}
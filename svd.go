@@ -128,7 +128,7 @@ type Device struct {
 // NewDevice : Create a Device
 func NewDevice(name string) *Device {
 	dev := Device{
-		SchemaVersion:             "1.3.6",
+		SchemaVersion:             DefaultSchemaVersion,
 		Xs:                        "http://www.w3.org/2001/XMLSchema-instance",
 		NoNamespaceSchemaLocation: "CMSIS-SVD.xsd",
 		Name:                      name,
@@ -143,7 +143,12 @@ func NewDevice(name string) *Device {
 }
 
 // SVD : Generate the device SVD
+// Elements which the schema version of the device does not define are
+// rejected; see Downgrade.
 func (dev Device) SVD() (svd []byte, err error) {
+	if err := dev.checkSchemaVersion(); err != nil {
+		return nil, err
+	}
 	svd, err = xml.MarshalIndent(dev, "", "  ")
 	return append([]byte(xml.Header), svd...), err
 }
@@ -0,0 +1,1982 @@
+package svd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rdlKind : kind of a SystemRDL token
+type rdlKind int
+
+const (
+	rdlEOF rdlKind = iota
+	rdlIdent
+	rdlNumber
+	rdlString
+	rdlPunct
+)
+
+// rdlToken : a SystemRDL token
+type rdlToken struct {
+	kind rdlKind
+	text string
+	line int
+
+	// Identifier written \name, never a keyword.
+	escaped bool
+}
+
+// rdlPuncts : punctuation tokens, longest first
+var rdlPuncts = []string{
+	"->", "+=", "%=", "::", "<<", ">>", "==", "!=", "<=", ">=", "&&", "||", "**",
+	"{", "}", "[", "]", "(", ")", ";", ",", "=", "@", "#", ".", ":", "?",
+	"+", "-", "*", "/", "%", "&", "|", "^", "~", "!", "<", ">",
+}
+
+// isRDLWord : Tell if a character can be part of an identifier or number
+func isRDLWord(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// lexSystemRDL : Split a SystemRDL description into tokens
+func lexSystemRDL(src string) ([]rdlToken, error) {
+	var toks []rdlToken
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"':
+			var b strings.Builder
+			start := line
+			for i++; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				if src[i] == '\n' {
+					line++
+				}
+				b.WriteByte(src[i])
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("line %d: unterminated string", start)
+			}
+			i++
+			toks = append(toks, rdlToken{kind: rdlString, text: b.String(), line: start})
+		case c == '`':
+			return nil, fmt.Errorf("line %d: preprocessor directives are not supported", line)
+		case c == '\\' && i+1 < len(src) && isRDLWord(src[i+1]):
+			j := i + 1
+			for j < len(src) && isRDLWord(src[j]) {
+				j++
+			}
+			toks = append(toks, rdlToken{kind: rdlIdent, text: src[i+1 : j], line: line, escaped: true})
+			i = j
+		case c >= '0' && c <= '9' || c == '\'':
+			j := i
+			for j < len(src) && (isRDLWord(src[j]) || src[j] == '\'') {
+				j++
+			}
+			toks = append(toks, rdlToken{kind: rdlNumber, text: src[i:j], line: line})
+			i = j
+		case isRDLWord(c):
+			j := i
+			for j < len(src) && isRDLWord(src[j]) {
+				j++
+			}
+			toks = append(toks, rdlToken{kind: rdlIdent, text: src[i:j], line: line})
+			i = j
+		default:
+			found := false
+			for _, p := range rdlPuncts {
+				if strings.HasPrefix(src[i:], p) {
+					toks = append(toks, rdlToken{kind: rdlPunct, text: p, line: line})
+					i += len(p)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+			}
+		}
+	}
+	return append(toks, rdlToken{kind: rdlEOF, line: line}), nil
+}
+
+// parseRDLNumber : Parse a SystemRDL number
+// Numbers are decimal, hexadecimal (0x...) or Verilog-style with an
+// optional width (e.g. 4'b1010, 32'hDEAD_BEEF, 'd10).
+func parseRDLNumber(s string) (uint64, error) {
+	t := strings.ReplaceAll(s, "_", "")
+	i := strings.IndexByte(t, '\'')
+	if i < 0 {
+		base, digits := 10, t
+		if strings.HasPrefix(t, "0x") || strings.HasPrefix(t, "0X") {
+			base, digits = 16, t[2:]
+		}
+		n, err := strconv.ParseUint(digits, base, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", s)
+		}
+		return n, nil
+	}
+	digits := t[i+1:]
+	if digits == "" {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	base := map[byte]int{'b': 2, 'o': 8, 'd': 10, 'h': 16}[digits[0]|0x20]
+	n, err := strconv.ParseUint(digits[1:], base, 64)
+	if base == 0 || err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	if i > 0 {
+		width, err := strconv.ParseUint(t[:i], 10, 64)
+		if err != nil || width == 0 {
+			return 0, fmt.Errorf("invalid number %q", s)
+		}
+		if width < 64 && n>>width != 0 {
+			return 0, fmt.Errorf("number %q does not fit in %d bits", s, width)
+		}
+	}
+	return n, nil
+}
+
+// rdlExpr : an expression of a property value, a parameter or an instance
+type rdlExpr struct {
+	// Operator, "" for a literal or an identifier, "::" for an enum entry,
+	// "?" for the conditional operator and "u-", "u~"... for unary ones.
+	op string
+
+	// Literal, identifier, enum name or operator token.
+	tok rdlToken
+
+	// Entry name of an enum reference.
+	member string
+
+	// Operands.
+	args []*rdlExpr
+}
+
+// rdlParam : a parameter declaration, or a parameter value of an instance
+type rdlParam struct {
+	name  string
+	value *rdlExpr
+}
+
+// rdlInstance : an instance of a component
+type rdlInstance struct {
+	name string
+	line int
+
+	// Array dimensions, or bit range of a field: each [] holds one
+	// expression, or two for a [msb:lsb] range.
+	bounds [][]*rdlExpr
+
+	// Reset value of a field, address, array stride and alignment.
+	reset, at, stride, align *rdlExpr
+}
+
+// rdlStmt : a statement of a component body
+// A statement is either a property assignment or an instantiation.
+type rdlStmt struct {
+	line int
+
+	// Property assignment "prop = value;", "default prop = value;" or
+	// "inst.inst->prop = value;", value is nil for "prop;".
+	prop      string
+	value     *rdlExpr
+	isDefault bool
+	target    []string
+
+	// Instantiation of an inline definition or of a named type.
+	comp      *rdlComponent
+	typeName  string
+	args      []rdlParam
+	instances []rdlInstance
+}
+
+// rdlComponent : a component definition (addrmap, regfile, reg or field)
+type rdlComponent struct {
+	kind   string
+	name   string
+	line   int
+	params []rdlParam
+	body   []rdlStmt
+
+	// Scope of the definitions made in the body.
+	scope *rdlScope
+}
+
+// rdlEnumEntry : an entry of an enum definition
+type rdlEnumEntry struct {
+	name  string
+	value *rdlExpr
+	props []rdlStmt
+}
+
+// rdlEnum : an enum definition
+type rdlEnum struct {
+	name    string
+	entries []rdlEnumEntry
+	scope   *rdlScope
+}
+
+// rdlScope : component and enum types defined in a body
+type rdlScope struct {
+	parent *rdlScope
+	types  map[string]*rdlComponent
+	enums  map[string]*rdlEnum
+}
+
+func newRDLScope(parent *rdlScope) *rdlScope {
+	return &rdlScope{parent: parent, types: make(map[string]*rdlComponent), enums: make(map[string]*rdlEnum)}
+}
+
+// lookupType : Get a component type visible from the scope, nil if none
+func (s *rdlScope) lookupType(name string) *rdlComponent {
+	for ; s != nil; s = s.parent {
+		if c, ok := s.types[name]; ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// lookupEnum : Get an enum type visible from the scope, nil if none
+func (s *rdlScope) lookupEnum(name string) *rdlEnum {
+	for ; s != nil; s = s.parent {
+		if e, ok := s.enums[name]; ok {
+			return e
+		}
+	}
+	return nil
+}
+
+// rdlParser : state of the parsing of a SystemRDL description
+// The first error is kept, the parser then only moves towards the end.
+type rdlParser struct {
+	toks  []rdlToken
+	pos   int
+	err   error
+	scope *rdlScope
+
+	// Named component definitions, in definition order.
+	components []*rdlComponent
+
+	// User-defined properties.
+	udps map[string]bool
+}
+
+func (ps *rdlParser) peek() rdlToken {
+	return ps.toks[ps.pos]
+}
+
+func (ps *rdlParser) next() rdlToken {
+	t := ps.toks[ps.pos]
+	if t.kind != rdlEOF && ps.err == nil {
+		ps.pos++
+	}
+	return t
+}
+
+// fail : Record an error at a token, unless one is already recorded
+func (ps *rdlParser) fail(t rdlToken, format string, a ...interface{}) {
+	if ps.err == nil {
+		ps.err = fmt.Errorf("line %d: %s", t.line, fmt.Sprintf(format, a...))
+	}
+}
+
+// accept : Consume the next token if it is the given punctuation
+func (ps *rdlParser) accept(p string) bool {
+	if t := ps.peek(); ps.err == nil && t.kind == rdlPunct && t.text == p {
+		ps.pos++
+		return true
+	}
+	return false
+}
+
+func (ps *rdlParser) expect(p string) {
+	if !ps.accept(p) {
+		t := ps.peek()
+		ps.fail(t, "expected %q, found %q", p, t.text)
+	}
+}
+
+func (ps *rdlParser) ident() string {
+	t := ps.next()
+	if t.kind != rdlIdent {
+		ps.fail(t, "expected an identifier, found %q", t.text)
+	}
+	return t.text
+}
+
+// parseBody : Parse statements up to the closing brace, or the end at root
+func (ps *rdlParser) parseBody(root bool) (body []rdlStmt) {
+	for ps.err == nil {
+		if t := ps.peek(); t.kind == rdlEOF {
+			if !root {
+				ps.fail(t, "missing \"}\"")
+			}
+			return
+		}
+		if !root && ps.accept("}") {
+			return
+		}
+		if st, ok := ps.parseStmt(); ok {
+			body = append(body, st)
+		}
+	}
+	return
+}
+
+// parseStmt : Parse a statement, ok is false for pure definitions
+func (ps *rdlParser) parseStmt() (st rdlStmt, ok bool) {
+	t := ps.peek()
+	st.line = t.line
+	if t.kind != rdlIdent {
+		ps.fail(t, "unexpected %q", t.text)
+		return
+	}
+	keyword := t.text
+	if t.escaped {
+		keyword = ""
+	}
+	switch keyword {
+	case "default":
+		ps.next()
+		st.isDefault = true
+		st.prop, st.value = ps.parseAssign()
+		return st, true
+	case "enum":
+		ps.next()
+		ps.parseEnum()
+		return
+	case "property":
+		ps.next()
+		ps.udps[ps.ident()] = true
+		ps.skipBlock()
+		ps.expect(";")
+		return
+	case "mem", "signal", "constraint", "struct", "alias":
+		ps.fail(t, "%s is not supported", t.text)
+		return
+	case "external", "internal":
+		ps.next()
+		return ps.parseInstantiation(st)
+	}
+	if n := ps.toks[ps.pos+1]; n.kind == rdlPunct {
+		switch n.text {
+		case "=", ";":
+			st.prop, st.value = ps.parseAssign()
+			return st, true
+		case "->", ".":
+			for st.target = []string{ps.ident()}; ps.accept("."); {
+				st.target = append(st.target, ps.ident())
+			}
+			ps.expect("->")
+			st.prop, st.value = ps.parseAssign()
+			return st, true
+		}
+	}
+	return ps.parseInstantiation(st)
+}
+
+// parseAssign : Parse "prop = value;" or "prop;"
+func (ps *rdlParser) parseAssign() (prop string, value *rdlExpr) {
+	prop = ps.ident()
+	if ps.accept("=") {
+		value = ps.parseExpr()
+	}
+	ps.expect(";")
+	return
+}
+
+// parseInstantiation : Parse a component definition and/or its instances
+func (ps *rdlParser) parseInstantiation(st rdlStmt) (rdlStmt, bool) {
+	switch t := ps.peek(); {
+	case !t.escaped && (t.text == "addrmap" || t.text == "regfile" || t.text == "reg" || t.text == "field"):
+		st.comp = ps.parseComponent()
+		if ps.accept(";") {
+			if st.comp.name == "" {
+				ps.fail(ps.peek(), "anonymous %s without instance", st.comp.kind)
+			}
+			return st, false
+		}
+	default:
+		st.typeName = ps.ident()
+		if ps.accept("#") {
+			ps.expect("(")
+			for ps.err == nil && !ps.accept(")") {
+				ps.expect(".")
+				p := rdlParam{name: ps.ident()}
+				ps.expect("(")
+				p.value = ps.parseExpr()
+				ps.expect(")")
+				st.args = append(st.args, p)
+				if !ps.accept(",") {
+					ps.expect(")")
+					break
+				}
+			}
+		}
+	}
+	for ps.err == nil {
+		st.instances = append(st.instances, ps.parseInstance())
+		if !ps.accept(",") {
+			break
+		}
+	}
+	ps.expect(";")
+	return st, ps.err == nil
+}
+
+// parseComponent : Parse a component definition
+func (ps *rdlParser) parseComponent() *rdlComponent {
+	t := ps.next()
+	comp := &rdlComponent{kind: t.text, line: t.line}
+	if ps.peek().kind == rdlIdent {
+		comp.name = ps.next().text
+	}
+	if ps.accept("#") {
+		ps.expect("(")
+		for ps.err == nil && !ps.accept(")") {
+			// the name is the last word of the declaration, after its type
+			var p rdlParam
+			for ps.err == nil && ps.peek().kind == rdlIdent {
+				p.name = ps.next().text
+			}
+			if ps.accept("[") {
+				ps.expect("]")
+			}
+			if p.name == "" {
+				ps.fail(ps.peek(), "expected a parameter name")
+			}
+			if ps.accept("=") {
+				p.value = ps.parseExpr()
+			}
+			comp.params = append(comp.params, p)
+			if !ps.accept(",") {
+				ps.expect(")")
+				break
+			}
+		}
+	}
+	ps.expect("{")
+	outer := ps.scope
+	comp.scope = newRDLScope(outer)
+	ps.scope = comp.scope
+	comp.body = ps.parseBody(false)
+	ps.scope = outer
+	if comp.name != "" {
+		if _, ok := outer.types[comp.name]; ok {
+			ps.fail(t, "%s %s defined twice", comp.kind, comp.name)
+		}
+		outer.types[comp.name] = comp
+		ps.components = append(ps.components, comp)
+	}
+	return comp
+}
+
+// parseInstance : Parse an instance name with its array, range and address
+func (ps *rdlParser) parseInstance() (in rdlInstance) {
+	in.line = ps.peek().line
+	in.name = ps.ident()
+	for ps.accept("[") {
+		b := []*rdlExpr{ps.parseExpr()}
+		if ps.accept(":") {
+			b = append(b, ps.parseExpr())
+		}
+		ps.expect("]")
+		in.bounds = append(in.bounds, b)
+	}
+	if ps.accept("=") {
+		in.reset = ps.parseExpr()
+	}
+	if ps.accept("@") {
+		in.at = ps.parseExpr()
+	}
+	if ps.accept("+=") {
+		in.stride = ps.parseExpr()
+	}
+	if ps.accept("%=") {
+		in.align = ps.parseExpr()
+	}
+	return
+}
+
+// parseEnum : Parse an enum definition
+func (ps *rdlParser) parseEnum() {
+	t := ps.peek()
+	e := &rdlEnum{name: ps.ident(), scope: ps.scope}
+	ps.expect("{")
+	for ps.err == nil && !ps.accept("}") {
+		entry := rdlEnumEntry{name: ps.ident()}
+		if ps.accept("=") {
+			entry.value = ps.parseExpr()
+		}
+		if ps.accept("{") {
+			for ps.err == nil && !ps.accept("}") {
+				st := rdlStmt{line: ps.peek().line}
+				st.prop, st.value = ps.parseAssign()
+				entry.props = append(entry.props, st)
+			}
+		}
+		ps.expect(";")
+		e.entries = append(e.entries, entry)
+	}
+	ps.expect(";")
+	if _, ok := ps.scope.enums[e.name]; ok {
+		ps.fail(t, "enum %s defined twice", e.name)
+	}
+	ps.scope.enums[e.name] = e
+}
+
+// skipBlock : Skip a braced block
+func (ps *rdlParser) skipBlock() {
+	ps.expect("{")
+	for depth := 1; ps.err == nil && depth > 0; {
+		switch t := ps.next(); {
+		case t.kind == rdlEOF:
+			ps.fail(t, "missing \"}\"")
+		case t.kind == rdlPunct && t.text == "{":
+			depth++
+		case t.kind == rdlPunct && t.text == "}":
+			depth--
+		}
+	}
+}
+
+// rdlBinaryOps : precedence of the binary operators
+var rdlBinaryOps = map[string]int{
+	"||": 1, "&&": 2, "|": 3, "^": 4, "&": 5, "==": 6, "!=": 6,
+	"<": 7, "<=": 7, ">": 7, ">=": 7, "<<": 8, ">>": 8,
+	"+": 9, "-": 9, "*": 10, "/": 10, "%": 10, "**": 11,
+}
+
+// parseExpr : Parse an expression
+func (ps *rdlParser) parseExpr() *rdlExpr {
+	cond := ps.parseBinary(1)
+	if t := ps.peek(); ps.accept("?") {
+		a := ps.parseExpr()
+		ps.expect(":")
+		b := ps.parseExpr()
+		return &rdlExpr{op: "?", tok: t, args: []*rdlExpr{cond, a, b}}
+	}
+	return cond
+}
+
+func (ps *rdlParser) parseBinary(prec int) *rdlExpr {
+	left := ps.parseUnary()
+	for ps.err == nil {
+		t := ps.peek()
+		p, ok := rdlBinaryOps[t.text]
+		if t.kind != rdlPunct || !ok || p < prec {
+			break
+		}
+		ps.next()
+		left = &rdlExpr{op: t.text, tok: t, args: []*rdlExpr{left, ps.parseBinary(p + 1)}}
+	}
+	return left
+}
+
+func (ps *rdlParser) parseUnary() *rdlExpr {
+	t := ps.next()
+	switch {
+	case t.kind == rdlPunct && strings.Contains("!~-+", t.text) && len(t.text) == 1:
+		return &rdlExpr{op: "u" + t.text, tok: t, args: []*rdlExpr{ps.parseUnary()}}
+	case t.kind == rdlPunct && t.text == "(":
+		e := ps.parseExpr()
+		ps.expect(")")
+		return e
+	case t.kind == rdlIdent && ps.accept("::"):
+		return &rdlExpr{op: "::", tok: t, member: ps.ident()}
+	case t.kind == rdlNumber || t.kind == rdlString || t.kind == rdlIdent:
+		return &rdlExpr{tok: t}
+	}
+	ps.fail(t, "expected an expression, found %q", t.text)
+	return &rdlExpr{tok: t}
+}
+
+// rdlValueKind : type of an elaborated value
+type rdlValueKind int
+
+const (
+	rdlNumberValue rdlValueKind = iota
+	rdlBoolValue
+	rdlStringValue
+	rdlWordValue
+	rdlEnumValue
+)
+
+// rdlValue : an elaborated property or parameter value
+type rdlValue struct {
+	kind rdlValueKind
+
+	// Number, or 0/1 for a boolean.
+	num uint64
+
+	// String, or identifier such as rw or woclr.
+	str string
+
+	// Enum type.
+	enum *rdlEnum
+}
+
+// rdlEnv : parameters and types visible from a component body
+type rdlEnv struct {
+	params map[string]rdlValue
+	scope  *rdlScope
+}
+
+// number : Get an expression value as a number
+func (env rdlEnv) number(e *rdlExpr) (uint64, error) {
+	v, err := env.eval(e)
+	if err == nil && v.kind != rdlNumberValue && v.kind != rdlBoolValue {
+		err = fmt.Errorf("line %d: %q is not a number", e.tok.line, v.str)
+	}
+	return v.num, err
+}
+
+// eval : Evaluate an expression
+func (env rdlEnv) eval(e *rdlExpr) (v rdlValue, err error) {
+	fail := func(format string, a ...interface{}) (rdlValue, error) {
+		return v, fmt.Errorf("line %d: %s", e.tok.line, fmt.Sprintf(format, a...))
+	}
+	switch e.op {
+	case "":
+		switch e.tok.kind {
+		case rdlNumber:
+			n, err := parseRDLNumber(e.tok.text)
+			if err != nil {
+				return fail("%v", err)
+			}
+			return rdlValue{kind: rdlNumberValue, num: n}, nil
+		case rdlString:
+			return rdlValue{kind: rdlStringValue, str: e.tok.text}, nil
+		}
+		name := e.tok.text
+		if p, ok := env.params[name]; ok {
+			return p, nil
+		}
+		switch name {
+		case "true":
+			return rdlValue{kind: rdlBoolValue, num: 1, str: name}, nil
+		case "false":
+			return rdlValue{kind: rdlBoolValue, str: name}, nil
+		}
+		if en := env.scope.lookupEnum(name); en != nil {
+			return rdlValue{kind: rdlEnumValue, str: name, enum: en}, nil
+		}
+		return rdlValue{kind: rdlWordValue, str: name}, nil
+	case "::":
+		en := env.scope.lookupEnum(e.tok.text)
+		if en == nil {
+			return fail("enum %s not defined", e.tok.text)
+		}
+		values, err := en.values()
+		if err != nil {
+			return v, err
+		}
+		for i, entry := range en.entries {
+			if entry.name == e.member {
+				return rdlValue{kind: rdlNumberValue, num: values[i]}, nil
+			}
+		}
+		return fail("enum %s has no entry %s", e.tok.text, e.member)
+	case "?":
+		cond, err := env.number(e.args[0])
+		if err != nil {
+			return v, err
+		}
+		if cond != 0 {
+			return env.eval(e.args[1])
+		}
+		return env.eval(e.args[2])
+	}
+	if strings.HasPrefix(e.op, "u") {
+		a, err := env.number(e.args[0])
+		if err != nil {
+			return v, err
+		}
+		switch e.op {
+		case "u!":
+			return rdlValue{kind: rdlBoolValue, num: uint64(boolBit(a == 0))}, nil
+		case "u~":
+			a = ^a
+		case "u-":
+			a = -a
+		}
+		return rdlValue{kind: rdlNumberValue, num: a}, nil
+	}
+	left, err := env.eval(e.args[0])
+	if err != nil {
+		return v, err
+	}
+	right, err := env.eval(e.args[1])
+	if err != nil {
+		return v, err
+	}
+	if left.kind >= rdlStringValue || right.kind >= rdlStringValue {
+		switch e.op {
+		case "==":
+			return rdlValue{kind: rdlBoolValue, num: uint64(boolBit(left.str == right.str))}, nil
+		case "!=":
+			return rdlValue{kind: rdlBoolValue, num: uint64(boolBit(left.str != right.str))}, nil
+		}
+		return fail("operator %s needs numbers", e.op)
+	}
+	a, b := left.num, right.num
+	n := rdlValue{kind: rdlNumberValue}
+	switch e.op {
+	case "+":
+		n.num = a + b
+	case "-":
+		n.num = a - b
+	case "*":
+		n.num = a * b
+	case "/", "%":
+		if b == 0 {
+			return fail("division by zero")
+		}
+		if n.num = a / b; e.op == "%" {
+			n.num = a % b
+		}
+	case "**":
+		n.num = 1
+		for i := uint64(0); i < b; i++ {
+			n.num *= a
+		}
+	case "<<":
+		n.num = a << b
+	case ">>":
+		n.num = a >> b
+	case "&":
+		n.num = a & b
+	case "|":
+		n.num = a | b
+	case "^":
+		n.num = a ^ b
+	default:
+		n.kind = rdlBoolValue
+		n.num = uint64(boolBit(map[string]bool{
+			"&&": a != 0 && b != 0, "||": a != 0 || b != 0,
+			"==": a == b, "!=": a != b, "<": a < b, "<=": a <= b, ">": a > b, ">=": a >= b,
+		}[e.op]))
+	}
+	return n, nil
+}
+
+// values : Evaluate the values of the entries of an enum
+// An entry without value follows the previous one.
+func (en *rdlEnum) values() ([]uint64, error) {
+	env := rdlEnv{scope: en.scope}
+	var values []uint64
+	next := uint64(0)
+	for _, entry := range en.entries {
+		if entry.value != nil {
+			n, err := env.number(entry.value)
+			if err != nil {
+				return nil, err
+			}
+			next = n
+		}
+		values = append(values, next)
+		next++
+	}
+	return values, nil
+}
+
+// rdlProperty : components a property applies to, and whether its
+// meaning is carried over to SVD (or does not matter to software)
+type rdlProperty struct {
+	kinds string
+	svd   bool
+}
+
+// rdlProperties : SystemRDL 2.0 properties
+var rdlProperties = map[string]rdlProperty{
+	"name": {"addrmap regfile reg field", true}, "desc": {"addrmap regfile reg field", true},
+	"ispresent": {"addrmap regfile reg field", true}, "dontcompare": {"addrmap regfile reg field", true},
+	"donttest": {"addrmap regfile reg field", true}, "hdl_path": {"addrmap regfile reg", true},
+	"hdl_path_gate": {"addrmap regfile reg", true}, "hdl_path_slice": {"field", true},
+	"hdl_path_gate_slice": {"field", true}, "errextbus": {"addrmap regfile reg", true},
+	"sharedextbus": {"addrmap regfile", true}, "alignment": {"addrmap regfile", true},
+	"addressing": {"addrmap", true}, "rsvdset": {"addrmap", true}, "rsvdsetX": {"addrmap", true},
+	"bigendian": {"addrmap", true}, "littleendian": {"addrmap", true}, "lsb0": {"addrmap", true},
+	"msb0": {"addrmap", true}, "bridge": {"addrmap", true},
+	"regwidth": {"reg", true}, "accesswidth": {"reg", true}, "shared": {"reg", true},
+	"sw": {"field", true}, "hw": {"field", true}, "reset": {"field", true}, "resetsignal": {"field", true},
+	"onread": {"field", true}, "onwrite": {"field", true}, "rclr": {"field", true}, "rset": {"field", true},
+	"woclr": {"field", true}, "woset": {"field", true}, "fieldwidth": {"field", true}, "encode": {"field", true},
+	"precedence": {"field", true}, "paritycheck": {"field", true}, "we": {"field", true}, "wel": {"field", true},
+	"swwe": {"field", false}, "swwel": {"field", false}, "swmod": {"field", false}, "swacc": {"field", false},
+	"singlepulse": {"field", false}, "hwclr": {"field", false}, "hwset": {"field", false},
+	"hwenable": {"field", false}, "hwmask": {"field", false}, "anded": {"field", false},
+	"ored": {"field", false}, "xored": {"field", false}, "counter": {"field", false},
+	"threshold": {"field", false}, "saturate": {"field", false}, "incrthreshold": {"field", false},
+	"incrsaturate": {"field", false}, "incr": {"field", false}, "incrvalue": {"field", false},
+	"incrwidth": {"field", false}, "overflow": {"field", false}, "decrthreshold": {"field", false},
+	"decrsaturate": {"field", false}, "decr": {"field", false}, "decrvalue": {"field", false},
+	"decrwidth": {"field", false}, "underflow": {"field", false}, "intr": {"reg field", false},
+	"enable": {"field", false}, "mask": {"field", false}, "haltenable": {"field", false},
+	"haltmask": {"field", false}, "sticky": {"field", false}, "stickybit": {"field", false},
+	"next": {"field", false},
+}
+
+// rdlApplies : Tell if a property can be set on a component
+func rdlApplies(prop, kind string) bool {
+	p, ok := rdlProperties[prop]
+	return ok && strings.Contains(" "+p.kinds+" ", " "+kind+" ")
+}
+
+// rdlNode : an elaborated component instance
+type rdlNode struct {
+	kind     string
+	name     string
+	typeName string
+	line     int
+	props    map[string]rdlValue
+	children []*rdlNode
+
+	// Array dimensions.
+	dims []uint64
+
+	// Offset in bytes relative to the parent, or bit offset of a field,
+	// and whether it is given by the description.
+	offset uint64
+	placed bool
+
+	// Width in bits of a field, 0 if not given by the instance.
+	width uint64
+
+	// Array stride and alignment given by the instance, 0 if none.
+	stride, align uint64
+
+	// Size in bytes of an element, and natural alignment.
+	size, natural uint64
+}
+
+// count : Get the number of elements of the instance
+func (n *rdlNode) count() uint64 {
+	c := uint64(1)
+	for _, d := range n.dims {
+		c *= d
+	}
+	return c
+}
+
+// str : Get a string property, "" if not set
+func (n *rdlNode) str(prop string) string {
+	if v, ok := n.props[prop]; ok && v.kind == rdlStringValue {
+		return v.str
+	}
+	return ""
+}
+
+// description : Get the desc property, or the name property
+func (n *rdlNode) description() string {
+	if d := n.str("desc"); d != "" {
+		return d
+	}
+	return n.str("name")
+}
+
+// rdlElaborator : state of the elaboration of a SystemRDL description
+type rdlElaborator struct {
+	udps  map[string]bool
+	diags []error
+}
+
+// lossy : Report a part of the description not carried over to SVD
+func (el *rdlElaborator) lossy(format string, a ...interface{}) {
+	el.diags = append(el.diags, fmt.Errorf(format, a...))
+}
+
+// componentEnv : Get the environment of a component body
+// Parameters get the values given by the instantiation, evaluated in the
+// outer environment, or their default value.
+func componentEnv(comp *rdlComponent, args []rdlParam, outer rdlEnv) (env rdlEnv, err error) {
+	if comp.name == "" {
+		return rdlEnv{params: outer.params, scope: comp.scope}, nil
+	}
+	env = rdlEnv{params: make(map[string]rdlValue), scope: comp.scope}
+	given := make(map[string]*rdlExpr)
+	for _, a := range args {
+		found := false
+		for _, p := range comp.params {
+			found = found || p.name == a.name
+		}
+		if !found {
+			return env, fmt.Errorf("line %d: %s has no parameter %s", a.value.tok.line, comp.name, a.name)
+		}
+		given[a.name] = a.value
+	}
+	for _, p := range comp.params {
+		expr, penv := p.value, env
+		if e, ok := given[p.name]; ok {
+			expr, penv = e, outer
+		}
+		if expr == nil {
+			return env, fmt.Errorf("line %d: parameter %s of %s has no value", comp.line, p.name, comp.name)
+		}
+		if env.params[p.name], err = penv.eval(expr); err != nil {
+			return env, err
+		}
+	}
+	return env, nil
+}
+
+// rdlParents : components each component can be instantiated in
+var rdlParents = map[string]string{
+	"field": "reg", "reg": "regfile addrmap", "regfile": "regfile addrmap", "addrmap": "addrmap",
+}
+
+// instantiate : Elaborate the body of a component into a node
+// defaults holds the default property values of the enclosing scopes.
+func (el *rdlElaborator) instantiate(comp *rdlComponent, env rdlEnv, defaults map[string]rdlValue) (*rdlNode, error) {
+	n := &rdlNode{kind: comp.kind, typeName: comp.name, line: comp.line, props: make(map[string]rdlValue)}
+	for prop, v := range defaults {
+		if rdlApplies(prop, n.kind) {
+			n.props[prop] = v
+		}
+	}
+	local := defaults
+	for _, st := range comp.body {
+		if st.prop != "" {
+			v := rdlValue{kind: rdlBoolValue, num: 1, str: "true"}
+			if st.value != nil {
+				var err error
+				if v, err = env.eval(st.value); err != nil {
+					return nil, err
+				}
+			}
+			if _, ok := rdlProperties[st.prop]; !ok && !el.udps[st.prop] {
+				return nil, fmt.Errorf("line %d: unknown property %s", st.line, st.prop)
+			}
+			if st.isDefault {
+				copied := make(map[string]rdlValue)
+				for k, d := range local {
+					copied[k] = d
+				}
+				copied[st.prop] = v
+				local = copied
+				continue
+			}
+			target := n
+			for _, name := range st.target {
+				var child *rdlNode
+				for _, c := range target.children {
+					if c.name == name {
+						child = c
+					}
+				}
+				if child == nil {
+					return nil, fmt.Errorf("line %d: instance %s not found", st.line, name)
+				}
+				target = child
+			}
+			if !el.udps[st.prop] && !rdlApplies(st.prop, target.kind) {
+				return nil, fmt.Errorf("line %d: property %s does not apply to %s", st.line, st.prop, target.kind)
+			}
+			target.props[st.prop] = v
+			continue
+		}
+		child := st.comp
+		if child == nil {
+			if child = env.scope.lookupType(st.typeName); child == nil {
+				return nil, fmt.Errorf("line %d: component %s not defined", st.line, st.typeName)
+			}
+		}
+		if !strings.Contains(" "+rdlParents[child.kind]+" ", " "+n.kind+" ") {
+			return nil, fmt.Errorf("line %d: %s cannot be instantiated in %s", st.line, child.kind, n.kind)
+		}
+		cenv, err := componentEnv(child, st.args, env)
+		if err != nil {
+			return nil, err
+		}
+		for _, in := range st.instances {
+			c, err := el.instantiate(child, cenv, local)
+			if err != nil {
+				return nil, err
+			}
+			c.name, c.line = in.name, in.line
+			if err := placeInstance(c, in, env); err != nil {
+				return nil, err
+			}
+			for _, other := range n.children {
+				if other.name == c.name {
+					return nil, fmt.Errorf("line %d: instance %s defined twice", in.line, c.name)
+				}
+			}
+			n.children = append(n.children, c)
+		}
+	}
+	return n, nil
+}
+
+// placeInstance : Apply the array, bit range, reset and address of an instance
+func placeInstance(n *rdlNode, in rdlInstance, env rdlEnv) (err error) {
+	fail := func(format string, a ...interface{}) error {
+		return fmt.Errorf("line %d: %s %s: %s", in.line, n.kind, n.name, fmt.Sprintf(format, a...))
+	}
+	if n.kind == "field" {
+		switch {
+		case len(in.bounds) > 1:
+			return fail("field arrays are not supported")
+		case len(in.bounds) == 1 && len(in.bounds[0]) == 2:
+			msb, err := env.number(in.bounds[0][0])
+			if err != nil {
+				return err
+			}
+			if n.offset, err = env.number(in.bounds[0][1]); err != nil {
+				return err
+			}
+			if msb < n.offset {
+				return fail("msb %d is below lsb %d", msb, n.offset)
+			}
+			n.width, n.placed = msb-n.offset+1, true
+		case len(in.bounds) == 1:
+			if n.width, err = env.number(in.bounds[0][0]); err != nil {
+				return err
+			}
+			if n.width == 0 {
+				return fail("zero width")
+			}
+		}
+		if in.reset != nil {
+			if n.props["reset"], err = env.eval(in.reset); err != nil {
+				return err
+			}
+		}
+		if in.at != nil {
+			if n.offset, err = env.number(in.at); err != nil {
+				return err
+			}
+			n.placed = true
+		}
+		if in.stride != nil || in.align != nil {
+			return fail("fields have no stride or alignment")
+		}
+		return nil
+	}
+	if in.reset != nil {
+		return fail("only fields have a reset value")
+	}
+	for _, b := range in.bounds {
+		if len(b) != 1 {
+			return fail("arrays are declared with [size]")
+		}
+		d, err := env.number(b[0])
+		if err != nil {
+			return err
+		}
+		if d == 0 {
+			return fail("empty array")
+		}
+		n.dims = append(n.dims, d)
+	}
+	if in.at != nil {
+		if n.offset, err = env.number(in.at); err != nil {
+			return err
+		}
+		n.placed = true
+	}
+	if in.stride != nil {
+		if n.stride, err = env.number(in.stride); err != nil {
+			return err
+		}
+	}
+	if in.align != nil {
+		if n.align, err = env.number(in.align); err != nil {
+			return err
+		}
+		if n.align == 0 || n.align&(n.align-1) != 0 {
+			return fail("alignment %d is not a power of two", n.align)
+		}
+	}
+	return nil
+}
+
+// rdlNumberProp : Get a numeric property, def if not set
+func rdlNumberProp(n *rdlNode, prop string, def uint64) (uint64, error) {
+	v, ok := n.props[prop]
+	if !ok {
+		return def, nil
+	}
+	if v.kind != rdlNumberValue {
+		return 0, fmt.Errorf("line %d: %s %s: %s is not a number", n.line, n.kind, n.name, prop)
+	}
+	return v.num, nil
+}
+
+// roundPow2 : Round up to a power of two
+func roundPow2(n uint64) uint64 {
+	p := uint64(1)
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// layout : Place the fields of registers and the instances of maps
+// Fields without position follow the previous one, from bit 0.
+// Instances without address follow the previous one, aligned according
+// to the addressing mode: registers on their access width (compact) or
+// their size (regalign), arrays on their whole size (fullalign).
+func (el *rdlElaborator) layout(n *rdlNode, mode string) error {
+	switch n.kind {
+	case "field":
+		return nil
+	case "reg":
+		width, err := rdlNumberProp(n, "regwidth", 32)
+		if err != nil {
+			return err
+		}
+		if width < 8 || width&(width-1) != 0 {
+			return fmt.Errorf("line %d: reg %s: regwidth %d is not a power of two of at least 8", n.line, n.name, width)
+		}
+		n.size, n.natural = width/8, width/8
+		if mode == "compact" {
+			access, err := rdlNumberProp(n, "accesswidth", width)
+			if err != nil {
+				return err
+			}
+			n.natural = roundPow2(access / 8)
+		}
+		var next, used uint64
+		for _, f := range n.children {
+			w, err := rdlNumberProp(f, "fieldwidth", f.width)
+			if err != nil {
+				return err
+			}
+			if f.width != 0 && w != f.width {
+				return fmt.Errorf("line %d: field %s: width %d differs from fieldwidth %d", f.line, f.name, f.width, w)
+			}
+			if f.width = w; w == 0 {
+				f.width = 1
+			}
+			if !f.placed {
+				f.offset = next
+			}
+			if f.offset+f.width > width {
+				return fmt.Errorf("line %d: field %s does not fit in register %s", f.line, f.name, n.name)
+			}
+			mask := bitMask(uint(f.width)) << f.offset
+			if used&mask != 0 {
+				return fmt.Errorf("line %d: field %s overlaps another field of register %s", f.line, f.name, n.name)
+			}
+			used |= mask
+			next = f.offset + f.width
+		}
+		return nil
+	}
+	if v, ok := n.props["addressing"]; ok && n.kind == "addrmap" {
+		switch mode = v.str; mode {
+		case "compact", "regalign", "fullalign":
+		default:
+			return fmt.Errorf("line %d: addrmap %s: invalid addressing %s", n.line, n.name, v.str)
+		}
+	}
+	alignment, err := rdlNumberProp(n, "alignment", 0)
+	if err != nil {
+		return err
+	}
+	var next uint64
+	n.natural = 1
+	for _, c := range n.children {
+		if err := el.layout(c, mode); err != nil {
+			return err
+		}
+		if c.stride == 0 {
+			c.stride = c.size
+		}
+		total := (c.count()-1)*c.stride + c.size
+		align := c.align
+		switch {
+		case align != 0:
+		case alignment != 0:
+			align = alignment
+		case mode == "fullalign":
+			align = roundPow2(total)
+		default:
+			align = c.natural
+		}
+		if !c.placed {
+			c.offset = (next + align - 1) / align * align
+		}
+		next = c.offset + total
+		if next > n.size {
+			n.size = next
+		}
+		if c.natural > n.natural {
+			n.natural = c.natural
+		}
+	}
+	return nil
+}
+
+// rdlAccess : SVD access of the sw property values
+var rdlAccess = map[string]AccessType{
+	"rw": AccessReadWrite, "wr": AccessReadWrite, "r": AccessReadOnly, "w": AccessWriteOnly,
+	"rw1": AccessReadWriteOnce, "w1": AccessWriteOnce, "na": "",
+}
+
+// rdlReadActions : SVD read action of the onread property values
+var rdlReadActions = map[string]ReadAction{
+	"rclr": ReadActionClear, "rset": ReadActionSet, "ruser": ReadActionModify,
+}
+
+// rdlWriteValues : SVD modified write values of the onwrite property values
+var rdlWriteValues = map[string]ModifiedWriteValues{
+	"woset": ModifiedWriteValuesOneToSet, "woclr": ModifiedWriteValuesOneToClear,
+	"wot": ModifiedWriteValuesOneToToggle, "wzs": ModifiedWriteValuesZeroToSet,
+	"wzc": ModifiedWriteValuesZeroToClear, "wzt": ModifiedWriteValuesZeroToToggle,
+	"wclr": ModifiedWriteValuesClear, "wset": ModifiedWriteValuesSet, "wuser": ModifiedWriteValuesModify,
+}
+
+// unmapped : Report the properties of a node not carried over to SVD
+func (el *rdlElaborator) unmapped(path string, n *rdlNode) {
+	var props []string
+	for prop := range n.props {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+	for _, prop := range props {
+		if p, ok := rdlProperties[prop]; !ok {
+			el.lossy("%s: user-defined property %s is not imported", path, prop)
+		} else if !p.svd {
+			el.lossy("%s: property %s is not represented in SVD", path, prop)
+		}
+	}
+}
+
+// present : Tell if the ispresent property of a node is not false
+func (n *rdlNode) present() bool {
+	v, ok := n.props["ispresent"]
+	return !ok || v.num != 0
+}
+
+// arrayName : Get the SVD name of an instance, with a [%s] placeholder
+// for arrays; multi-dimensional arrays are flattened.
+func (el *rdlElaborator) arrayName(path string, n *rdlNode) string {
+	if len(n.dims) == 0 {
+		return n.name
+	}
+	if len(n.dims) > 1 {
+		el.lossy("%s: %d-dimensional array flattened", path, len(n.dims))
+	}
+	return n.name + "[%s]"
+}
+
+// field : Convert a field node, ok is false if it is not software accessible
+func (el *rdlElaborator) field(path string, n *rdlNode) (f Field, reset uint64, hasReset, ok bool, err error) {
+	fail := func(format string, a ...interface{}) error {
+		return fmt.Errorf("line %d: field %s: %s", n.line, path, fmt.Sprintf(format, a...))
+	}
+	el.unmapped(path, n)
+	access := AccessReadWrite
+	if v, set := n.props["sw"]; set {
+		if access, ok = rdlAccess[v.str]; !ok {
+			return f, 0, false, false, fail("invalid sw %s", v.str)
+		}
+		if access == "" {
+			el.lossy("%s: field without software access is not imported", path)
+			return f, 0, false, false, nil
+		}
+	}
+	f = Field{
+		Name:        n.name,
+		Description: n.description(),
+		BitOffset:   strconv.FormatUint(n.offset, 10),
+		BitWidth:    strconv.FormatUint(n.width, 10),
+		Access:      &access,
+	}
+	action := ReadAction("")
+	if v, set := n.props["onread"]; set {
+		if action = rdlReadActions[v.str]; action == "" {
+			return f, 0, false, false, fail("invalid onread %s", v.str)
+		}
+	}
+	if v, set := n.props["rclr"]; set && v.num != 0 {
+		action = ReadActionClear
+	}
+	if v, set := n.props["rset"]; set && v.num != 0 {
+		action = ReadActionSet
+	}
+	if action != "" {
+		f.ReadAction = &action
+	}
+	mwv := ModifiedWriteValues("")
+	if v, set := n.props["onwrite"]; set {
+		if mwv = rdlWriteValues[v.str]; mwv == "" {
+			return f, 0, false, false, fail("invalid onwrite %s", v.str)
+		}
+	}
+	if v, set := n.props["woclr"]; set && v.num != 0 {
+		mwv = ModifiedWriteValuesOneToClear
+	}
+	if v, set := n.props["woset"]; set && v.num != 0 {
+		mwv = ModifiedWriteValuesOneToSet
+	}
+	if mwv != "" {
+		f.ModifiedWriteValues = &mwv
+	}
+	if v, set := n.props["reset"]; set {
+		switch v.kind {
+		case rdlNumberValue, rdlBoolValue:
+			if n.width < 64 && v.num>>n.width != 0 {
+				return f, 0, false, false, fail("reset 0x%X does not fit in %d bits", v.num, n.width)
+			}
+			reset, hasReset = v.num, true
+		default:
+			el.lossy("%s: reset %s is not a constant, not imported", path, v.str)
+		}
+	}
+	if v, set := n.props["encode"]; set {
+		if v.kind != rdlEnumValue {
+			return f, 0, false, false, fail("encode %s is not an enum", v.str)
+		}
+		values, err := v.enum.values()
+		if err != nil {
+			return f, 0, false, false, err
+		}
+		ev := &EnumeratedValues{Name: v.enum.name}
+		for i, entry := range v.enum.entries {
+			e := EnumeratedValue{Name: entry.name, Value: fmt.Sprintf("0x%X", values[i])}
+			env := rdlEnv{scope: v.enum.scope}
+			for _, st := range entry.props {
+				pv, err := env.eval(st.value)
+				if err != nil {
+					return f, 0, false, false, err
+				}
+				if st.prop == "desc" || st.prop == "name" && e.Description == "" {
+					e.Description = pv.str
+				}
+			}
+			ev.EnumeratedValue = append(ev.EnumeratedValue, e)
+		}
+		f.EnumeratedValues = ev
+	}
+	return f, reset, hasReset, true, nil
+}
+
+// register : Convert a reg node
+// The register gets the access of its fields when they all share it.
+func (el *rdlElaborator) register(path string, n *rdlNode) (r Register, err error) {
+	el.unmapped(path, n)
+	r = Register{
+		Name:          el.arrayName(path, n),
+		DisplayName:   n.str("name"),
+		Description:   n.description(),
+		AddressOffset: fmt.Sprintf("0x%X", n.offset),
+		Size:          strconv.FormatUint(n.size*8, 10),
+		Access:        AccessReadWrite,
+	}
+	if len(n.dims) > 0 {
+		r.Dim = strconv.FormatUint(n.count(), 10)
+		r.DimIncrement = fmt.Sprintf("0x%X", n.stride)
+	}
+	var resetValue, resetMask uint64
+	var fields []Field
+	for _, c := range n.children {
+		if !c.present() {
+			continue
+		}
+		f, reset, hasReset, ok, err := el.field(path+"."+c.name, c)
+		if err != nil {
+			return r, err
+		}
+		if !ok {
+			continue
+		}
+		if hasReset {
+			resetValue |= reset << c.offset
+			resetMask |= bitMask(uint(c.width)) << c.offset
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) > 0 {
+		uniform := true
+		for _, f := range fields {
+			uniform = uniform && *f.Access == *fields[0].Access
+		}
+		if uniform {
+			r.Access = *fields[0].Access
+			for i := range fields {
+				fields[i].Access = nil
+			}
+		}
+		r.Fields = &Fields{Field: fields}
+	}
+	r.ResetValue = FormatHex(resetValue, uint(n.size*8))
+	r.ResetMask = FormatHex(resetMask, uint(n.size*8))
+	return r, nil
+}
+
+// scope : Convert the instances of a map into registers and clusters
+func (el *rdlElaborator) scope(path string, children []*rdlNode) (regs []Register, clusters []Cluster, err error) {
+	for _, c := range children {
+		if !c.present() {
+			continue
+		}
+		cpath := path + "." + c.name
+		if c.kind == "reg" {
+			r, err := el.register(cpath, c)
+			if err != nil {
+				return nil, nil, err
+			}
+			regs = append(regs, r)
+			continue
+		}
+		el.unmapped(cpath, c)
+		cl := Cluster{
+			Name:          el.arrayName(cpath, c),
+			Description:   c.description(),
+			AddressOffset: fmt.Sprintf("0x%X", c.offset),
+		}
+		if len(c.dims) > 0 {
+			cl.Dim = strconv.FormatUint(c.count(), 10)
+			cl.DimIncrement = fmt.Sprintf("0x%X", c.stride)
+		}
+		if cl.Register, cl.Cluster, err = el.scope(cpath, c.children); err != nil {
+			return nil, nil, err
+		}
+		clusters = append(clusters, cl)
+	}
+	return
+}
+
+// peripheral : Convert a map into a peripheral at the given base address
+func (el *rdlElaborator) peripheral(n *rdlNode, name string, base uint64, children []*rdlNode) (p Peripheral, err error) {
+	p = Peripheral{
+		Name:        name,
+		Description: n.description(),
+		GroupName:   n.typeName,
+		BaseAddress: fmt.Sprintf("0x%08X", base),
+	}
+	if len(n.dims) > 0 {
+		p.Dim = uint(n.count())
+		p.DimIncrement = uint(n.stride)
+	}
+	var size uint64
+	for _, c := range children {
+		if end := c.offset + (c.count()-1)*c.stride + c.size; c.present() && end > size {
+			size = end
+		}
+	}
+	p.AddressBlock = []AddressBlock{{Offset: 0, Size: fmt.Sprintf("0x%X", size), Usage: UsageRegisters}}
+	regs, clusters, err := el.scope(n.name, children)
+	if err != nil {
+		return p, err
+	}
+	if len(regs) > 0 || len(clusters) > 0 {
+		p.Registers = &Registers{Register: regs, Cluster: clusters}
+	}
+	return p, nil
+}
+
+// device : Convert the root map into a device
+// The maps instantiated in the root become peripherals; registers and
+// register files of the root go into a peripheral named after it.
+func (el *rdlElaborator) device(root *rdlNode) (*Device, error) {
+	dev := NewDevice(root.name)
+	dev.Description = root.description()
+	var maps, others []*rdlNode
+	for _, c := range root.children {
+		if c.kind == "addrmap" {
+			maps = append(maps, c)
+		} else {
+			others = append(others, c)
+		}
+	}
+	el.unmapped(root.name, root)
+	if len(others) > 0 || len(maps) == 0 {
+		p, err := el.peripheral(root, root.name, 0, others)
+		if err != nil {
+			return nil, err
+		}
+		p.GroupName = ""
+		dev.Peripherals.Peripheral = append(dev.Peripherals.Peripheral, p)
+	}
+	for _, m := range maps {
+		if !m.present() {
+			continue
+		}
+		el.unmapped(m.name, m)
+		p, err := el.peripheral(m, el.arrayName(m.name, m), m.offset, m.children)
+		if err != nil {
+			return nil, err
+		}
+		dev.Peripherals.Peripheral = append(dev.Peripherals.Peripheral, p)
+	}
+	return dev, nil
+}
+
+// rdlRoot : Get the root map of a description
+// The root is the last addrmap instantiated at the top level, or else the
+// last addrmap definition not instantiated by another component.
+func (ps *rdlParser) rdlRoot(body []rdlStmt) (comp *rdlComponent, name string, defaults map[string]rdlValue, err error) {
+	defaults = make(map[string]rdlValue)
+	env := rdlEnv{scope: ps.scope}
+	for _, st := range body {
+		switch {
+		case st.isDefault:
+			v := rdlValue{kind: rdlBoolValue, num: 1, str: "true"}
+			if st.value != nil {
+				if v, err = env.eval(st.value); err != nil {
+					return
+				}
+			}
+			defaults[st.prop] = v
+		case st.prop != "":
+			return nil, "", nil, fmt.Errorf("line %d: property %s assigned outside of a component", st.line, st.prop)
+		default:
+			c := st.comp
+			if c == nil {
+				c = ps.scope.lookupType(st.typeName)
+			}
+			if c == nil || c.kind != "addrmap" || len(st.instances) != 1 || len(st.args) > 0 {
+				return nil, "", nil, fmt.Errorf("line %d: only a single addrmap can be instantiated at the top level", st.line)
+			}
+			comp, name = c, st.instances[0].name
+		}
+	}
+	if comp != nil {
+		return
+	}
+	used := make(map[string]bool)
+	var walk func([]rdlStmt)
+	walk = func(body []rdlStmt) {
+		for _, st := range body {
+			used[st.typeName] = true
+			if st.comp != nil {
+				walk(st.comp.body)
+			}
+		}
+	}
+	for _, c := range ps.components {
+		walk(c.body)
+	}
+	for _, c := range ps.components {
+		if c.kind == "addrmap" && ps.scope.types[c.name] == c && !used[c.name] {
+			comp, name = c, c.name
+		}
+	}
+	if comp == nil {
+		return nil, "", nil, fmt.Errorf("no root addrmap")
+	}
+	return
+}
+
+// ReadSystemRDL : Import a SystemRDL 2.0 description
+// The root addrmap becomes the device, the addrmaps it instantiates
+// become peripherals, regfiles and nested addrmaps become clusters.
+// The sw, onread and onwrite properties become access, readAction and
+// modifiedWriteValues, enums used by encode become enumerated values.
+// Parameters are elaborated; the preprocessor, signals, memories and
+// user-defined property values are not supported. Properties without
+// SVD equivalent (counters, interrupts...) are reported in diags.
+func ReadSystemRDL(r io.Reader) (dev *Device, diags []error, err error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	toks, err := lexSystemRDL(string(src))
+	if err != nil {
+		return nil, nil, err
+	}
+	ps := &rdlParser{toks: toks, scope: newRDLScope(nil), udps: make(map[string]bool)}
+	body := ps.parseBody(true)
+	if ps.err != nil {
+		return nil, nil, ps.err
+	}
+	comp, name, defaults, err := ps.rdlRoot(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	el := &rdlElaborator{udps: ps.udps}
+	env, err := componentEnv(comp, nil, rdlEnv{scope: ps.scope})
+	if err != nil {
+		return nil, nil, err
+	}
+	root, err := el.instantiate(comp, env, defaults)
+	if err != nil {
+		return nil, nil, err
+	}
+	root.name = name
+	if err := el.layout(root, "regalign"); err != nil {
+		return nil, nil, err
+	}
+	if dev, err = el.device(root); err != nil {
+		return nil, nil, err
+	}
+	return dev, el.diags, nil
+}
+
+// rdlKeywords : SystemRDL 2.0 reserved words
+var rdlKeywords = func() map[string]bool {
+	words := make(map[string]bool)
+	for _, k := range strings.Fields(`abstract accesstype addressingtype addrmap alias all bit boolean
+		bothedge compact component componentwidth constraint default encode enum external false field
+		fullalign hw inside internal level longint mem na negedge nonsticky number onreadtype
+		onwritetype posedge property r rclr ref reg regalign regfile rset ruser rw rw1 signal string
+		struct sw this true type unsigned w w1 wclr woclr woset wot wr wset wuser wzc wzs wzt`) {
+		words[k] = true
+	}
+	return words
+}()
+
+var rdlNameRe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// rdlName : Make a SystemRDL identifier of a name, without dim placeholder
+// Reserved words are escaped with a backslash.
+func rdlName(name string) string {
+	s := rdlNameRe.ReplaceAllString(strings.NewReplacer("[%s]", "", "%s", "").Replace(name), "_")
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		s = "_" + s
+	}
+	if rdlKeywords[s] {
+		s = `\` + s
+	}
+	return s
+}
+
+// rdlQuote : Quote a string property, on a single line
+func rdlQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(strings.Join(strings.Fields(s), " ")) + `"`
+}
+
+// rdlInstances : Format the instance names and addresses of an element
+// Lists with default indices and a trailing placeholder become arrays,
+// other lists are expanded into one instance per element.
+func rdlInstances(name string, offset uint64, indices []string, inc uint64) string {
+	if indices == nil {
+		return fmt.Sprintf("%s @0x%X", rdlName(name), offset)
+	}
+	array := (strings.HasSuffix(name, "%s") || strings.HasSuffix(name, "[%s]")) && strings.Count(name, "%s") == 1
+	for i, idx := range indices {
+		array = array && idx == strconv.Itoa(i)
+	}
+	if array {
+		return fmt.Sprintf("%s[%d] @0x%X += 0x%X", rdlName(name), len(indices), offset, inc)
+	}
+	var list []string
+	for n, idx := range indices {
+		list = append(list, fmt.Sprintf("%s @0x%X", rdlName(dimName(name, idx)), offset+uint64(n)*inc))
+	}
+	return strings.Join(list, ", ")
+}
+
+// rdlSw : sw property of the SVD access types
+var rdlSw = map[AccessType]string{
+	AccessReadWrite: "rw", AccessReadOnly: "r", AccessWriteOnly: "w",
+	AccessReadWriteOnce: "rw1", AccessWriteOnce: "w1",
+}
+
+// rdlWriter : state of a SystemRDL export
+type rdlWriter struct {
+	dev *Device
+
+	// Enum definitions, written before the maps.
+	enums bytes.Buffer
+
+	// Enum names by content.
+	enumNames map[string]string
+
+	// Names of the types defined so far.
+	names map[string]bool
+
+	diags []error
+}
+
+// lossy : Report a part of the device not carried over to SystemRDL
+func (w *rdlWriter) lossy(format string, a ...interface{}) {
+	w.diags = append(w.diags, fmt.Errorf(format, a...))
+}
+
+// unique : Reserve a type name, adding a suffix if already used
+func (w *rdlWriter) unique(name string) string {
+	u := name
+	for n := 2; w.names[u]; n++ {
+		u = fmt.Sprintf("%s_%d", name, n)
+	}
+	w.names[u] = true
+	return u
+}
+
+// enum : Get the name of the enum type of enumerated values, "" if none
+// Identical enumerated values share the same enum type.
+func (w *rdlWriter) enum(path string, ev *EnumeratedValues, width uint) string {
+	var body strings.Builder
+	for _, e := range ev.EnumeratedValue {
+		if e.IsDefault {
+			w.lossy("%s: default enumerated value %s is not exported", path, e.Name)
+			continue
+		}
+		value, dontCare, err := parseDontCare(e.Value)
+		if err != nil || dontCare != 0 || value&^bitMask(width) != 0 {
+			w.lossy("%s: enumerated value %s = %s is not exported", path, e.Name, e.Value)
+			continue
+		}
+		fmt.Fprintf(&body, "\t%s = %d'h%X", rdlName(e.Name), width, value)
+		if e.Description != "" {
+			fmt.Fprintf(&body, " { desc = %s; }", rdlQuote(e.Description))
+		}
+		body.WriteString(";\n")
+	}
+	if body.Len() == 0 {
+		return ""
+	}
+	key := ev.Name + "\n" + body.String()
+	if name, ok := w.enumNames[key]; ok {
+		return name
+	}
+	base := ev.Name
+	if base == "" {
+		base = strings.ReplaceAll(path, ".", "_") + "_e"
+	}
+	name := w.unique(rdlName(base))
+	w.enumNames[key] = name
+	fmt.Fprintf(&w.enums, "enum %s {\n%s};\n\n", name, body.String())
+	return name
+}
+
+// field : Write a field of a register
+func (w *rdlWriter) field(b *bytes.Buffer, indent, path string, reg ResolvedRegister, f ResolvedField) {
+	path += "." + f.Name
+	fmt.Fprintf(b, "%sfield {\n", indent)
+	sw, ok := rdlSw[f.Access]
+	if !ok {
+		sw = "rw"
+		w.lossy("%s: access %s exported as rw", path, f.Access)
+	}
+	fmt.Fprintf(b, "%s\tsw = %s;\n", indent, sw)
+	switch f.ReadAction {
+	case "":
+	case ReadActionClear:
+		fmt.Fprintf(b, "%s\tonread = rclr;\n", indent)
+	case ReadActionSet:
+		fmt.Fprintf(b, "%s\tonread = rset;\n", indent)
+	default:
+		fmt.Fprintf(b, "%s\tonread = ruser;\n", indent)
+		if f.ReadAction != ReadActionModify {
+			w.lossy("%s: readAction %s exported as ruser", path, f.ReadAction)
+		}
+	}
+	if f.ModifiedWriteValues != "" && f.ModifiedWriteValues != ModifiedWriteValuesModify {
+		for onwrite, mwv := range rdlWriteValues {
+			if mwv == f.ModifiedWriteValues {
+				fmt.Fprintf(b, "%s\tonwrite = %s;\n", indent, onwrite)
+			}
+		}
+	}
+	if f.Description != "" {
+		fmt.Fprintf(b, "%s\tdesc = %s;\n", indent, rdlQuote(f.Description))
+	}
+	if f.EnumeratedValues != nil {
+		if name := w.enum(path, f.EnumeratedValues, f.Width()); name != "" {
+			fmt.Fprintf(b, "%s\tencode = %s;\n", indent, name)
+		}
+	}
+	if f.Field != nil && f.Field.WriteConstraint != nil {
+		w.lossy("%s: write constraint is not exported", path)
+	}
+	reset := ""
+	switch mask := f.Mask(); reg.ResetMask & mask {
+	case mask:
+		reset = fmt.Sprintf(" = %d'h%X", f.Width(), f.Extract(reg.ResetValue))
+	case 0:
+	default:
+		w.lossy("%s: partly defined reset value is not exported", path)
+	}
+	fmt.Fprintf(b, "%s} %s[%d:%d]%s;\n", indent, rdlName(f.Name), f.Msb, f.Lsb, reset)
+}
+
+// register : Write a register, or a register array, of a scope
+func (w *rdlWriter) register(b *bytes.Buffer, depth int, path string, r *Register, regs []ResolvedRegister) error {
+	reg := regs[0]
+	indent := strings.Repeat("\t", depth)
+	path += "." + rdlName(r.Name)
+	fmt.Fprintf(b, "%sreg {\n", indent)
+	if r.DisplayName != "" {
+		fmt.Fprintf(b, "%s\tname = %s;\n", indent, rdlQuote(r.DisplayName))
+	}
+	if reg.Description != "" {
+		fmt.Fprintf(b, "%s\tdesc = %s;\n", indent, rdlQuote(reg.Description))
+	}
+	if reg.Size != 32 {
+		fmt.Fprintf(b, "%s\tregwidth = %d;\n", indent, reg.Size)
+	}
+	fields := reg.bitFields()
+	if len(reg.Fields) == 0 {
+		fields[0].Name, fields[0].Description = rdlName(r.Name), ""
+	}
+	for _, f := range fields {
+		w.field(b, indent+"\t", path, reg, f)
+	}
+	if r.AlternateGroup != "" || r.AlternateRegister != "" {
+		w.lossy("%s: alternate register exported at the same address", path)
+	}
+	indices, inc, err := parseDim(r.Dim, r.DimIncrement, r.DimIndex)
+	if err != nil {
+		return fmt.Errorf("register %s: %v", path, err)
+	}
+	fmt.Fprintf(b, "%s} %s;\n", indent, rdlInstances(r.Name, reg.Offset, indices, inc))
+	return nil
+}
+
+// scope : Write the registers and clusters of a peripheral or cluster,
+// sorted by address offset
+func (w *rdlWriter) scope(b *bytes.Buffer, depth int, p *Peripheral, path string, registers []Register, clusters []Cluster, def defaults) error {
+	type item struct {
+		offset uint64
+		text   bytes.Buffer
+	}
+	var items []*item
+	for i := range registers {
+		r, err := w.dev.derivedRegister(p, &registers[i], 0)
+		if err != nil {
+			return fmt.Errorf("peripheral %s: %v", p.Name, err)
+		}
+		regs, err := w.dev.resolveRegister(p, r, def)
+		if err != nil {
+			return fmt.Errorf("peripheral %s register %s: %v", p.Name, r.Name, err)
+		}
+		it := &item{offset: regs[0].Offset}
+		if err := w.register(&it.text, depth, path, r, regs); err != nil {
+			return err
+		}
+		items = append(items, it)
+	}
+	for i := range clusters {
+		c, err := derivedCluster(clusters, &clusters[i], 0)
+		if err != nil {
+			return fmt.Errorf("peripheral %s: %v", p.Name, err)
+		}
+		cpath := path + "." + rdlName(c.Name)
+		cdef, err := def.cluster(c)
+		if err != nil {
+			return fmt.Errorf("cluster %s: %v", cpath, err)
+		}
+		offset, err := ParseNumber(c.AddressOffset)
+		if err != nil {
+			return fmt.Errorf("cluster %s addressOffset: %v", cpath, err)
+		}
+		indices, inc, err := parseDim(c.Dim, c.DimIncrement, c.DimIndex)
+		if err != nil {
+			return fmt.Errorf("cluster %s: %v", cpath, err)
+		}
+		it := &item{offset: offset}
+		indent := strings.Repeat("\t", depth)
+		fmt.Fprintf(&it.text, "%sregfile {\n", indent)
+		if c.Description != "" {
+			fmt.Fprintf(&it.text, "%s\tdesc = %s;\n", indent, rdlQuote(c.Description))
+		}
+		if err := w.scope(&it.text, depth+1, p, cpath, c.Register, c.Cluster, cdef); err != nil {
+			return err
+		}
+		fmt.Fprintf(&it.text, "%s} %s;\n", indent, rdlInstances(c.Name, offset, indices, inc))
+		items = append(items, it)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].offset < items[j].offset })
+	for _, it := range items {
+		b.Write(it.text.Bytes())
+	}
+	return nil
+}
+
+// SystemRDL : Generate a SystemRDL 2.0 description of the device
+// Peripherals become addrmap types instantiated in an addrmap named after
+// the device; peripherals sharing their registers (derivedFrom) share
+// their type. Clusters become regfiles, enumerated values become enums,
+// and dim lists become arrays when their indices are the default ones.
+// What SystemRDL cannot express (interrupts, write constraints...) is
+// reported in diags.
+func (dev *Device) SystemRDL() (data []byte, diags []error, err error) {
+	top, err := dev.defaults()
+	if err != nil {
+		return nil, nil, err
+	}
+	w := &rdlWriter{dev: dev, enumNames: make(map[string]string), names: make(map[string]bool)}
+	root := w.unique(rdlName(dev.Name))
+	var maps, insts bytes.Buffer
+	types := make(map[*Registers]string)
+	descs := make(map[string]string)
+	for i := range dev.Peripherals.Peripheral {
+		p, err := dev.derivedPeripheral(&dev.Peripherals.Peripheral[i], 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		base, err := ParseNumber(p.BaseAddress)
+		if err != nil {
+			return nil, nil, fmt.Errorf("peripheral %s baseAddress: %v", p.Name, err)
+		}
+		if p.Registers == nil || len(p.Registers.Register) == 0 && len(p.Registers.Cluster) == 0 {
+			w.lossy("%s: peripheral without register is not exported", p.Name)
+			continue
+		}
+		if len(p.Interrupt) > 0 {
+			w.lossy("%s: interrupts are not exported", p.Name)
+		}
+		typ, ok := types[p.Registers]
+		if !ok {
+			name := p.HeaderStructName
+			if name == "" {
+				name = p.Name
+			}
+			typ = w.unique(rdlName(strings.NewReplacer("[%s]", "", "%s", "").Replace(name) + "_t"))
+			types[p.Registers] = typ
+			descs[typ] = p.Description
+			fmt.Fprintf(&maps, "addrmap %s {\n", typ)
+			if p.Description != "" {
+				fmt.Fprintf(&maps, "\tdesc = %s;\n", rdlQuote(p.Description))
+			}
+			if err := w.scope(&maps, 1, p, rdlName(p.Name), p.Registers.Register, p.Registers.Cluster, top.peripheral(p)); err != nil {
+				return nil, nil, err
+			}
+			maps.WriteString("};\n\n")
+		}
+		var indices []string
+		if p.Dim != 0 {
+			if indices, err = DimIndices(uint64(p.Dim), p.DimIndex); err != nil {
+				return nil, nil, fmt.Errorf("peripheral %s: %v", p.Name, err)
+			}
+		}
+		fmt.Fprintf(&insts, "\t%s %s;\n", typ, rdlInstances(p.Name, base, indices, uint64(p.DimIncrement)))
+		if p.Description != descs[typ] && indices == nil {
+			fmt.Fprintf(&insts, "\t%s->desc = %s;\n", rdlName(p.Name), rdlQuote(p.Description))
+		}
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// %s registers, generated from its SVD description\n\n", dev.Name)
+	b.Write(w.enums.Bytes())
+	b.Write(maps.Bytes())
+	fmt.Fprintf(&b, "addrmap %s {\n", root)
+	if dev.Description != "" {
+		fmt.Fprintf(&b, "\tdesc = %s;\n", rdlQuote(dev.Description))
+	}
+	b.Write(insts.Bytes())
+	b.WriteString("};\n")
+	return b.Bytes(), w.diags, nil
+}
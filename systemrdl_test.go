@@ -0,0 +1,263 @@
+package svd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const rdlTestSource = `
+enum mode_e {
+	IDLE = 2'd0 { desc = "Idle"; };
+	RUN { desc = "Running"; };
+};
+
+reg ctrl_t #(longint unsigned W = 4) {
+	name = "Control";
+	field { encode = mode_e; } MODE[2] = mode_e::RUN;
+	field { sw = r; hw = w; } LEVEL[W];
+};
+
+addrmap uart_t {
+	default sw = rw;
+	ctrl_t #(.W(6)) CR;
+	reg { field { sw = r; onread = rclr; } RXD[8]; } DR;
+	reg { field { woclr; counter; } OVR @4; } SR @0x10;
+	regfile {
+		reg { regwidth = 16; field {} DATA[15:8] = 0xA5; } DATA;
+	} CH[2] += 0x8;
+	SR->desc = "Status";
+};
+
+addrmap soc {
+	desc = "Test SoC";
+	uart_t UART @0x40000000;
+};
+`
+
+func TestReadSystemRDL(t *testing.T) {
+	dev, diags, err := ReadSystemRDL(strings.NewReader(rdlTestSource))
+	if err != nil {
+		t.Fatalf("ReadSystemRDL() error = %v", err)
+	}
+	wantDiags := []string{"UART.SR.OVR: property counter is not represented in SVD"}
+	var gotDiags []string
+	for _, d := range diags {
+		gotDiags = append(gotDiags, d.Error())
+	}
+	if !reflect.DeepEqual(gotDiags, wantDiags) {
+		t.Errorf("ReadSystemRDL() diags = %q, want %q", gotDiags, wantDiags)
+	}
+	ro, rw := AccessReadOnly, AccessReadWrite
+	clear, w1c := ReadActionClear, ModifiedWriteValuesOneToClear
+	want := []Peripheral{{
+		Name:         "UART",
+		GroupName:    "uart_t",
+		BaseAddress:  "0x40000000",
+		AddressBlock: []AddressBlock{{Offset: 0, Size: "0x1E", Usage: UsageRegisters}},
+		Registers: &Registers{
+			Cluster: []Cluster{{
+				Name:          "CH[%s]",
+				AddressOffset: "0x14",
+				Dim:           "2",
+				DimIncrement:  "0x8",
+				Register: []Register{{
+					Name: "DATA", AddressOffset: "0x0", Size: "16", Access: rw,
+					ResetValue: "0xA500", ResetMask: "0xFF00",
+					Fields: &Fields{Field: []Field{{Name: "DATA", BitOffset: "8", BitWidth: "8"}}},
+				}},
+			}},
+			Register: []Register{{
+				Name: "CR", DisplayName: "Control", Description: "Control", AddressOffset: "0x0", Size: "32",
+				Access: rw, ResetValue: "0x00000001", ResetMask: "0x00000003",
+				Fields: &Fields{Field: []Field{
+					{Name: "MODE", BitOffset: "0", BitWidth: "2", Access: &rw, EnumeratedValues: &EnumeratedValues{
+						Name: "mode_e",
+						EnumeratedValue: []EnumeratedValue{
+							{Name: "IDLE", Description: "Idle", Value: "0x0"},
+							{Name: "RUN", Description: "Running", Value: "0x1"},
+						},
+					}},
+					{Name: "LEVEL", BitOffset: "2", BitWidth: "6", Access: &ro},
+				}},
+			}, {
+				Name: "DR", AddressOffset: "0x4", Size: "32", Access: ro,
+				ResetValue: "0x00000000", ResetMask: "0x00000000",
+				Fields: &Fields{Field: []Field{{Name: "RXD", BitOffset: "0", BitWidth: "8", ReadAction: &clear}}},
+			}, {
+				Name: "SR", Description: "Status", AddressOffset: "0x10", Size: "32", Access: rw,
+				ResetValue: "0x00000000", ResetMask: "0x00000000",
+				Fields: &Fields{Field: []Field{{Name: "OVR", BitOffset: "4", BitWidth: "1", ModifiedWriteValues: &w1c}}},
+			}},
+		},
+	}}
+	if dev.Name != "soc" || dev.Description != "Test SoC" {
+		t.Errorf("ReadSystemRDL() device = %s %q, want soc \"Test SoC\"", dev.Name, dev.Description)
+	}
+	if !reflect.DeepEqual(dev.Peripherals.Peripheral, want) {
+		t.Errorf("ReadSystemRDL() peripherals = %+v, want %+v", dev.Peripherals.Peripheral, want)
+	}
+
+	periphs, err := dev.Resolve()
+	if err != nil {
+		t.Fatalf("Device.Resolve() error = %v", err)
+	}
+	var got []string
+	for _, r := range periphs[0].Registers {
+		got = append(got, r.Name+"@"+FormatHex(r.Address, 32))
+	}
+	wantRegs := []string{"CR@0x40000000", "DR@0x40000004", "SR@0x40000010",
+		"CH0_DATA@0x40000014", "CH1_DATA@0x4000001C"}
+	if !reflect.DeepEqual(got, wantRegs) {
+		t.Errorf("Device.Resolve() registers = %v, want %v", got, wantRegs)
+	}
+}
+
+func TestReadSystemRDL_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"syntax", "addrmap top { reg { field {} F; } R }", `line 1: expected ";", found "}"`},
+		{"unknown property", "addrmap top {\n foo = 1;\n};", "line 2: unknown property foo"},
+		{"wrong component", "addrmap top { regwidth = 8; };", "line 1: property regwidth does not apply to addrmap"},
+		{"undefined type", "addrmap top { my_reg R; };", "line 1: component my_reg not defined"},
+		{"field in map", "addrmap top { field {} F; };", "line 1: field cannot be instantiated in addrmap"},
+		{"overlap", "addrmap top { reg { field {} A[3:0]; field {} B[4:2]; } R; };",
+			"line 1: field B overlaps another field of register R"},
+		{"too wide", "addrmap top { reg { regwidth = 8; field {} A[9]; } R; };",
+			"line 1: field A does not fit in register R"},
+		{"parameter", "reg r_t #(longint W) { field {} F[W]; };\naddrmap top { r_t #(.X(1)) R; };",
+			"line 2: r_t has no parameter X"},
+		{"preprocessor", "`include \"x.rdl\"", "line 1: preprocessor directives are not supported"},
+		{"no root", "reg r_t { field {} F; };", "no root addrmap"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ReadSystemRDL(strings.NewReader(tt.src))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("ReadSystemRDL() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseRDLNumber(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    uint64
+		wantErr bool
+	}{
+		{"42", 42, false},
+		{"0x2A", 42, false},
+		{"1_000", 1000, false},
+		{"8'h2A", 42, false},
+		{"4'b1010", 10, false},
+		{"'d7", 7, false},
+		{"32'hDEAD_BEEF", 0xDEADBEEF, false},
+		{"2'd4", 0, true},
+		{"4'q1", 0, true},
+		{"0xZ", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := parseRDLNumber(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRDLNumber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRDLNumber() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDevice_SystemRDL_roundTrip(t *testing.T) {
+	dev := simTestDevice()
+	uart := &dev.Peripherals.Peripheral[0]
+	uart.Registers.Register[0].Fields.Field[0].EnumeratedValues = &EnumeratedValues{
+		Name: "enable_e",
+		EnumeratedValue: []EnumeratedValue{
+			{Name: "OFF", Description: "Disabled", Value: "0"},
+			{Name: "ON", Description: "Enabled", Value: "1"},
+		},
+	}
+	uart.Registers.Register = append(uart.Registers.Register, Register{
+		Name: "FIFO[%s]", AddressOffset: "0x20", Dim: "4", DimIncrement: "4", Size: "16",
+	})
+	uart.Registers.Cluster = []Cluster{{
+		Name: "CH%s", AddressOffset: "0x40", Dim: "2", DimIncrement: "0x10", DimIndex: "A,B",
+		Register: []Register{{Name: "CFG", AddressOffset: "0x4", ResetValue: "0x5"}},
+	}}
+	dev.Peripherals.Peripheral = append(dev.Peripherals.Peripheral,
+		Peripheral{Name: "UART1", DerivedFrom: "UART0", BaseAddress: "0x40001000"})
+
+	data, diags, err := dev.SystemRDL()
+	if err != nil {
+		t.Fatalf("Device.SystemRDL() error = %v", err)
+	}
+	if len(diags) != 0 {
+		t.Errorf("Device.SystemRDL() diags = %v", diags)
+	}
+	for _, line := range []string{
+		"enum enable_e {\n\tOFF = 1'h0 { desc = \"Disabled\"; };\n\tON = 1'h1 { desc = \"Enabled\"; };\n};",
+		"\t} FIFO[4] @0x20 += 0x4;",
+		"\t} CHA @0x40, CHB @0x50;",
+		"\t\tonread = rclr;",
+		"\t\tonwrite = woclr;",
+		"\tUART0_t UART0 @0x40000000;\n\tUART0_t UART1 @0x40001000;",
+	} {
+		if !strings.Contains(string(data), line) {
+			t.Errorf("Device.SystemRDL() lacks %q", line)
+		}
+	}
+
+	imported, diags, err := ReadSystemRDL(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("ReadSystemRDL() error = %v\n%s", err, data)
+	}
+	if len(diags) != 0 {
+		t.Errorf("ReadSystemRDL() diags = %v", diags)
+	}
+	want, err := dev.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := imported.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := make(map[string]ResolvedRegister)
+	for _, p := range got {
+		for _, r := range p.Registers {
+			index[r.Path()] = r
+		}
+	}
+	for _, p := range want {
+		for _, w := range p.Registers {
+			g, ok := index[w.Path()]
+			if !ok {
+				t.Errorf("register %s not imported", w.Path())
+				continue
+			}
+			if g.Address != w.Address || g.Size != w.Size || g.ResetValue&g.ResetMask != w.ResetValue&w.ResetMask {
+				t.Errorf("register %s = %+v, want %+v", w.Path(), g, w)
+			}
+			gf, wf := g.bitFields(), w.bitFields()
+			if len(gf) != len(wf) {
+				t.Errorf("register %s has %d fields, want %d", w.Path(), len(gf), len(wf))
+				continue
+			}
+			for k := range wf {
+				if gf[k].Lsb != wf[k].Lsb || gf[k].Msb != wf[k].Msb || gf[k].Access != wf[k].Access ||
+					gf[k].ModifiedWriteValues != wf[k].ModifiedWriteValues || gf[k].ReadAction != wf[k].ReadAction {
+					t.Errorf("field %s.%s = %+v, want %+v", w.Path(), wf[k].Name, gf[k], wf[k])
+				}
+			}
+		}
+	}
+	if ev := index["UART1.CR"].Fields[0].EnumeratedValues; ev == nil || len(ev.EnumeratedValue) != 2 {
+		t.Errorf("enumerated values of UART1.CR.UARTEN = %+v", ev)
+	}
+}
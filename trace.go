@@ -0,0 +1,373 @@
+package svd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseTraceTime : Parse a timestamp in seconds or with a unit (ns, us, ms, s)
+func parseTraceTime(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	sec, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	return time.Duration(math.Round(sec * float64(time.Second))), nil
+}
+
+// parseTraceOp : Tell whether an access direction is a write
+func parseTraceOp(s string) (bool, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "R", "RD", "READ":
+		return false, nil
+	case "W", "WR", "WRITE":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid access direction %q (expected R or W)", s)
+}
+
+// traceColumns : names accepted in the CSV header for each column
+var traceColumns = map[string][]string{
+	"time":    {"time", "timestamp", "t"},
+	"address": {"address", "addr"},
+	"value":   {"value", "data"},
+	"op":      {"op", "rw", "r/w", "dir", "direction"},
+	"width":   {"width", "size", "bits"},
+}
+
+// ReadTraceCSV : Parse bus accesses from CSV
+// Columns are timestamp, address, value, R/W and an optional width in
+// bits (32 by default). A header row may name the columns in any order.
+// Timestamps are in seconds unless a unit is given (ns, us, ms, s).
+func ReadTraceCSV(r io.Reader) (accesses []BusAccess, err error) {
+	rd := csv.NewReader(r)
+	rd.FieldsPerRecord = -1
+	rd.TrimLeadingSpace = true
+	rd.Comment = '#'
+	cols := map[string]int{"time": 0, "address": 1, "value": 2, "op": 3, "width": 4}
+	for first := true; ; first = false {
+		rec, err := rd.Read()
+		if err == io.EOF {
+			return accesses, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			if _, err := ParseNumber(rec[0]); err != nil {
+				if _, err := parseTraceTime(rec[0]); err != nil {
+					cols = map[string]int{}
+					for i, name := range rec {
+						for col, names := range traceColumns {
+							for _, n := range names {
+								if strings.EqualFold(strings.TrimSpace(name), n) {
+									cols[col] = i
+								}
+							}
+						}
+					}
+					continue
+				}
+			}
+		}
+		get := func(col string) string {
+			if i, ok := cols[col]; ok && i < len(rec) {
+				return rec[i]
+			}
+			return ""
+		}
+		a, err := parseTraceRecord(get("time"), get("address"), get("value"), get("op"), get("width"))
+		if err != nil {
+			line, _ := rd.FieldPos(0)
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		accesses = append(accesses, a)
+	}
+}
+
+// parseTraceRecord : Build an access from its text columns
+func parseTraceRecord(t, address, value, op, width string) (a BusAccess, err error) {
+	if a.Time, err = parseTraceTime(t); err != nil {
+		return
+	}
+	if a.Address, err = ParseNumber(address); err != nil {
+		return a, fmt.Errorf("address: %v", err)
+	}
+	if a.Value, err = ParseNumber(value); err != nil {
+		return a, fmt.Errorf("value: %v", err)
+	}
+	if a.Write, err = parseTraceOp(op); err != nil {
+		return
+	}
+	a.Width = 32
+	if strings.TrimSpace(width) != "" {
+		w, err := ParseNumber(width)
+		if err != nil {
+			return a, fmt.Errorf("width: %v", err)
+		}
+		a.Width = uint(w)
+	}
+	return
+}
+
+// ReadTraceJSON : Parse bus accesses from JSON lines
+// Each line is an object with "time", "address", "value", "op" and an
+// optional "width". Numbers can be given as JSON numbers or strings.
+func ReadTraceJSON(r io.Reader) (accesses []BusAccess, err error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &obj); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		get := func(col string) string {
+			for _, name := range traceColumns[col] {
+				switch v := obj[name].(type) {
+				case string:
+					return v
+				case float64:
+					if col == "time" {
+						return strconv.FormatFloat(v, 'g', -1, 64)
+					}
+					return strconv.FormatUint(uint64(v), 10)
+				}
+			}
+			return ""
+		}
+		a, err := parseTraceRecord(get("time"), get("address"), get("value"), get("op"), get("width"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		accesses = append(accesses, a)
+	}
+	return accesses, sc.Err()
+}
+
+// FieldChange : a field whose value differs from the previous access
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   uint64 `json:"old"`
+	New   uint64 `json:"new"`
+
+	// Name of the enumerated value matching New, if any.
+	Enum string `json:"enum,omitempty"`
+}
+
+// TraceEvent : a bus access decoded through the device description
+type TraceEvent struct {
+	BusAccess
+
+	// "peripheral.register" accessed, empty if the address is unmapped.
+	Register string
+
+	// Value of the whole register after the access.
+	RegisterValue uint64
+
+	// Fields whose value changed since the previous access.
+	Changes []FieldChange
+
+	// Anomalies of the access.
+	Warnings []string
+
+	// Bit-width of the register.
+	size uint
+}
+
+// TraceDecoder : decode bus accesses into register and field events
+// The decoder keeps the last known value of every register, starting
+// with their reset value. Reads sample the value, writes update it
+// through the modifiedWriteValues semantics, leaving read-only fields
+// unchanged.
+type TraceDecoder struct {
+	endian    EndianType
+	registers []ResolvedRegister
+	last      map[string]uint64
+}
+
+// NewTraceDecoder : Create a TraceDecoder for a device
+func NewTraceDecoder(dev *Device) (*TraceDecoder, error) {
+	periphs, err := dev.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	d := TraceDecoder{endian: dev.Cpu.Endian, last: make(map[string]uint64)}
+	for _, p := range periphs {
+		for _, r := range p.Registers {
+			d.registers = append(d.registers, r)
+			d.last[r.Path()] = r.ResetValue & r.ResetMask
+		}
+	}
+	return &d, nil
+}
+
+// Decode : Decode an access
+func (d *TraceDecoder) Decode(a BusAccess) (ev TraceEvent) {
+	ev.BusAccess = a
+	bytes := uint64(a.Width / 8)
+	var reg *ResolvedRegister
+	var shift uint
+	for i := range d.registers {
+		r := &d.registers[i]
+		size := uint64(r.Size / 8)
+		if a.Address < r.Address || a.Address >= r.Address+size {
+			continue
+		}
+		// among alternate registers, prefer the one allowing the access
+		allowed := r.Access != AccessReadOnly
+		if !a.Write {
+			allowed = r.Access != AccessWriteOnly && r.Access != AccessWriteOnce
+		}
+		if reg != nil && !allowed {
+			continue
+		}
+		// an access wider than the register is truncated to it
+		offset := a.Address - r.Address
+		if d.endian == EndianBig && offset+bytes <= size {
+			offset = size - bytes - offset
+		}
+		reg, shift = r, uint(offset*8)
+		if allowed {
+			break
+		}
+	}
+	if reg == nil {
+		ev.Warnings = append(ev.Warnings, "no register at this address")
+		return
+	}
+	ev.Register = reg.Path()
+	switch {
+	case a.Write && reg.Access == AccessReadOnly:
+		ev.Warnings = append(ev.Warnings, "write to read-only register")
+	case !a.Write && (reg.Access == AccessWriteOnly || reg.Access == AccessWriteOnce):
+		ev.Warnings = append(ev.Warnings, "read of write-only register")
+	}
+	mask := bitMask(a.Width) << shift & reg.Mask()
+	old := d.last[ev.Register]
+	value := old&^mask | (a.Value<<shift)&mask
+	if a.Write {
+		// the register takes the written value through the write semantics
+		written := value
+		value = old
+		for _, f := range reg.bitFields() {
+			if f.Mask()&mask == 0 {
+				continue
+			}
+			if f.Access == AccessReadOnly {
+				if reg.Access != AccessReadOnly {
+					ev.Warnings = append(ev.Warnings, fmt.Sprintf("write to read-only field %s", f.Name))
+				}
+				continue
+			}
+			value = f.writeBits(value, written, mask)
+		}
+	}
+	for _, f := range reg.Fields {
+		if f.Mask()&mask == 0 || f.Extract(old) == f.Extract(value) {
+			continue
+		}
+		c := FieldChange{Field: f.Name, Old: f.Extract(old), New: f.Extract(value)}
+		if e := f.Enum(c.New); e != nil {
+			c.Enum = e.Name
+		}
+		ev.Changes = append(ev.Changes, c)
+	}
+	d.last[ev.Register] = value
+	ev.RegisterValue = value
+	ev.size = reg.Size
+	return
+}
+
+// DecodeTrace : Decode a sequence of bus accesses through the device
+func (dev *Device) DecodeTrace(accesses []BusAccess) ([]TraceEvent, error) {
+	d, err := NewTraceDecoder(dev)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]TraceEvent, 0, len(accesses))
+	for _, a := range accesses {
+		events = append(events, d.Decode(a))
+	}
+	return events, nil
+}
+
+// WriteTraceText : Write decoded events as human readable text
+func WriteTraceText(w io.Writer, events []TraceEvent) error {
+	bw := bufio.NewWriter(w)
+	for _, ev := range events {
+		dir := "R"
+		if ev.Write {
+			dir = "W"
+		}
+		target := ev.Register
+		if target == "" {
+			target = fmt.Sprintf("0x%08X", ev.Address)
+		}
+		fmt.Fprintf(bw, "%12.9f %s %-24s 0x%0*X", ev.Time.Seconds(), dir, target, int(ev.Width+3)/4, ev.Value)
+		for _, c := range ev.Changes {
+			fmt.Fprintf(bw, "  %s: %d -> %d", c.Field, c.Old, c.New)
+			if c.Enum != "" {
+				fmt.Fprintf(bw, " (%s)", c.Enum)
+			}
+		}
+		bw.WriteString("\n")
+		for _, warn := range ev.Warnings {
+			fmt.Fprintf(bw, "%12s   warning: %s\n", "", warn)
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteTraceJSON : Write decoded events as JSON lines
+func WriteTraceJSON(w io.Writer, events []TraceEvent) error {
+	enc := json.NewEncoder(w)
+	for _, ev := range events {
+		out := struct {
+			Time          float64       `json:"time"`
+			Op            string        `json:"op"`
+			Address       string        `json:"address"`
+			Width         uint          `json:"width"`
+			Value         string        `json:"value"`
+			Register      string        `json:"register,omitempty"`
+			RegisterValue string        `json:"registerValue,omitempty"`
+			Changes       []FieldChange `json:"changes,omitempty"`
+			Warnings      []string      `json:"warnings,omitempty"`
+		}{
+			Time:     ev.Time.Seconds(),
+			Op:       "R",
+			Address:  fmt.Sprintf("0x%08X", ev.Address),
+			Width:    ev.Width,
+			Value:    FormatHex(ev.Value, ev.Width),
+			Register: ev.Register,
+			Changes:  ev.Changes,
+			Warnings: ev.Warnings,
+		}
+		if ev.Write {
+			out.Op = "W"
+		}
+		if ev.Register != "" {
+			out.RegisterValue = FormatHex(ev.RegisterValue, ev.size)
+		}
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package svd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadTraceCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []BusAccess
+		wantErr bool
+	}{
+		{
+			name: "default columns",
+			csv:  "0.5,0x40000000,0x1,W\n1us,0x40000004,3,r,8\n",
+			want: []BusAccess{
+				{Time: 500 * time.Millisecond, Address: 0x40000000, Width: 32, Value: 1, Write: true},
+				{Time: time.Microsecond, Address: 0x40000004, Width: 8, Value: 3},
+			},
+		},
+		{
+			name: "header",
+			csv:  "rw,addr,data,timestamp\nW,0x40000000,0x1,0\n",
+			want: []BusAccess{{Address: 0x40000000, Width: 32, Value: 1, Write: true}},
+		},
+		{
+			name:    "bad direction",
+			csv:     "0,0x40000000,0x1,X\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadTraceCSV(strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadTraceCSV() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadTraceCSV() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// comments and quoted newlines are counted in the reported line
+	_, err := ReadTraceCSV(strings.NewReader("# trace\n0,0x40000000,\"0x1\n\",W\n0,0x40000000,0x1,X\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 4:") {
+		t.Errorf("ReadTraceCSV() error = %v, want line 4", err)
+	}
+}
+
+// traceTestDevice : the simulator test device with an enumerated field,
+// a write-only and a read-only register
+func traceTestDevice() *Device {
+	dev := simTestDevice()
+	regs := &dev.Peripherals.Peripheral[0].Registers.Register
+	(*regs)[0].Fields.Field[0].EnumeratedValues = &EnumeratedValues{EnumeratedValue: []EnumeratedValue{
+		{Name: "DISABLED", Value: "0"},
+		{Name: "ENABLED", Value: "1"},
+	}}
+	*regs = append(*regs,
+		Register{Name: "TXD", AddressOffset: "0x14", Access: AccessWriteOnly},
+		Register{Name: "ID", AddressOffset: "0x18", Access: AccessReadOnly, ResetValue: "0x11"},
+	)
+	return dev
+}
+
+func TestDevice_DecodeTrace(t *testing.T) {
+	dev := traceTestDevice()
+	accesses, err := ReadTraceJSON(strings.NewReader(`{"time":0,"address":"0x40000000","value":"0x301","op":"W"}
+{"time":1,"address":"0x40000004","value":3,"op":"R"}
+{"time":2,"address":"0x40000004","value":2,"op":"W"}
+{"time":3,"address":"0x40000004","value":0,"op":"W"}
+{"time":4,"address":"0x40000018","value":5,"op":"W"}
+{"time":5,"address":"0x40000014","value":0,"op":"R"}
+{"time":6,"address":"0x40000100","value":0,"op":"R"}
+`))
+	if err != nil {
+		t.Fatalf("ReadTraceJSON() error = %v", err)
+	}
+	events, err := dev.DecodeTrace(accesses)
+	if err != nil {
+		t.Fatalf("Device.DecodeTrace() error = %v", err)
+	}
+	want := []struct {
+		register string
+		value    uint64
+		changes  []FieldChange
+		warnings []string
+	}{
+		{register: "UART0.CR", value: 0x301, changes: []FieldChange{{Field: "UARTEN", Old: 0, New: 1, Enum: "ENABLED"}}},
+		{register: "UART0.SR", value: 0x3, changes: []FieldChange{{Field: "OVR", Old: 0, New: 1}}},
+		{register: "UART0.SR", value: 0x1, changes: []FieldChange{{Field: "OVR", Old: 1, New: 0}},
+			warnings: []string{"write to read-only field TXEMPTY"}},
+		{register: "UART0.SR", value: 0x1, warnings: []string{"write to read-only field TXEMPTY"}},
+		{register: "UART0.ID", value: 0x11, warnings: []string{"write to read-only register"}},
+		{register: "UART0.TXD", warnings: []string{"read of write-only register"}},
+		{warnings: []string{"no register at this address"}},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Device.DecodeTrace() = %d events, want %d", len(events), len(want))
+	}
+	for i, w := range want {
+		ev := events[i]
+		if ev.Register != w.register || ev.RegisterValue != w.value || !reflect.DeepEqual(ev.Changes, w.changes) || !reflect.DeepEqual(ev.Warnings, w.warnings) {
+			t.Errorf("event %d = %s 0x%X %v %q, want %s 0x%X %v %q", i, ev.Register, ev.RegisterValue, ev.Changes, ev.Warnings,
+				w.register, w.value, w.changes, w.warnings)
+		}
+	}
+
+	var text strings.Builder
+	if err := WriteTraceText(&text, events[:3]); err != nil {
+		t.Fatalf("WriteTraceText() error = %v", err)
+	}
+	wantText := " 0.000000000 W UART0.CR                 0x00000301  UARTEN: 0 -> 1 (ENABLED)\n" +
+		" 1.000000000 R UART0.SR                 0x00000003  OVR: 0 -> 1\n" +
+		" 2.000000000 W UART0.SR                 0x00000002  OVR: 1 -> 0\n" +
+		"               warning: write to read-only field TXEMPTY\n"
+	if text.String() != wantText {
+		t.Errorf("WriteTraceText() = %q, want %q", text.String(), wantText)
+	}
+	var js strings.Builder
+	if err := WriteTraceJSON(&js, events[2:3]); err != nil {
+		t.Fatalf("WriteTraceJSON() error = %v", err)
+	}
+	wantJSON := `{"time":2,"op":"W","address":"0x40000004","width":32,"value":"0x00000002","register":"UART0.SR",` +
+		`"registerValue":"0x00000001","changes":[{"field":"OVR","old":1,"new":0}],"warnings":["write to read-only field TXEMPTY"]}` + "\n"
+	if js.String() != wantJSON {
+		t.Errorf("WriteTraceJSON() = %q, want %q", js.String(), wantJSON)
+	}
+}
+
+func TestDevice_DecodeTrace_narrowWrite(t *testing.T) {
+	dev := traceTestDevice()
+	regs := &dev.Peripherals.Peripheral[0].Registers.Register
+	*regs = append(*regs, Register{Name: "IFR", AddressOffset: "0x1C", ModifiedWriteValues: ModifiedWriteValuesOneToClear,
+		Fields: &Fields{Field: []Field{{Name: "FLAGS", BitRange: "[15:0]"}}}})
+	events, err := dev.DecodeTrace([]BusAccess{
+		{Address: 0x4000001C, Width: 32, Value: 0xFFFF},
+		{Address: 0x4000001D, Width: 8, Value: 0x0F, Write: true},
+	})
+	if err != nil {
+		t.Fatalf("Device.DecodeTrace() error = %v", err)
+	}
+	ev := events[1]
+	want := []FieldChange{{Field: "FLAGS", Old: 0xFFFF, New: 0xF0FF}}
+	if ev.RegisterValue != 0xF0FF || !reflect.DeepEqual(ev.Changes, want) {
+		t.Errorf("byte write = 0x%X %v, want 0xF0FF %v", ev.RegisterValue, ev.Changes, want)
+	}
+}
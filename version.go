@@ -0,0 +1,211 @@
+package svd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultSchemaVersion : the schema version of new and migrated devices
+const DefaultSchemaVersion = "1.3.6"
+
+// cpuNameCM0PLUS : the deprecated name of the Cortex-M0+
+const cpuNameCM0PLUS CpuName = "CM0PLUS"
+
+// SchemaElement : an element of a device which a schema version does not define
+type SchemaElement struct {
+	// Path of the element: the element name, prefixed by the names of the
+	// peripheral, cluster, register and field holding it.
+	Path string
+
+	// First schema version defining the element.
+	Since string
+}
+
+// String : Describe the element
+func (e SchemaElement) String() string {
+	return fmt.Sprintf("%s (schema version %s)", e.Path, e.Since)
+}
+
+// Migration : a change made to upgrade a device
+type Migration struct {
+	// Path of the changed element, as in SchemaElement.
+	Path string
+
+	Change string
+}
+
+// String : Describe the change
+func (m Migration) String() string {
+	return m.Path + ": " + m.Change
+}
+
+// laterElements : Get the elements of the device a schema version does not define
+// Processor names a version does not define count as such elements. With
+// drop, these elements are removed from the device, and processor names
+// are replaced by other.
+func (dev *Device) laterElements(version string, drop bool) (later []SchemaElement) {
+	check := func(used bool, since, path string, clear func()) {
+		if !used || since <= version {
+			return
+		}
+		later = append(later, SchemaElement{Path: path, Since: since})
+		if drop {
+			clear()
+		}
+	}
+	var fields func(scope string, fields []Field)
+	fields = func(scope string, fields []Field) {
+		for i := range fields {
+			f := &fields[i]
+			path := scope + "." + f.Name
+			check(f.DimArrayIndex != nil, SchemaVersion13, path+"/dimArrayIndex", func() { f.DimArrayIndex = nil })
+			if ev := f.EnumeratedValues; ev != nil {
+				check(ev.HeaderEnumName != "", SchemaVersion13, path+"/enumeratedValues/headerEnumName", func() { ev.HeaderEnumName = "" })
+			}
+		}
+	}
+	var walk func(scope string, registers []Register, clusters []Cluster)
+	walk = func(scope string, registers []Register, clusters []Cluster) {
+		for i := range registers {
+			r := &registers[i]
+			path := scope + "." + r.Name
+			check(r.DimArrayIndex != nil, SchemaVersion13, path+"/dimArrayIndex", func() { r.DimArrayIndex = nil })
+			check(r.Protection != "", SchemaVersion13, path+"/protection", func() { r.Protection = "" })
+			check(r.DataType != "", SchemaVersion12, path+"/dataType", func() { r.DataType = "" })
+			if r.Fields != nil {
+				fields(path, r.Fields.Field)
+			}
+		}
+		for i := range clusters {
+			c := &clusters[i]
+			path := scope + "." + c.Name
+			check(c.DimArrayIndex != nil, SchemaVersion13, path+"/dimArrayIndex", func() { c.DimArrayIndex = nil })
+			check(c.Protection != "", SchemaVersion13, path+"/protection", func() { c.Protection = "" })
+			walk(path, c.Register, c.Cluster)
+		}
+	}
+
+	cpu := &dev.Cpu
+	since := svdValuesSince["cpuNameType"][string(cpu.Name)]
+	check(since != "", since, "cpu/name", func() { cpu.Name = CpuNameother })
+	check(cpu.FpuDP, SchemaVersion12, "cpu/fpuDP", func() { cpu.FpuDP = false })
+	check(cpu.DspPresent, SchemaVersion13, "cpu/dspPresent", func() { cpu.DspPresent = false })
+	check(cpu.IcachePresent, SchemaVersion12, "cpu/icachePresent", func() { cpu.IcachePresent = false })
+	check(cpu.DcachePresent, SchemaVersion12, "cpu/dcachePresent", func() { cpu.DcachePresent = false })
+	check(cpu.ItcmPresent, SchemaVersion12, "cpu/itcmPresent", func() { cpu.ItcmPresent = false })
+	check(cpu.DtcmPresent, SchemaVersion12, "cpu/dtcmPresent", func() { cpu.DtcmPresent = false })
+	check(cpu.VtorPresent, SchemaVersion12, "cpu/vtorPresent", func() { cpu.VtorPresent = false })
+	check(cpu.DeviceNumInterrupts != 0, SchemaVersion13, "cpu/deviceNumInterrupts", func() { cpu.DeviceNumInterrupts = 0 })
+	check(cpu.SauNumRegions != 0, SchemaVersion13, "cpu/sauNumRegions", func() { cpu.SauNumRegions = 0 })
+	check(cpu.SauRegionsConfig != nil, SchemaVersion13, "cpu/sauRegionsConfig", func() { cpu.SauRegionsConfig = nil })
+	check(dev.Protection != "", SchemaVersion13, "protection", func() { dev.Protection = "" })
+	for i := range dev.Peripherals.Peripheral {
+		p := &dev.Peripherals.Peripheral[i]
+		check(p.DimArrayIndex != nil, SchemaVersion13, p.Name+"/dimArrayIndex", func() { p.DimArrayIndex = nil })
+		check(p.Protection != "", SchemaVersion13, p.Name+"/protection", func() { p.Protection = "" })
+		for j := range p.AddressBlock {
+			ab := &p.AddressBlock[j]
+			check(ab.Protection != "", SchemaVersion13, p.Name+"/addressBlock/protection", func() { ab.Protection = "" })
+		}
+		if p.Registers != nil {
+			walk(p.Name, p.Registers.Register, p.Registers.Cluster)
+		}
+	}
+	return later
+}
+
+// checkSchemaVersion : Check that the device only uses elements its schema version defines
+// Devices of an unknown schema version are not checked.
+func (dev *Device) checkSchemaVersion() error {
+	version, ok := schemaVersionOf(dev.SchemaVersion)
+	if !ok {
+		return nil
+	}
+	later := dev.laterElements(version, false)
+	if len(later) == 0 {
+		return nil
+	}
+	names := make([]string, len(later))
+	for i, e := range later {
+		names[i] = e.String()
+	}
+	return fmt.Errorf("schema version %s does not define %s", dev.SchemaVersion, strings.Join(names, ", "))
+}
+
+// Downgrade : Make the device conform to an older schema version
+// The elements the version does not define are removed from the device
+// and returned, then the device takes the version. A processor name the
+// version does not define, such as CM33 before 1.3, becomes other.
+func (dev *Device) Downgrade(version string) (dropped []SchemaElement, err error) {
+	known, ok := schemaVersionOf(version)
+	if !ok {
+		return nil, fmt.Errorf("unknown schema version %q", version)
+	}
+	dropped = dev.laterElements(known, true)
+	dev.SchemaVersion = version
+	return dropped, nil
+}
+
+// defaultNvicPrioBits : Get the priority bits CMSIS-Core assumes for a processor
+func defaultNvicPrioBits(name CpuName) string {
+	switch name {
+	case CpuNameCM0, CpuNameCM0p, CpuNameCM1, CpuNameSC000, CpuNameCM23:
+		return "2"
+	}
+	return "3"
+}
+
+// Migrate : Upgrade the device to a newer schema version
+// The version is DefaultSchemaVersion if empty. Deprecated constructs are
+// converted, such as the CM0PLUS processor name, and the elements the
+// schema requires but older files may omit are filled: the schema location,
+// the device version, description, addressUnitBits and width, and the
+// revision, endian and nvicPrioBits of a named processor. The changes made
+// are returned.
+func (dev *Device) Migrate(version string) (changes []Migration, err error) {
+	if version == "" {
+		version = DefaultSchemaVersion
+	}
+	known, ok := schemaVersionOf(version)
+	if !ok {
+		return nil, fmt.Errorf("unknown schema version %q", version)
+	}
+	if current, ok := schemaVersionOf(dev.SchemaVersion); ok && current > known {
+		return nil, fmt.Errorf("cannot migrate schema version %s to %s, use Downgrade", dev.SchemaVersion, version)
+	}
+	fill := func(path string, value *string, def string) {
+		if *value == "" {
+			*value = def
+			changes = append(changes, Migration{Path: path, Change: fmt.Sprintf("set to %q", def)})
+		}
+	}
+	def := NewDevice(dev.Name)
+	fill("xmlns:xs", &dev.Xs, def.Xs)
+	fill("xs:noNamespaceSchemaLocation", &dev.NoNamespaceSchemaLocation, def.NoNamespaceSchemaLocation)
+	fill("version", &dev.Version, "1.0")
+	fill("description", &dev.Description, dev.Name)
+	if dev.AddressUnitBits == 0 {
+		dev.AddressUnitBits = def.AddressUnitBits
+		changes = append(changes, Migration{Path: "addressUnitBits", Change: fmt.Sprintf("set to %d", def.AddressUnitBits)})
+	}
+	if dev.Width == 0 {
+		dev.Width = def.Width
+		changes = append(changes, Migration{Path: "width", Change: fmt.Sprintf("set to %d", def.Width)})
+	}
+	if cpu := &dev.Cpu; cpu.Name != "" {
+		if cpu.Name == cpuNameCM0PLUS {
+			cpu.Name = CpuNameCM0p
+			changes = append(changes, Migration{Path: "cpu/name", Change: fmt.Sprintf("%s replaced by %s", cpuNameCM0PLUS, CpuNameCM0p)})
+		}
+		fill("cpu/revision", &cpu.Revision, "r0p0")
+		endian := string(cpu.Endian)
+		fill("cpu/endian", &endian, string(EndianLittle))
+		cpu.Endian = EndianType(endian)
+		fill("cpu/nvicPrioBits", &cpu.NvicPrioBits, defaultNvicPrioBits(cpu.Name))
+	}
+	if dev.SchemaVersion != version {
+		changes = append(changes, Migration{Path: "schemaVersion", Change: fmt.Sprintf("%q replaced by %q", dev.SchemaVersion, version)})
+		dev.SchemaVersion = version
+	}
+	return changes, nil
+}
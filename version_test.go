@@ -0,0 +1,114 @@
+package svd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDevice_Downgrade(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    []SchemaElement
+		wantErr bool
+	}{
+		{
+			name:    "1.3",
+			version: "1.3.6",
+		},
+		{
+			name:    "1.2",
+			version: "1.2",
+			want: []SchemaElement{
+				{Path: "cpu/name", Since: "1.3"},
+				{Path: "cpu/sauNumRegions", Since: "1.3"},
+				{Path: "cpu/sauRegionsConfig", Since: "1.3"},
+				{Path: "UART0/protection", Since: "1.3"},
+				{Path: "UART1/protection", Since: "1.3"},
+			},
+		},
+		{
+			name:    "1.1",
+			version: "1.1",
+			want: []SchemaElement{
+				{Path: "cpu/name", Since: "1.3"},
+				{Path: "cpu/fpuDP", Since: "1.2"},
+				{Path: "cpu/sauNumRegions", Since: "1.3"},
+				{Path: "cpu/sauRegionsConfig", Since: "1.3"},
+				{Path: "UART0/protection", Since: "1.3"},
+				{Path: "UART0.CTRL/dataType", Since: "1.2"},
+				{Path: "UART1/protection", Since: "1.3"},
+			},
+		},
+		{
+			name:    "unknown",
+			version: "0.9",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := sauTestDevice()
+			dev.Cpu.FpuDP = true
+			dev.Peripherals.Peripheral[0].Registers = &Registers{Register: []Register{
+				{Name: "CTRL", AddressOffset: "0x0", DataType: DataType("uint32_t")},
+			}}
+			if _, err := dev.SVD(); err != nil {
+				t.Fatalf("Device.SVD() error = %v", err)
+			}
+			dev.SchemaVersion = tt.version
+			if _, err := dev.SVD(); (err != nil) != (len(tt.want) > 0) {
+				t.Errorf("Device.SVD() as %s error = %v", tt.version, err)
+			}
+			got, err := dev.Downgrade(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Device.Downgrade() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Device.Downgrade() = %v, want %v", got, tt.want)
+			}
+			if tt.wantErr {
+				return
+			}
+			if _, err := dev.SVD(); err != nil {
+				t.Errorf("Device.SVD() after Downgrade() error = %v", err)
+			}
+			if len(tt.want) > 0 && dev.Cpu.Name != CpuNameother {
+				t.Errorf("Device.Downgrade() cpu name = %s, want %s", dev.Cpu.Name, CpuNameother)
+			}
+		})
+	}
+}
+
+func TestDevice_Migrate(t *testing.T) {
+	dev := Device{
+		SchemaVersion: "1.1",
+		Name:          "OLD",
+		Width:         32,
+		Cpu:           Cpu{Name: "CM0PLUS", Revision: "r0p1"},
+	}
+	got, err := dev.Migrate("")
+	if err != nil {
+		t.Fatalf("Device.Migrate() error = %v", err)
+	}
+	want := []Migration{
+		{Path: "xmlns:xs", Change: `set to "http://www.w3.org/2001/XMLSchema-instance"`},
+		{Path: "xs:noNamespaceSchemaLocation", Change: `set to "CMSIS-SVD.xsd"`},
+		{Path: "version", Change: `set to "1.0"`},
+		{Path: "description", Change: `set to "OLD"`},
+		{Path: "addressUnitBits", Change: "set to 8"},
+		{Path: "cpu/name", Change: "CM0PLUS replaced by CM0+"},
+		{Path: "cpu/endian", Change: `set to "little"`},
+		{Path: "cpu/nvicPrioBits", Change: `set to "2"`},
+		{Path: "schemaVersion", Change: `"1.1" replaced by "1.3.6"`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Device.Migrate() = %v, want %v", got, want)
+	}
+	if got, _ := dev.Migrate(""); got != nil {
+		t.Errorf("Device.Migrate() again = %v, want nil", got)
+	}
+	if _, err := dev.Migrate("1.2"); err == nil {
+		t.Errorf("Device.Migrate() to 1.2 succeeded, want an error")
+	}
+}